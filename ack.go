@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ackEmoji is the reaction that acknowledges a reminder.
+const ackEmoji = "✅"
+
+// maxEscalateMin caps /escalate's window at a day.
+const maxEscalateMin = 24 * 60
+
+// ackTimers holds the pending escalation for each unacknowledged message.
+var (
+	ackTimers   = map[string]*time.Timer{}
+	ackTimersMu sync.Mutex
+)
+
+// awaitAck marks msg, a fire of r, as waiting for a ✅ and arms the
+// escalation. The wait is persisted so it survives a restart.
+func awaitAck(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder, msg *discordgo.Message) {
+	if err := s.MessageReactionAdd(msg.ChannelID, msg.ID, ackEmoji); err != nil {
+		log.Printf("ack reaction on reminder %d: %v", r.ID, err)
+	}
+	due := clk.Now().Add(time.Duration(r.EscalateMin) * time.Minute)
+	if _, err := db.Exec(ctx,
+		`INSERT INTO pending_acks (message_id, reminder_id, channel_id, due_at) VALUES ($1,$2,$3,$4)`,
+		msg.ID, r.ID, msg.ChannelID, due); err != nil {
+		log.Printf("track ack for reminder %d: %v", r.ID, err)
+		return
+	}
+	armEscalation(db, clk, s, msg.ID, r.ID, due)
+}
+
+// armEscalation re-sends the reminder once at due unless the message has
+// been acknowledged by then.
+func armEscalation(db *pgxpool.Pool, clk Clock, s *discordgo.Session, messageID string, reminderID int, due time.Time) {
+	t := scheduleOneOff(clk, due, func() {
+		ackTimersMu.Lock()
+		delete(ackTimers, messageID)
+		ackTimersMu.Unlock()
+
+		ctx, cancel := dbCtx()
+		defer cancel()
+
+		// claiming the row means an ack that raced us hasn't happened
+		tag, err := db.Exec(ctx, `DELETE FROM pending_acks WHERE message_id=$1`, messageID)
+		if err != nil || tag.RowsAffected() == 0 {
+			return
+		}
+		r, err := loadReminder(ctx, db, reminderID)
+		if err != nil || !r.Active {
+			return
+		}
+		d := loadDelivery(ctx, db, s, r, clk.Now())
+		d.prefix = "(still waiting) "
+		if _, err := sendReminder(s, r, d); err != nil {
+			log.Printf("escalate reminder %d: %v", r.ID, err)
+		}
+	})
+
+	ackTimersMu.Lock()
+	ackTimers[messageID] = t
+	ackTimersMu.Unlock()
+}
+
+// acknowledge cancels the escalation for messageID, if it has one.
+func acknowledge(ctx context.Context, db *pgxpool.Pool, messageID string) error {
+	ackTimersMu.Lock()
+	if t, ok := ackTimers[messageID]; ok {
+		t.Stop()
+		delete(ackTimers, messageID)
+	}
+	ackTimersMu.Unlock()
+
+	_, err := db.Exec(ctx, `DELETE FROM pending_acks WHERE message_id=$1`, messageID)
+	return err
+}
+
+// onReactionAdd treats a ✅ from anyone the reminder pinged as the ack,
+// on the latest fire of a reminder or one still waiting to escalate.
+func onReactionAdd(db *pgxpool.Pool, clk Clock) func(*discordgo.Session, *discordgo.MessageReactionAdd) {
+	return func(s *discordgo.Session, ev *discordgo.MessageReactionAdd) {
+		if ev.Emoji.Name != ackEmoji || ev.UserID == s.State.User.ID {
+			return
+		}
+		ackTimersMu.Lock()
+		_, pending := ackTimers[ev.MessageID]
+		ackTimersMu.Unlock()
+
+		ctx, cancel := dbCtx()
+		defer cancel()
+
+		var reminderID *int
+		if err := db.QueryRow(ctx,
+			`SELECT COALESCE(
+			        (SELECT reminder_id FROM pending_acks WHERE message_id=$1),
+			        (SELECT id FROM reminders WHERE last_message_id=$1 LIMIT 1))`,
+			ev.MessageID).Scan(&reminderID); err != nil || reminderID == nil {
+			return
+		}
+		r, err := loadReminder(ctx, db, *reminderID)
+		if err != nil || !slices.Contains(r.mentions(), ev.UserID) {
+			return
+		}
+		if pending {
+			if err := acknowledge(ctx, db, ev.MessageID); err != nil {
+				log.Printf("ack reminder %d: %v", r.ID, err)
+			}
+		}
+		if err := recordAck(ctx, db, clk, r); err != nil {
+			log.Printf("streak for reminder %d: %v", r.ID, err)
+		}
+	}
+}
+
+// restoreAcks re-arms escalations that were pending when the bot stopped.
+// Ones that came due while it was down are sent straight away.
+func restoreAcks(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := db.Query(ctx,
+		`SELECT message_id, reminder_id, due_at FROM pending_acks`)
+	if err != nil {
+		log.Printf("restore acks: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageID string
+		var reminderID int
+		var due time.Time
+		if err := rows.Scan(&messageID, &reminderID, &due); err != nil {
+			continue
+		}
+		armEscalation(db, clk, s, messageID, reminderID, due)
+	}
+}
+
+// handleEscalate sets how long a reminder waits for a ✅ before pinging
+// once more. Owner only.
+func handleEscalate(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var minutes int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "minutes":
+			minutes = int(opt.IntValue())
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET escalate_min = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, minutes).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the escalation", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if minutes == 0 {
+		respond(s, ic, fmt.Sprintf("Reminder %d won't re-ping any more.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("If nobody reacts %s to reminder %d within %d minutes, I'll ping once more.", ackEmoji, id, minutes))
+}
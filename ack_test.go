@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEscalation(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM pending_acks WHERE message_id LIKE 'test-ack-%'`)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-ack'`)
+	})
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, escalate_min)
+		 VALUES ('test-ack', 'c1', 'g1', 'take your meds', 9, 0, 'UTC', true, 10) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := func(msg string) bool {
+		var n int
+		if err := db.QueryRow(ctx, `SELECT count(*) FROM pending_acks WHERE message_id=$1`, msg).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n > 0
+	}
+
+	t.Run("fires without an ack", func(t *testing.T) {
+		s, f := newFakeDiscord(nil)
+		if _, err := db.Exec(ctx,
+			`INSERT INTO pending_acks (message_id, reminder_id, channel_id, due_at) VALUES ('test-ack-1', $1, 'c1', now())`, id); err != nil {
+			t.Fatal(err)
+		}
+		armEscalation(db, realClock{}, s, "test-ack-1", id, time.Now())
+
+		deadline := time.Now().Add(2 * time.Second)
+		for len(f.posts(t)) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		posts := f.posts(t)
+		if len(posts) != 1 || !strings.HasPrefix(posts[0].Content, "(still waiting) ") {
+			t.Fatalf("posts = %+v, want one re-ping", posts)
+		}
+		if pending("test-ack-1") {
+			t.Error("the wait is still pending after escalating")
+		}
+	})
+
+	t.Run("cancelled by an ack", func(t *testing.T) {
+		s, f := newFakeDiscord(nil)
+		awaitAck(ctx, db, realClock{}, s, r, &discordgo.Message{ID: "test-ack-2", ChannelID: "c1"})
+		if !pending("test-ack-2") {
+			t.Fatal("the wait wasn't persisted")
+		}
+		var reacted bool
+		for _, c := range f.calls {
+			reacted = reacted || (c.Method == http.MethodPut && strings.HasPrefix(c.Path, "/channels/c1/messages/test-ack-2/reactions/"))
+		}
+		if !reacted {
+			t.Error("no ✅ was added to the message")
+		}
+
+		if err := acknowledge(ctx, db, "test-ack-2"); err != nil {
+			t.Fatal(err)
+		}
+		ackTimersMu.Lock()
+		_, armed := ackTimers["test-ack-2"]
+		ackTimersMu.Unlock()
+		if armed || pending("test-ack-2") {
+			t.Errorf("after the ack: timer armed %t, row pending %t", armed, pending("test-ack-2"))
+		}
+	})
+
+	t.Run("ack wins a race with the timer", func(t *testing.T) {
+		s, f := newFakeDiscord(nil)
+		// the timer has already fired when the ack claims the row
+		if _, err := db.Exec(ctx,
+			`INSERT INTO pending_acks (message_id, reminder_id, channel_id, due_at) VALUES ('test-ack-3', $1, 'c1', now())`, id); err != nil {
+			t.Fatal(err)
+		}
+		if err := acknowledge(ctx, db, "test-ack-3"); err != nil {
+			t.Fatal(err)
+		}
+		armEscalation(db, realClock{}, s, "test-ack-3", id, time.Now())
+		time.Sleep(100 * time.Millisecond)
+		if posts := f.posts(t); len(posts) != 0 {
+			t.Errorf("re-pinged %d times after the ack", len(posts))
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errorChannel is where significant failures are posted for the admins,
+// from ERROR_CHANNEL_ID. Empty means they're only logged.
+var errorChannel string
+
+// errorCooldown is how long after one report the same kind of failure is
+// held back, so an outage posts once rather than once per fire.
+const errorCooldown = 10 * time.Minute
+
+// throttle lets through one event per key per cooldown and counts the
+// ones it held back.
+type throttle struct {
+	mu       sync.Mutex
+	clk      Clock
+	cooldown time.Duration
+	last     map[string]time.Time
+	held     map[string]int
+}
+
+func newThrottle(clk Clock, cooldown time.Duration) *throttle {
+	return &throttle{clk: clk, cooldown: cooldown, last: make(map[string]time.Time), held: make(map[string]int)}
+}
+
+// maxThrottleKeys is how many keys a throttle remembers before it forgets
+// the ones whose cooldown is over.
+const maxThrottleKeys = 1000
+
+// allow reports whether an event for key may go out at now, and if so how
+// many were held back since the last one that did.
+func (t *throttle) allow(key string, now time.Time) (ok bool, held int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.last) > maxThrottleKeys {
+		for k, last := range t.last {
+			if now.Sub(last) >= t.cooldown {
+				delete(t.last, k)
+				delete(t.held, k)
+			}
+		}
+	}
+
+	if last, seen := t.last[key]; seen && now.Sub(last) < t.cooldown {
+		t.held[key]++
+		return false, 0
+	}
+	t.last[key] = now
+	held = t.held[key]
+	delete(t.held, key)
+	return true, held
+}
+
+// allowNow is allow as of the throttle's clock.
+func (t *throttle) allowNow(key string) (ok bool, held int) {
+	return t.allow(key, t.clk.Now())
+}
+
+var errorReports = newThrottle(realClock{}, errorCooldown)
+
+// reportError posts msg to errorChannel in the background, unless a
+// report with the same key went out within errorCooldown.
+func reportError(s *discordgo.Session, key, msg string) {
+	if errorChannel == "" || s == nil {
+		return
+	}
+	ok, held := errorReports.allowNow(key)
+	if !ok {
+		return
+	}
+	if held > 0 {
+		msg += fmt.Sprintf(" (and %d more like it since the last report)", held)
+	}
+	msg = truncate(msg, maxMessageLen-len("⚠️ "))
+	go func() {
+		if _, err := s.ChannelMessageSendComplex(errorChannel, &discordgo.MessageSend{
+			Content:         "⚠️ " + msg,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}); err != nil {
+			log.Printf("error report to %s: %v", errorChannel, err)
+		}
+	}()
+}
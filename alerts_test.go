@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	th := newThrottle(realClock{}, 10*time.Minute)
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		key      string
+		after    time.Duration
+		wantOK   bool
+		wantHeld int
+	}{
+		{"db", 0, true, 0},
+		{"db", time.Minute, false, 0},
+		{"send", time.Minute, true, 0}, // keys don't hold each other back
+		{"db", 9 * time.Minute, false, 0},
+		{"db", 10 * time.Minute, true, 2}, // reports the two held back
+		{"db", 11 * time.Minute, false, 0},
+		{"db", 30 * time.Minute, true, 1},
+		{"db", 45 * time.Minute, true, 0},
+	} {
+		ok, held := th.allow(c.key, start.Add(c.after))
+		if ok != c.wantOK || held != c.wantHeld {
+			t.Errorf("%s at +%v: allow = %t, %d; want %t, %d", c.key, c.after, ok, held, c.wantOK, c.wantHeld)
+		}
+	}
+}
+
+func TestThrottleForgetsExpiredKeys(t *testing.T) {
+	th := newThrottle(realClock{}, time.Minute)
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	for i := range maxThrottleKeys + 1 {
+		th.allow(fmt.Sprint(i), start)
+	}
+	th.allow("0", start.Add(30*time.Second)) // held, and still cooling down
+	th.allow("fresh", start.Add(2*time.Minute))
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	if len(th.last) != 1 || th.held["0"] != 0 {
+		t.Errorf("%d keys left, held %v; want only the new one", len(th.last), th.held)
+	}
+}
+
+func TestReportErrorThrottles(t *testing.T) {
+	oldChannel, oldReports := errorChannel, errorReports
+	t.Cleanup(func() { errorChannel, errorReports = oldChannel, oldReports })
+	clk := newFakeClock(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	errorChannel, errorReports = "ops", newThrottle(clk, errorCooldown)
+
+	s, f := newFakeDiscord(nil)
+	waitPosts := func(n int) []postedMessage {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if posts := f.posts(t); len(posts) >= n || time.Now().After(deadline) {
+				return posts
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	reportError(s, "db", "The database is down.")
+	reportError(s, "db", "The database is down.")
+	reportError(s, "db", "The database is down.")
+	posts := waitPosts(1)
+	time.Sleep(20 * time.Millisecond) // give a wrongly let-through report time to land
+	if posts = f.posts(t); len(posts) != 1 || posts[0].ChannelID != "ops" || posts[0].Content != "⚠️ The database is down." {
+		t.Fatalf("posts = %+v, want one report in ops", posts)
+	}
+	if am := posts[0].AllowedMentions; am == nil || len(am.Parse) != 0 {
+		t.Errorf("report may ping: %+v", am)
+	}
+
+	clk.Advance(errorCooldown)
+	reportError(s, "db", "The database is down.")
+	posts = waitPosts(2)
+	if len(posts) != 2 || posts[1].Content != "⚠️ The database is down. (and 2 more like it since the last report)" {
+		t.Errorf("after the cooldown posts = %+v", posts)
+	}
+
+	errorChannel = ""
+	reportError(s, "other", "Not configured.")
+	time.Sleep(20 * time.Millisecond)
+	if len(f.posts(t)) != 2 {
+		t.Error("reported with no error channel set")
+	}
+}
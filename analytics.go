@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// analyticsURL receives a JSON event for every reminder fire. It comes
+// from ANALYTICS_WEBHOOK; empty turns events off.
+var analyticsURL string
+
+// analyticsClient gives up quickly so a slow collector only costs a
+// goroutine, never a delivery.
+var analyticsClient = &http.Client{Timeout: 5 * time.Second}
+
+// fireEvent is the body POSTed to analyticsURL.
+type fireEvent struct {
+	ReminderID int       `json:"reminder_id"`
+	UserID     string    `json:"user_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Success    bool      `json:"success"`
+}
+
+// emitFire sends ev in the background, if analytics are on. Failures are
+// only logged.
+func emitFire(ev fireEvent) {
+	if analyticsURL == "" {
+		return
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("analytics event: %v", err)
+		return
+	}
+	go func() {
+		resp, err := analyticsClient.Post(analyticsURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("analytics post: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("analytics post: %s", resp.Status)
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEmitFire(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type %q", ct)
+		}
+		b, _ := io.ReadAll(req.Body)
+		bodies <- b
+		<-release // a slow collector
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	old := analyticsURL
+	analyticsURL = srv.URL
+	t.Cleanup(func() { analyticsURL = old })
+
+	at := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	start := time.Now()
+	emitFire(fireEvent{ReminderID: 42, UserID: "u1", Timestamp: at, Success: true})
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("emitFire blocked for %s", d)
+	}
+
+	var body []byte
+	select {
+	case body = <-bodies:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event was posted")
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"reminder_id": 42.0, "user_id": "u1", "timestamp": "2026-05-04T09:00:00Z", "success": true}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("event = %v, want just %v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// betweenHorizon is how far ahead /between looks, long enough to see
+// every weekday once.
+const betweenHorizon = 7 * 24 * time.Hour
+
+// inWindow reports whether the minute of day m is within [from, to], both
+// inclusive. A window with to before from spans midnight.
+func inWindow(m, from, to int) bool {
+	if from <= to {
+		return from <= m && m <= to
+	}
+	return m >= from || m <= to
+}
+
+// firstInWindow is r's first fire after now, within betweenHorizon, whose
+// time of day in loc falls in [from, to].
+func firstInWindow(r Reminder, now time.Time, loc *time.Location, from, to int) (time.Time, bool) {
+	sched, _, err := buildSchedule(r, now)
+	if err != nil {
+		return time.Time{}, false
+	}
+	rloc, _ := time.LoadLocation(r.TZ) // buildSpec checked it
+	end := now.Add(betweenHorizon)
+	for t := now.In(rloc); ; {
+		t = sched.Next(t)
+		if t.IsZero() || t.After(end) {
+			return time.Time{}, false
+		}
+		lt := t.In(loc)
+		if onFireDay(r, t) && inWindow(lt.Hour()*60+lt.Minute(), from, to) {
+			return t, true
+		}
+	}
+}
+
+// handleBetween lists the caller's reminders that fire between two times
+// of day, read in the given timezone or their default one.
+func handleBetween(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var rawFrom, rawTo, tz string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "from":
+			rawFrom = opt.StringValue() // "08:00"
+		case "to":
+			rawTo = opt.StringValue() // "12:00"
+		case "timezone":
+			tz = opt.StringValue() // "Europe/Paris"
+		}
+	}
+
+	fh, fm, err := parseClock(rawFrom)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	th, tm, err := parseClock(rawTo)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	if tz == "" {
+		if tz, err = defaultTZ(ctx, db, ic.Member.User.ID, ic.GuildID); err != nil {
+			respondErr(s, ic, "looking up your timezone", err)
+			return
+		}
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		respond(s, ic, invalidTZ(tz))
+		return
+	}
+
+	rs, err := userReminders(ctx, db, ic.Member.User.ID)
+	if err != nil {
+		respondErr(s, ic, "listing your reminders", err)
+		return
+	}
+
+	h12 := uses12h(ctx, db, ic.Member.User.ID)
+	window := fmt.Sprintf("%s and %s (%s)", formatClock(fh, fm, h12), formatClock(th, tm, h12), tz)
+	now := clk.Now()
+	var b strings.Builder
+	n := 0
+	for _, r := range rs {
+		next, ok := firstInWindow(r, now, loc, fh*60+fm, th*60+tm)
+		if !ok {
+			continue
+		}
+		line := formatReminderLine(r, next.In(loc), h12) + "\n"
+		if b.Len()+len(line) > 1800 {
+			b.WriteString("…and more")
+			break
+		}
+		b.WriteString(line)
+		n++
+	}
+	if n == 0 {
+		respond(s, ic, "None of your reminders fires between "+window+" in the coming week.")
+		return
+	}
+	respond(s, ic, "Your reminders firing between "+window+":\n"+b.String())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInWindow(t *testing.T) {
+	const (
+		h8, h12 = 8 * 60, 12 * 60
+		h22, h2 = 22 * 60, 2 * 60
+	)
+	for _, c := range []struct {
+		m, from, to int
+		want        bool
+	}{
+		{h8, h8, h12, true}, // both ends count
+		{h12, h8, h12, true},
+		{10 * 60, h8, h12, true},
+		{h8 - 1, h8, h12, false},
+		{h12 + 1, h8, h12, false},
+		// over midnight
+		{23 * 60, h22, h2, true},
+		{0, h22, h2, true},
+		{h2, h22, h2, true},
+		{h2 + 1, h22, h2, false},
+		{12 * 60, h22, h2, false},
+		{h22 - 1, h22, h2, false},
+		// a single minute
+		{h8, h8, h8, true},
+		{h8 + 1, h8, h8, false},
+	} {
+		if got := inWindow(c.m, c.from, c.to); got != c.want {
+			t.Errorf("inWindow(%d, %d, %d) = %t, want %t", c.m, c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestFirstInWindow(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // a Wednesday
+	paris, _ := time.LoadLocation("Europe/Paris")
+	for _, c := range []struct {
+		name     string
+		r        Reminder
+		loc      *time.Location
+		from, to int
+		want     time.Time // zero = not found
+	}{
+		{"read in the query's zone", Reminder{Hour: 23, TZ: "Asia/Tokyo"}, time.UTC, 13 * 60, 15 * 60,
+			time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC)},
+		{"outside the window", Reminder{Hour: 12, TZ: "UTC"}, time.UTC, 8 * 60, 11 * 60, time.Time{}},
+		{"over midnight", Reminder{Hour: 23, Min: 30, TZ: "UTC"}, paris, 22 * 60, 2 * 60,
+			time.Date(2026, 10, 14, 23, 30, 0, 0, time.UTC)}, // 01:30 in Paris
+		{"weekly, on its day", Reminder{Mode: modeWeekly, Days: "5", Hour: 8, TZ: "UTC"}, time.UTC, 7 * 60, 9 * 60,
+			time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{"interval, first one inside", Reminder{Mode: modeInterval, IntervalMin: 45, TZ: "UTC"}, time.UTC, 14 * 60, 14*60 + 20,
+			time.Date(2026, 10, 14, 14, 15, 0, 0, time.UTC)},
+		{"monthly, beyond a week", Reminder{Mode: modeMonthly, MonthDay: 1, Hour: 9, TZ: "UTC"}, time.UTC, 8 * 60, 10 * 60, time.Time{}},
+	} {
+		got, ok := firstInWindow(c.r, now, c.loc, c.from, c.to)
+		if ok != !c.want.IsZero() || !got.Equal(c.want) {
+			t.Errorf("%s: firstInWindow = %v, %t; want %v", c.name, got, ok, c.want)
+		}
+	}
+}
+
+func TestBetweenBadBounds(t *testing.T) {
+	_, _, want := parseClock("25:00")
+	s, f := newFakeDiscord(nil)
+	handleBetween(context.Background(), nil, realClock{}, s, slash("between", "u1", "from", "08:00", "to", "25:00"))
+	if got := f.replies(t); len(got) != 1 || got[0] != want.Error() {
+		t.Errorf("replies = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxBoostHours caps how long /boost can run, two weeks.
+const maxBoostHours = 14 * 24
+
+// boostTimers holds the pending end of each reminder's boost.
+var (
+	boostTimers   = map[int]*time.Timer{}
+	boostTimersMu sync.Mutex
+)
+
+// boosted reports whether r runs on a /boost cadence at t instead of its
+// own schedule.
+func boosted(r Reminder, t time.Time) bool {
+	return r.BoostMin > 0 && r.BoostUntil != nil && t.Before(*r.BoostUntil)
+}
+
+// armBoostEnd puts r back on its own schedule when its boost runs out.
+// Arming again replaces the previous timer, so rescheduling a boosted
+// reminder doesn't stack them.
+func armBoostEnd(db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder) {
+	t := scheduleOneOff(clk, *r.BoostUntil, func() {
+		boostTimersMu.Lock()
+		delete(boostTimers, r.ID)
+		boostTimersMu.Unlock()
+
+		ctx, cancel := dbCtx()
+		defer cancel()
+		if err := endBoost(ctx, db, clk, s, r.ID); err != nil {
+			log.Printf("end boost of reminder %d: %v", r.ID, err)
+		}
+	})
+
+	boostTimersMu.Lock()
+	if old, ok := boostTimers[r.ID]; ok {
+		old.Stop()
+	}
+	boostTimers[r.ID] = t
+	boostTimersMu.Unlock()
+}
+
+// endBoost clears reminder id's boost and reschedules it on its own
+// schedule.
+func endBoost(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, id int) error {
+	var r Reminder
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET boost_min = 0, boost_until = NULL, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id).Scan(reminderDest(&r)...); err != nil {
+		return err
+	}
+	if !r.Active {
+		return nil
+	}
+	return reschedule(db, clk, s, r)
+}
+
+// handleBoost makes a reminder fire every few minutes for a while, e.g.
+// hourly through a crunch, after which it goes back to its own schedule.
+// Without every it ends a boost early. Owner only.
+func handleBoost(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var every, hours int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "every":
+			every = int(opt.IntValue()) // 60
+		case "hours":
+			hours = int(opt.IntValue()) // 72
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if every == 0 {
+		if !boosted(r, clk.Now()) {
+			respond(s, ic, fmt.Sprintf("Reminder %d isn't boosted.", id))
+			return
+		}
+		if err := endBoost(ctx, db, clk, s, id); err != nil {
+			respondErr(s, ic, "ending the boost", err)
+			return
+		}
+		respond(s, ic, fmt.Sprintf("Reminder %d is back to its usual schedule ✅", id))
+		return
+	}
+	if hours == 0 {
+		respond(s, ic, "Say how many hours the boost should last.")
+		return
+	}
+
+	until := clk.Now().Add(time.Duration(hours) * time.Hour)
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET boost_min = $2, boost_until = $3, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, every, until).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "boosting your reminder", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	respond(s, ic, fmt.Sprintf("🚀 Reminder %d now fires every %s until <t:%d:f>, then goes back to %s.",
+		id, time.Duration(every)*time.Minute, until.Unix(), describeSchedule(r)))
+}
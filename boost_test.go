@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoosted(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	for _, c := range []struct {
+		name string
+		r    Reminder
+		want bool
+	}{
+		{"never boosted", Reminder{}, false},
+		{"running", Reminder{BoostMin: 60, BoostUntil: &later}, true},
+		{"ran out", Reminder{BoostMin: 60, BoostUntil: &earlier}, false},
+		{"ends right now", Reminder{BoostMin: 60, BoostUntil: &now}, false},
+		{"cleared cadence", Reminder{BoostUntil: &later}, false},
+	} {
+		if got := boosted(c.r, now); got != c.want {
+			t.Errorf("%s: boosted = %t, want %t", c.name, got, c.want)
+		}
+	}
+}
+
+// A boosted reminder fires on its boost cadence every day, not just on
+// the days its own schedule picks.
+func TestBoostOverridesFireDays(t *testing.T) {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // a Wednesday, not the last day
+	until := start.Add(3 * time.Hour)
+	r := Reminder{ID: 1, Mode: modeLastDay, Hour: 9, TZ: "UTC", BoostMin: 60, BoostUntil: &until}
+
+	times, err := nextFires(r, start, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !times[0].Equal(start.Add(time.Hour)) || !times[1].Equal(start.Add(2*time.Hour)) {
+		t.Errorf("boosted fires %v, want hourly from noon", times)
+	}
+}
+
+func TestBoostApplyAndRevert(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		boostTimersMu.Lock()
+		if tm, ok := boostTimers[id]; ok {
+			tm.Stop()
+			delete(boostTimers, id)
+		}
+		boostTimersMu.Unlock()
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-boost'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-boost', 'c1', 'g1', 'ship it', 9, 0, 'UTC', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	nextOfRunner := func() time.Time {
+		cronsMu.Lock()
+		defer cronsMu.Unlock()
+		c := crons[id]
+		if c == nil {
+			t.Fatal("reminder isn't scheduled")
+		}
+		return c.Entries()[0].Schedule.Next(time.Now())
+	}
+
+	s, f := newFakeDiscord(nil)
+	handleBoost(ctx, db, realClock{}, s, slash("boost", "test-boost", "id", strconv.Itoa(id), "every", 60, "hours", 2))
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], fmt.Sprintf("🚀 Reminder %d now fires every 1h0m0s until ", id)) {
+		t.Errorf("replies = %q", got)
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.BoostMin != 60 || r.BoostUntil == nil {
+		t.Fatalf("boost not stored: %d until %v", r.BoostMin, r.BoostUntil)
+	}
+	if d := time.Until(nextOfRunner()); d < 59*time.Minute || d > 61*time.Minute {
+		t.Errorf("boosted runner next fires in %v, want an hour", d)
+	}
+
+	// bring the end close, as a restart with a nearly expired boost would
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET boost_until = now() + interval '200 milliseconds' WHERE id = $1
+		RETURNING `+reminderColumns, id).Scan(reminderDest(&r)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := reschedule(db, realClock{}, s, r); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if r, err = loadReminder(ctx, db, id); err == nil && r.BoostMin == 0 && r.BoostUntil == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the boost never ended")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond) // endBoost reschedules right after the update
+	if next := nextOfRunner(); next.Hour() != 9 || next.Minute() != 0 {
+		t.Errorf("after the boost the runner fires at %v, want 09:00", next)
+	}
+}
+
+func TestBoostEndEarly(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-boost-early'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, boost_min, boost_until)
+		 VALUES ('test-boost-early', 'c1', 'g1', 'ship it', 9, 0, 'UTC', true, 30, now() + interval '1 day') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	s, f := newFakeDiscord(nil)
+	handleBoost(ctx, db, realClock{}, s, slash("boost", "test-boost-early", "id", strconv.Itoa(id)))
+	if got := f.replies(t); len(got) != 1 || got[0] != fmt.Sprintf("Reminder %d is back to its usual schedule ✅", id) {
+		t.Errorf("replies = %q", got)
+	}
+	if r, err := loadReminder(ctx, db, id); err != nil || boosted(r, time.Now()) {
+		t.Errorf("still boosted after ending it: %+v, %v", r, err)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleBoost(ctx, db, realClock{}, s, slash("boost", "test-boost-early", "id", strconv.Itoa(id)))
+	if got := f.replies(t); len(got) != 1 || got[0] != fmt.Sprintf("Reminder %d isn't boosted.", id) {
+		t.Errorf("second end: replies = %q", got)
+	}
+}
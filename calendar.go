@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// handleCalendar gives the user the link to their iCalendar feed, issuing
+// a token the first time. With reset the old link stops working.
+func handleCalendar(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if publicURL == "" {
+		respond(s, ic, "Calendar feeds aren't enabled on this bot.")
+		return
+	}
+	var reset bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "reset" {
+			reset = opt.BoolValue()
+		}
+	}
+
+	fresh, err := newCalendarToken()
+	if err != nil {
+		respondErr(s, ic, "setting up your calendar feed", err)
+		return
+	}
+	var token string
+	if err := db.QueryRow(ctx,
+		`INSERT INTO user_prefs (user_id, calendar_token) VALUES ($1,$2)
+		 ON CONFLICT (user_id) DO UPDATE
+		    SET calendar_token = CASE WHEN $3 OR user_prefs.calendar_token IS NULL
+		                              THEN EXCLUDED.calendar_token
+		                              ELSE user_prefs.calendar_token END
+		 RETURNING calendar_token`,
+		ic.Member.User.ID, fresh, reset).Scan(&token); err != nil {
+		respondErr(s, ic, "setting up your calendar feed", err)
+		return
+	}
+
+	link := strings.TrimSuffix(publicURL, "/") + "/calendar/" + token + ".ics"
+	respond(s, ic, "📅 Subscribe to this in your calendar app to see your reminders there:\n"+link+
+		"\nAnyone with the link can see them. Run /calendar reset:true to change it.")
+}
+
+// newCalendarToken makes the secret in a feed's URL. Anyone holding it can
+// read the feed, so there's no fallback if randomness fails.
+func newCalendarToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarFeed serves /calendar/<token>.ics, the active reminders of
+// whoever holds token.
+func calendarFeed(db *pgxpool.Pool, clk Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/calendar/"), ".ics")
+		if token == "" {
+			http.NotFound(w, req)
+			return
+		}
+
+		ctx, cancel := dbCtx()
+		defer cancel()
+		var userID string
+		if err := db.QueryRow(ctx,
+			`SELECT user_id FROM user_prefs WHERE calendar_token=$1`, token).Scan(&userID); err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		rs, err := userReminders(ctx, db, userID)
+		if err != nil {
+			log.Printf("calendar feed for %s: %v", userID, err)
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		if _, err := w.Write([]byte(buildICal(rs, clk.Now()))); err != nil {
+			log.Printf("calendar feed for %s: %v", userID, err)
+		}
+	}
+}
+
+// buildICal renders rs as an iCalendar document, one VEVENT per reminder
+// starting at its next fire. Schedules iCalendar can express recur with an
+// RRULE; cron reminders only show their next fire.
+func buildICal(rs []Reminder, now time.Time) string {
+	var b strings.Builder
+	line := func(s string) { b.WriteString(foldICal(s) + "\r\n") }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//KermitTheBot//Reminders//EN")
+	line("X-WR-CALNAME:Reminders")
+	for _, r := range rs {
+		next, err := nextFire(r, now)
+		if err != nil {
+			continue
+		}
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:reminder-%d@kermitthebot", r.ID))
+		line("DTSTAMP:" + now.UTC().Format("20060102T150405Z"))
+		line("DTSTART;TZID=" + r.TZ + ":" + next.Format("20060102T150405"))
+		line("SUMMARY:" + escapeICal(r.Message))
+		if rule := icalRRule(r); rule != "" {
+			line("RRULE:" + rule)
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icalRRule is the RRULE for r's schedule, or "" when it fires once or has
+// no iCalendar equivalent. A fire limit wins over an until date, since
+// RFC 5545 allows only one of them.
+func icalRRule(r Reminder) string {
+	var rule string
+	switch r.Mode {
+	case "", modeDaily:
+		rule = "FREQ=DAILY"
+	case modeWeekly:
+		var days []string
+		for _, d := range strings.Split(r.Days, ",") {
+			if i, err := strconv.Atoi(d); err == nil && i >= 0 && i < 7 {
+				days = append(days, strings.ToUpper(time.Weekday(i).String()[:2]))
+			}
+		}
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")
+		if r.EveryWeeks > 1 {
+			rule += fmt.Sprintf(";INTERVAL=%d", r.EveryWeeks)
+		}
+	case modeMonthly:
+		rule = fmt.Sprintf("FREQ=MONTHLY;BYMONTHDAY=%d", r.MonthDay)
+	case modeLastDay:
+		rule = "FREQ=MONTHLY;BYMONTHDAY=-1"
+	case modeInterval:
+		rule = fmt.Sprintf("FREQ=MINUTELY;INTERVAL=%d", r.IntervalMin)
+	case modeRRule:
+		rule = r.RRule
+	default:
+		return ""
+	}
+
+	if r.MaxFires > 0 {
+		rule += fmt.Sprintf(";COUNT=%d", max(r.MaxFires-r.FireCount, 1))
+	} else if r.Until != nil {
+		if loc, err := time.LoadLocation(r.TZ); err == nil {
+			end := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 23, 59, 59, 0, loc)
+			rule += ";UNTIL=" + end.UTC().Format("20060102T150405Z")
+		}
+	}
+	return rule
+}
+
+// escapeICal escapes a TEXT value.
+func escapeICal(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICal splits a content line into 75-octet pieces joined by CRLF and a
+// space, without cutting a UTF-8 character in half.
+func foldICal(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBuildICalDailyAndWeekly(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // Wednesday, 14:00 in Paris
+	rs := []Reminder{
+		{ID: 1, Mode: modeDaily, Hour: 9, TZ: "Europe/Paris", Message: "Stand up, team; now"},
+		{ID: 2, Mode: modeWeekly, Days: "1,3", Hour: 18, Min: 30, TZ: "Europe/Paris", Message: "Gym"},
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//KermitTheBot//Reminders//EN",
+		"X-WR-CALNAME:Reminders",
+		"BEGIN:VEVENT",
+		"UID:reminder-1@kermitthebot",
+		"DTSTAMP:20261014T120000Z",
+		"DTSTART;TZID=Europe/Paris:20261015T090000",
+		`SUMMARY:Stand up\, team\; now`,
+		"RRULE:FREQ=DAILY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:reminder-2@kermitthebot",
+		"DTSTAMP:20261014T120000Z",
+		"DTSTART;TZID=Europe/Paris:20261014T183000",
+		"SUMMARY:Gym",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buildICal(rs, now); got != want {
+		t.Errorf("buildICal =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildICalSkipsBrokenSchedules(t *testing.T) {
+	got := buildICal([]Reminder{{ID: 3, Mode: modeCron, CronSpec: "nope", TZ: "UTC"}}, time.Now())
+	if strings.Contains(got, "VEVENT") {
+		t.Errorf("a reminder with no next fire made an event:\n%s", got)
+	}
+}
+
+func TestICalRRule(t *testing.T) {
+	until := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		name string
+		r    Reminder
+		want string
+	}{
+		{"legacy daily", Reminder{}, "FREQ=DAILY"},
+		{"fortnightly", Reminder{Mode: modeWeekly, Days: "2", EveryWeeks: 2}, "FREQ=WEEKLY;BYDAY=TU;INTERVAL=2"},
+		{"bad day dropped", Reminder{Mode: modeWeekly, Days: "5,9"}, "FREQ=WEEKLY;BYDAY=FR"},
+		{"fires left", Reminder{Mode: modeDaily, MaxFires: 10, FireCount: 4}, "FREQ=DAILY;COUNT=6"},
+		{"count beats until", Reminder{Mode: modeDaily, MaxFires: 3, Until: &until}, "FREQ=DAILY;COUNT=3"},
+		// the end of the 31st in Paris is 22:59:59 UTC
+		{"until", Reminder{Mode: modeDaily, TZ: "Europe/Paris", Until: &until}, "FREQ=DAILY;UNTIL=20261231T225959Z"},
+		{"once", Reminder{Mode: modeOnce}, ""},
+		{"cron", Reminder{Mode: modeCron, CronSpec: "0 9 * * *"}, ""},
+	} {
+		if got := icalRRule(c.r); got != c.want {
+			t.Errorf("%s: icalRRule = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFoldICal(t *testing.T) {
+	if got := foldICal("SUMMARY:short"); got != "SUMMARY:short" {
+		t.Errorf("short line folded: %q", got)
+	}
+	long := "SUMMARY:" + strings.Repeat("é", 60) // 120 octets of é
+	folded := foldICal(long)
+	for i, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("piece %d is %d octets", i, len(part))
+		}
+		if !utf8.ValidString(part) {
+			t.Errorf("piece %d cuts a character: %q", i, part)
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("continuation %d doesn't start with a space", i)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != long {
+		t.Error("unfolding doesn't give the line back")
+	}
+}
+
+func TestEscapeICal(t *testing.T) {
+	if got, want := escapeICal("a\\b;c,d\r\ne\nf"), `a\\b\;c\,d\ne\nf`; got != want {
+		t.Errorf("escapeICal = %q, want %q", got, want)
+	}
+}
+
+func TestNewCalendarToken(t *testing.T) {
+	a, err := newCalendarToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := newCalendarToken()
+	if len(a) != 32 || a == b {
+		t.Errorf("tokens %q and %q", a, b)
+	}
+}
+
+func TestCalendarFeedNeedsToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	calendarFeed(nil, realClock{})(w, httptest.NewRequest(http.MethodGet, "/calendar/.ics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("empty token got %d", w.Code)
+	}
+}
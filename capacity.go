@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// capacity is what /capacity reports about this instance.
+type capacity struct {
+	Active     int // active reminders in the database
+	Timezones  int // distinct timezones among them
+	Runners    int // cron runners in memory
+	Goroutines int
+	HeapBytes  uint64 // live heap
+	SysBytes   uint64 // memory obtained from the OS
+	NumGC      uint32
+}
+
+// formatCapacity renders c for /capacity.
+func formatCapacity(c capacity) string {
+	const mib = 1 << 20
+	var b strings.Builder
+	b.WriteString("**Capacity**\n")
+	fmt.Fprintf(&b, "active reminders: %d in %d timezones\n", c.Active, c.Timezones)
+	fmt.Fprintf(&b, "scheduled runners: %d\n", c.Runners)
+	fmt.Fprintf(&b, "goroutines: %d\n", c.Goroutines)
+	fmt.Fprintf(&b, "memory: %.1f MiB heap, %.1f MiB from the OS, %d GCs\n",
+		float64(c.HeapBytes)/mib, float64(c.SysBytes)/mib, c.NumGC)
+	return b.String()
+}
+
+// handleCapacity shows how loaded this instance is, for capacity
+// planning. The numbers cover every server the bot is in, so it's for
+// operators only.
+func handleCapacity(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+	if !isOperator(ic) {
+		respond(s, ic, "Only the bot's operators can see its capacity.")
+		return
+	}
+
+	var c capacity
+	if err := db.QueryRow(ctx,
+		`SELECT COUNT(*), COUNT(DISTINCT tz) FROM reminders WHERE active`).Scan(&c.Active, &c.Timezones); err != nil {
+		respondErr(s, ic, "counting reminders", err)
+		return
+	}
+	cronsMu.Lock()
+	c.Runners = len(crons)
+	cronsMu.Unlock()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	c.Goroutines = runtime.NumGoroutine()
+	c.HeapBytes, c.SysBytes, c.NumGC = m.HeapAlloc, m.Sys, m.NumGC
+
+	respond(s, ic, formatCapacity(c))
+}
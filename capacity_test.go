@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFormatCapacity(t *testing.T) {
+	got := formatCapacity(capacity{
+		Active: 1200, Timezones: 14, Runners: 1198, Goroutines: 2411,
+		HeapBytes: 48 << 20, SysBytes: 96<<20 + 512<<10, NumGC: 37,
+	})
+	want := "**Capacity**\n" +
+		"active reminders: 1200 in 14 timezones\n" +
+		"scheduled runners: 1198\n" +
+		"goroutines: 2411\n" +
+		"memory: 48.0 MiB heap, 96.5 MiB from the OS, 37 GCs\n"
+	if got != want {
+		t.Errorf("formatCapacity =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCapacityNeedsAdmin(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleCapacity(context.Background(), nil, s, slash("capacity", "u1"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "You need the Manage Server permission to do that." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestCapacityNeedsOperator(t *testing.T) {
+	useOperators(t, "someone-else")
+	admin := slash("capacity", "u1")
+	admin.Member.Permissions = discordgo.PermissionManageServer
+	s, f := newFakeDiscord(nil)
+	handleCapacity(context.Background(), nil, s, admin)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Only the bot's operators can see its capacity." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestCapacityReport(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	const orphan = 2147483005
+	t.Cleanup(func() {
+		unschedule(orphan)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-capacity'`)
+	})
+	admin := slash("capacity", "test-capacity")
+	admin.Member.Permissions = discordgo.PermissionManageServer
+	useOperators(t, admin.Member.User.ID)
+	active := func() int {
+		s, f := newFakeDiscord(nil)
+		handleCapacity(ctx, db, s, admin)
+		got := f.replies(t)
+		if len(got) != 1 {
+			t.Fatalf("replies = %q", got)
+		}
+		var n, zones, runners int
+		if _, err := fmt.Sscanf(got[0], "**Capacity**\nactive reminders: %d in %d timezones\nscheduled runners: %d\n", &n, &zones, &runners); err != nil {
+			t.Fatalf("report %q: %v", got[0], err)
+		}
+		cronsMu.Lock()
+		want := len(crons)
+		cronsMu.Unlock()
+		if runners != want {
+			t.Errorf("reported %d runners, %d are scheduled", runners, want)
+		}
+		return n
+	}
+
+	before := active()
+	for _, on := range []bool{true, true, false} {
+		if _, err := db.Exec(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+			 VALUES ('test-capacity', 'c1', 'g1', 'count me', 9, 0, 'Asia/Tokyo', $1)`, on); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, _ := newFakeDiscord(nil)
+	if err := reschedule(nil, realClock{}, s, Reminder{ID: orphan, Hour: 9, TZ: "UTC", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if after := active(); after != before+2 {
+		t.Errorf("active reminders went from %d to %d, want only the 2 active ones counted", before, after)
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import "time"
+
+// Clock is the source of "now" for every scheduling decision the bot makes
+// itself (date windows, cooldowns, catch-up, one-shots). cron still fires
+// on wall time; the clock only decides what a fire should do. It's handed
+// down from main so tests can pass their own.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock(t time.Time) *fakeClock { return &fakeClock{t: t} }
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+func (f *fakeClock) Set(t time.Time) {
+	f.mu.Lock()
+	f.t = t
+	f.mu.Unlock()
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.t = f.t.Add(d)
+	f.mu.Unlock()
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 1, 31, 23, 59, 0, 0, time.UTC)
+	fc := newFakeClock(start)
+	if !fc.Now().Equal(start) {
+		t.Fatalf("Now = %s, want %s", fc.Now(), start)
+	}
+	fc.Advance(2 * time.Minute)
+	if want := start.Add(2 * time.Minute); !fc.Now().Equal(want) {
+		t.Errorf("after Advance Now = %s, want %s", fc.Now(), want)
+	}
+	fc.Set(start)
+	if !fc.Now().Equal(start) {
+		t.Errorf("after Set Now = %s, want %s", fc.Now(), start)
+	}
+}
+
+// Walking the fake clock from fire to fire shows which days a last-day
+// reminder actually posts on: cron wakes it on the 28th to 31st and
+// onFireDay lets only the last one through.
+func TestSimulatedLastDayFires(t *testing.T) {
+	fc := newFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := Reminder{ID: 1, TZ: "UTC", Hour: 9, Mode: modeLastDay, Active: true}
+
+	var posted []string
+	for range 16 {
+		next, err := nextFire(r, fc.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fc.Set(next)
+		if onFireDay(r, fc.Now()) {
+			posted = append(posted, fc.Now().Format("2006-01-02"))
+		}
+	}
+	want := []string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"}
+	if len(posted) < len(want) {
+		t.Fatalf("posted on %v, want at least %v", posted, want)
+	}
+	for i, d := range want {
+		if posted[i] != d {
+			t.Errorf("fire %d on %s, want %s", i, posted[i], d)
+		}
+	}
+}
+
+// A boost only applies while the clock is before its end.
+func TestSimulatedBoostEnds(t *testing.T) {
+	start := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	fc := newFakeClock(start)
+	until := start.Add(2 * time.Hour)
+	r := Reminder{ID: 1, TZ: "UTC", Hour: 9, BoostMin: 15, BoostUntil: &until}
+
+	if spec, _, _ := buildSpec(r, fc.Now()); spec != "@every 15m" {
+		t.Errorf("while boosted spec = %q, want @every 15m", spec)
+	}
+	fc.Advance(3 * time.Hour)
+	if spec, _, _ := buildSpec(r, fc.Now()); spec != "0 9 * * *" {
+		t.Errorf("after the boost spec = %q, want 0 9 * * *", spec)
+	}
+}
+
+// A one-off that came due while the clock moved on runs straight away.
+func TestScheduleOneOffOverdue(t *testing.T) {
+	fc := newFakeClock(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+	at := fc.Now().Add(time.Hour)
+	fc.Advance(2 * time.Hour)
+
+	done := make(chan struct{})
+	scheduleOneOff(fc, at, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("overdue one-off didn't run")
+	}
+}
+
+func TestSimulatedCooldown(t *testing.T) {
+	fc := newFakeClock(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+	last := fc.Now()
+	gap := 30 * time.Minute
+
+	fc.Advance(29 * time.Minute)
+	if !tooSoon(&last, fc.Now(), gap) {
+		t.Error("29 minutes after the last fire isn't too soon")
+	}
+	fc.Advance(time.Minute)
+	if tooSoon(&last, fc.Now(), gap) {
+		t.Error("30 minutes after the last fire is too soon")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// memberEvents asks Discord for member joins and leaves so reminders of
+// members who leave a server stop. It needs the Server Members intent
+// turned on for the bot, or the gateway refuses to connect.
+var memberEvents bool
+
+// deactivateDeparted turns off userID's active reminders in guildID and
+// returns their IDs. Reminders they have in other servers keep running.
+func deactivateDeparted(ctx context.Context, db *pgxpool.Pool, guildID, userID string) ([]int, error) {
+	rows, err := db.Query(ctx,
+		`UPDATE reminders SET active = false, updated_at = now()
+		  WHERE user_id = $1 AND guild_id = $2 AND active
+		RETURNING id`, userID, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// onMemberRemove stops the reminders of someone who left or was removed
+// from a server, so they don't keep pinging a user who isn't there.
+func onMemberRemove(db *pgxpool.Pool) func(*discordgo.Session, *discordgo.GuildMemberRemove) {
+	return func(s *discordgo.Session, ev *discordgo.GuildMemberRemove) {
+		if ev.Member == nil || ev.User == nil || ev.User.Bot {
+			return
+		}
+		ctx, cancel := dbCtx()
+		defer cancel()
+
+		ids, err := deactivateDeparted(ctx, db, ev.GuildID, ev.User.ID)
+		if err != nil {
+			log.Printf("deactivate reminders of %s leaving %s: %v", ev.User.ID, ev.GuildID, err)
+			return
+		}
+		for _, id := range ids {
+			unschedule(id)
+		}
+		if len(ids) > 0 {
+			log.Printf("%s left %s, turned off reminders %v", ev.User.ID, ev.GuildID, ids)
+		}
+	}
+}
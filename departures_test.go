@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDeactivateDeparted(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id LIKE 'test-departed-%'`)
+	})
+
+	add := func(user, guild string, active bool) int {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, message, hour, minute, tz, active, guild_id)
+			 VALUES ($1, 'c1', 'water the plants ' || $2, 9, 0, 'UTC', $3, $2)
+			 RETURNING id`, user, guild, active).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	leaving := add("test-departed-a", "g1", true)
+	off := add("test-departed-a", "g1-off", false)
+	elsewhere := add("test-departed-a", "g2", true)
+	other := add("test-departed-b", "g1", true)
+
+	ids, err := deactivateDeparted(ctx, db, "g1", "test-departed-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []int{leaving}) {
+		t.Errorf("deactivated %v, want [%d]", ids, leaving)
+	}
+
+	for id, want := range map[int]bool{leaving: false, off: false, elsewhere: true, other: true} {
+		var active bool
+		if err := db.QueryRow(ctx, `SELECT active FROM reminders WHERE id=$1`, id).Scan(&active); err != nil {
+			t.Fatal(err)
+		}
+		if active != want {
+			t.Errorf("reminder %d active = %t, want %t", id, active, want)
+		}
+	}
+
+	// leaving again finds nothing left to turn off
+	if ids, err := deactivateDeparted(ctx, db, "g1", "test-departed-a"); err != nil || len(ids) != 0 {
+		t.Errorf("second run = %v, %v; want nothing", ids, err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+)
+
+// the digest goes out during this hour every Sunday, user-local time
+const digestHour = 18
+
+// startDigest runs an hourly check that DMs every opted-in user whose local
+// time has just reached Sunday evening.
+func startDigest(db *pgxpool.Pool, clk Clock, s *discordgo.Session) {
+	c := cron.New(cron.WithLocation(time.UTC))
+	_, err := c.AddFunc("0 * * * *", func() { sendDigests(db, s, clk.Now()) })
+	if err != nil {
+		log.Printf("digest cron: %v", err)
+		return
+	}
+	c.Start()
+}
+
+func sendDigests(db *pgxpool.Pool, s *discordgo.Session, now time.Time) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	rows, err := db.Query(ctx,
+		`SELECT user_id, COALESCE(tz, 'UTC') FROM user_prefs WHERE weekly_digest`)
+	if err != nil {
+		log.Printf("digest users: %v", err)
+		return
+	}
+	due := map[string]*time.Location{}
+	for rows.Next() {
+		var userID, tz string
+		if err := rows.Scan(&userID, &tz); err != nil {
+			continue
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			continue
+		}
+		if local := now.In(loc); local.Weekday() == time.Sunday && local.Hour() == digestHour {
+			due[userID] = loc
+		}
+	}
+	rows.Close()
+
+	for userID, loc := range due {
+		ctx, cancel := dbCtx()
+		rs, err := userReminders(ctx, db, userID)
+		cancel()
+		if err != nil {
+			log.Printf("digest reminders for %s: %v", userID, err)
+			continue
+		}
+		if err := sendDM(s, userID, buildDigest(rs, now.In(loc))); err != nil {
+			// most likely DMs are closed; nothing to do but try next week
+			log.Printf("digest DM to %s: %v", userID, err)
+		}
+	}
+}
+
+// userReminders lists a user's active reminders by ID.
+func userReminders(ctx context.Context, db *pgxpool.Pool, userID string) ([]Reminder, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+reminderColumns+` FROM reminders
+		  WHERE user_id=$1 AND active ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rs []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := scanReminder(rows, &r); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+// buildDigest renders the weekly summary of rs, soonest first.
+func buildDigest(rs []Reminder, now time.Time) string {
+	if len(rs) == 0 {
+		return "📋 Your weekly reminder digest: you have no active reminders."
+	}
+
+	type entry struct {
+		r    Reminder
+		next time.Time
+	}
+	entries := make([]entry, 0, len(rs))
+	for _, r := range rs {
+		next, err := nextFire(r, now)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{r, next})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].next.Before(entries[j].next) })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 Your weekly reminder digest (%d active):\n", len(rs))
+	for _, e := range entries {
+		b.WriteString(formatReminderLine(e.r, e.next, false) + "\n")
+	}
+	return b.String()
+}
+
+// formatReminderLine is the one-line summary of r used in listings.
+func formatReminderLine(r Reminder, next time.Time, h12 bool) string {
+	id := strconv.Itoa(r.ID)
+	if r.Name != "" {
+		id += " " + r.Name
+	}
+	layout := "Mon Jan 2 15:04"
+	if h12 {
+		layout = "Mon Jan 2 3:04 PM"
+	}
+	return fmt.Sprintf("• **%s** %s: %s (next %s)",
+		id, describeScheduleClock(r, h12), r.Message, next.Format(layout))
+}
+
+// sendDM opens (or reuses) the DM channel with a user and posts msg there.
+func sendDM(s *discordgo.Session, userID, msg string) error {
+	ch, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageSend(ch.ID, msg)
+	return err
+}
+
+// handleTestDM sends the user a DM so they know DMs from the bot reach
+// them before relying on DM reminders, digests or fallbacks.
+func handleTestDM(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	err := sendDM(s, ic.Member.User.ID, "👋 This is a test DM. If you can read it, DM reminders will reach you.")
+	respond(s, ic, dmTestResult(err))
+}
+
+// dmTestResult is what /testdm tells the user about a test DM that ended
+// with err.
+func dmTestResult(err error) string {
+	switch {
+	case err == nil:
+		return "✅ I sent you a DM. If you don't see it, check your message requests."
+	case discordErrCode(err) == discordgo.ErrCodeCannotSendMessagesToThisUser:
+		return "❌ I can't DM you. You've probably turned off DMs from server members " +
+			"(Privacy Settings for this server), or blocked me."
+	default:
+		log.Printf("test DM: %v", err)
+		return "❌ I couldn't DM you just now because Discord returned an error. Try again in a little while."
+	}
+}
+
+func handleDigest(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var enabled bool
+	var tz *string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "timezone":
+			v := opt.StringValue()
+			tz = &v
+		}
+	}
+	if tz != nil {
+		if _, err := time.LoadLocation(*tz); err != nil {
+			respond(s, ic, invalidTZ(*tz))
+			return
+		}
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO user_prefs (user_id, weekly_digest, tz) VALUES ($1,$2,$3)
+		 ON CONFLICT (user_id) DO UPDATE
+		    SET weekly_digest = EXCLUDED.weekly_digest,
+		        tz = COALESCE(EXCLUDED.tz, user_prefs.tz)`,
+		ic.Member.User.ID, enabled, tz); err != nil {
+		respondErr(s, ic, "saving your preference", err)
+		return
+	}
+
+	if !enabled {
+		respond(s, ic, "Weekly digest turned off.")
+		return
+	}
+	respond(s, ic, fmt.Sprintf("You'll get a digest by DM every Sunday around %02d:00 (make sure DMs from server members are allowed).", digestHour))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBuildDigestEmpty(t *testing.T) {
+	got := buildDigest(nil, time.Now())
+	if !strings.Contains(got, "no active reminders") {
+		t.Errorf("empty digest = %q", got)
+	}
+}
+
+func TestBuildDigestSoonestFirst(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 3, 1, 18, 0, 0, 0, loc) // Sunday evening
+	rs := []Reminder{
+		{ID: 1, TZ: "Europe/Paris", Hour: 9, Message: "stand-up", Mode: modeWeekly, Days: "3"}, // Wednesday
+		{ID: 2, TZ: "Europe/Paris", Hour: 8, Message: "water", Name: "plants"},                 // tomorrow
+		{ID: 3, TZ: "Europe/Paris", Hour: 20, Message: "bins", Mode: modeWeekly, Days: "0"},    // tonight
+	}
+	got := buildDigest(rs, now)
+
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("digest has %d lines, want a header and 3 reminders:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[0], "(3 active)") {
+		t.Errorf("header = %q", lines[0])
+	}
+	for i, want := range []string{"**3**", "**2 plants**", "**1**"} {
+		if !strings.HasPrefix(lines[i+1], "• "+want+" ") {
+			t.Errorf("line %d = %q, want reminder %s", i+1, lines[i+1], want)
+		}
+	}
+	if !strings.Contains(lines[1], "(next Sun Mar 1 20:00)") {
+		t.Errorf("first line = %q, want tonight's fire", lines[1])
+	}
+}
+
+func TestBuildDigestSkipsBrokenSchedules(t *testing.T) {
+	now := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	rs := []Reminder{
+		{ID: 1, TZ: "UTC", Hour: 9, Message: "ok"},
+		{ID: 2, TZ: "UTC", Mode: modeWeekly, Message: "no days"},
+	}
+	got := buildDigest(rs, now)
+	if !strings.Contains(got, "**1**") || strings.Contains(got, "**2**") {
+		t.Errorf("digest = %q, want only reminder 1 listed", got)
+	}
+}
+
+func TestHandleTestDM(t *testing.T) {
+	blocked := func(c discordCall) (int, any) {
+		if c.Method == http.MethodPost && c.Path == "/channels/dm-u1/messages" {
+			return http.StatusForbidden, discordError(discordgo.ErrCodeCannotSendMessagesToThisUser, "Cannot send messages to this user")
+		}
+		return 0, nil
+	}
+	down := func(c discordCall) (int, any) {
+		if c.Path == "/users/@me/channels" {
+			return http.StatusInternalServerError, discordError(0, "500: Internal Server Error")
+		}
+		return 0, nil
+	}
+	for _, c := range []struct {
+		name  string
+		reply func(discordCall) (int, any)
+		want  string
+		sent  bool
+	}{
+		{"delivered", nil, "✅ I sent you a DM.", true},
+		{"DMs closed", blocked, "❌ I can't DM you. You've probably turned off DMs", false},
+		{"Discord down", down, "❌ I couldn't DM you just now", false},
+	} {
+		s, f := newFakeDiscord(c.reply)
+		handleTestDM(s, slash("testdm", "u1"))
+		got := f.replies(t)
+		if len(got) != 1 || !strings.HasPrefix(got[0], c.want) {
+			t.Errorf("%s: replies = %q, want one starting %q", c.name, got, c.want)
+		}
+		posts := f.posts(t)
+		if c.sent && (len(posts) != 1 || posts[0].ChannelID != "dm-u1") {
+			t.Errorf("%s: posts = %+v, want the test DM to u1", c.name, posts)
+		}
+	}
+}
+
+func TestFormatReminderLineClock(t *testing.T) {
+	r := Reminder{ID: 4, Name: "tea", Hour: 16, TZ: "UTC", Message: "kettle"}
+	next := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC)
+	if got, want := formatReminderLine(r, next, false), "• **4 tea** every day at 16:00 UTC: kettle (next Wed Oct 14 16:00)"; got != want {
+		t.Errorf("24h: %q\nwant %q", got, want)
+	}
+	if got, want := formatReminderLine(r, next, true), "• **4 tea** every day at 4:00 PM UTC: kettle (next Wed Oct 14 4:00 PM)"; got != want {
+		t.Errorf("12h: %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delivery modes. The channel stays stored in DM mode so /undmize can
+// put a reminder back where it was.
+const (
+	deliverChannel = "channel" // post in ChannelID
+	deliverDM      = "dm"      // DM the owner instead
+)
+
+// postChannel is where r's fires go: its channel, or its owner's DM
+// channel once /dmize has moved it there.
+func postChannel(s *discordgo.Session, r Reminder) (string, error) {
+	if r.Delivery != deliverDM {
+		return r.ChannelID, nil
+	}
+	ch, err := s.UserChannelCreate(r.UserID)
+	if err != nil {
+		return "", err
+	}
+	return ch.ID, nil
+}
+
+// handleDMize moves one of the caller's reminders to their DMs, or with
+// dm unset back to its channel. Moving to DMs first sends a DM, so a user
+// whose DMs are closed finds out now rather than at the next fire.
+func handleDMize(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate, dm bool) {
+	ref := ic.ApplicationCommandData().Options[0].StringValue() // "42", "standup"
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	if dm == (r.Delivery == deliverDM) {
+		if dm {
+			respond(s, ic, fmt.Sprintf("Reminder %d already goes to your DMs.", id))
+		} else {
+			respond(s, ic, fmt.Sprintf("Reminder %d already posts in <#%s>.", id, r.ChannelID))
+		}
+		return
+	}
+
+	mode := deliverChannel
+	if dm {
+		mode = deliverDM
+		note := fmt.Sprintf("📬 Reminder %d will be delivered here from now on. /undmize %d moves it back to <#%s>.", id, id, r.ChannelID)
+		if err := sendDM(s, r.UserID, note); err != nil {
+			respond(s, ic, fmt.Sprintf("%s Reminder %d stays where it is.", dmTestResult(err), id))
+			return
+		}
+	}
+
+	// the last fire is in the other channel, so a reply chain starts over
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET delivery = $2, last_message_id = '', updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, mode).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "moving your reminder", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if dm {
+		msg := fmt.Sprintf("Reminder %d now goes to your DMs ✅", id)
+		if len(r.Mirrors) > 0 {
+			msg += " Its mirror channels still get a copy in their channel."
+		}
+		respond(s, ic, msg)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d posts in <#%s> again ✅", id, r.ChannelID))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPostChannelKeepsChannelDelivery(t *testing.T) {
+	for _, mode := range []string{"", deliverChannel} {
+		r := Reminder{ChannelID: "100", UserID: "7", Delivery: mode}
+		got, err := postChannel(nil, r)
+		if err != nil || got != "100" {
+			t.Errorf("delivery %q: postChannel = %q, %v; want 100", mode, got, err)
+		}
+	}
+}
+
+func TestPostChannelDM(t *testing.T) {
+	s, _ := newFakeDiscord(nil)
+	got, err := postChannel(s, Reminder{ChannelID: "100", UserID: "7", Delivery: deliverDM})
+	if err != nil || got != "dm-7" {
+		t.Errorf("postChannel = %q, %v; want the owner's DM channel", got, err)
+	}
+}
+func TestMirrorCopyPostsInMirrorChannel(t *testing.T) {
+	r := Reminder{ID: 1, ChannelID: "100", Delivery: deliverDM, Mirrors: []string{"200"}}
+	m := mirrorCopy(r, "200")
+	if m.ChannelID != "200" || m.Delivery != deliverChannel {
+		t.Errorf("mirrorCopy = channel %q delivery %q, want 200 in the channel", m.ChannelID, m.Delivery)
+	}
+	if r.Delivery != deliverDM || r.ChannelID != "100" {
+		t.Error("mirrorCopy changed the original reminder")
+	}
+}
+
+func TestDMizeSwitch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-dmize'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, last_message_id)
+		 VALUES ('test-dmize', 'c1', 'g1', 'stretch', 9, 0, 'UTC', true, 'm-old') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	ref := strconv.Itoa(id)
+	stored := func() (delivery, channel, last string) {
+		if err := db.QueryRow(ctx,
+			`SELECT delivery, channel_id, last_message_id FROM reminders WHERE id=$1`, id).Scan(&delivery, &channel, &last); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	closed := func(c discordCall) (int, any) {
+		if c.Method == http.MethodPost && c.Path == "/channels/dm-test-dmize/messages" {
+			return http.StatusForbidden, discordError(discordgo.ErrCodeCannotSendMessagesToThisUser, "Cannot send messages to this user")
+		}
+		return 0, nil
+	}
+	s, f := newFakeDiscord(closed)
+	handleDMize(ctx, db, realClock{}, s, slash("dmize", "test-dmize", "id", ref), true)
+	if got := f.replies(t); len(got) != 1 || !strings.HasSuffix(got[0], "Reminder "+ref+" stays where it is.") {
+		t.Errorf("DMs closed: replies = %q", got)
+	}
+	if delivery, _, _ := stored(); delivery != deliverChannel {
+		t.Fatalf("DMs closed but delivery = %q", delivery)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleDMize(ctx, db, realClock{}, s, slash("dmize", "test-dmize", "id", ref), true)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Reminder "+ref+" now goes to your DMs ✅" {
+		t.Errorf("dmize: replies = %q", got)
+	}
+	if posts := f.posts(t); len(posts) != 1 || posts[0].ChannelID != "dm-test-dmize" || !strings.Contains(posts[0].Content, "/undmize "+ref) {
+		t.Errorf("dmize: posts = %+v, want a note in their DMs", posts)
+	}
+	if delivery, channel, last := stored(); delivery != deliverDM || channel != "c1" || last != "" {
+		t.Errorf("after /dmize: delivery %q, channel %q, last message %q; want dm, c1 kept, chain reset", delivery, channel, last)
+	}
+	if _, ok := scheduledNext(id); !ok {
+		t.Error("not rescheduled after /dmize")
+	}
+
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, f = newFakeDiscord(nil)
+	fireReminder(db, realClock{}, s, r, time.UTC)
+	if posts := f.posts(t); len(posts) != 1 || posts[0].ChannelID != "dm-test-dmize" {
+		t.Errorf("fire posted %+v, want it in their DMs", posts)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleDMize(ctx, db, realClock{}, s, slash("dmize", "test-dmize", "id", ref), true)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Reminder "+ref+" already goes to your DMs." {
+		t.Errorf("second /dmize: replies = %q", got)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleDMize(ctx, db, realClock{}, s, slash("undmize", "test-dmize", "id", ref), false)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Reminder "+ref+" posts in <#c1> again ✅" {
+		t.Errorf("undmize: replies = %q", got)
+	}
+	if delivery, channel, _ := stored(); delivery != deliverChannel || channel != "c1" {
+		t.Errorf("after /undmize: delivery %q in %q, want back in c1", delivery, channel)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fetchPlaceholder marks where the fetched text goes in a reminder's
+// message. Without one it's added on a line of its own.
+const fetchPlaceholder = "{fetched}"
+
+// maxFetchBytes caps how much of a response is read.
+const maxFetchBytes = 64 << 10
+
+// fetchClient fetches dynamic reminder text. The URLs come from users, so
+// it refuses to connect anywhere but public addresses and gives up fast
+// enough not to hold up the fire.
+var fetchClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Control: publicOnly}).DialContext,
+	},
+}
+
+// publicOnly is a net.Dialer Control refusing loopback, private and other
+// non-public addresses, checked after DNS so a hostname can't sneak by.
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("%s is not a public address", host)
+	}
+	return nil
+}
+
+// checkFetchURL validates /fetch's url. The error is meant for the user.
+func checkFetchURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("The URL must be an https:// link.")
+	}
+	return nil
+}
+
+// fetchText GETs rawURL and returns its body, or with a field the value
+// at that path in the JSON body.
+func fetchText(ctx context.Context, rawURL, field string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", errors.New(resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		return strings.TrimSpace(string(body)), nil
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", err
+	}
+	return selectField(v, field)
+}
+
+// selectField follows a dotted path such as "data.quotes.0.text" into a
+// decoded JSON value, numbers indexing arrays. The value found is
+// returned as text: strings as they are, anything else as JSON.
+func selectField(v any, path string) (string, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return "", fmt.Errorf("no field %q", key)
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("no index %q", key)
+			}
+			v = node[i]
+		default:
+			return "", fmt.Errorf("no field %q", key)
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// dynamicMessage is r's message with the fetched text filled in. If the
+// fetch fails it's logged and the message goes out without it.
+func dynamicMessage(ctx context.Context, r Reminder) string {
+	text, err := fetchText(ctx, r.FetchURL, r.FetchField)
+	if err != nil {
+		logRepeated("fetch text for reminder %d: %v", r.ID, err)
+		text = ""
+	}
+	if strings.Contains(r.Message, fetchPlaceholder) {
+		return strings.TrimSpace(strings.ReplaceAll(r.Message, fetchPlaceholder, text))
+	}
+	return strings.TrimSpace(r.Message + "\n" + text)
+}
+
+// handleFetch makes a reminder fetch part of its message from a URL each
+// time it fires, or stops it when no URL is given.
+func handleFetch(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref, rawURL, field string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "url":
+			rawURL = strings.TrimSpace(opt.StringValue()) // "https://zenquotes.io/api/today"
+		case "field":
+			field = strings.TrimSpace(opt.StringValue()) // "0.q"
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	var sample string
+	if rawURL != "" {
+		if err := checkFetchURL(rawURL); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+		if sample, err = fetchText(ctx, rawURL, field); err != nil {
+			respond(s, ic, fmt.Sprintf("I couldn't get any text from that URL: %v", err))
+			return
+		}
+	} else {
+		field = ""
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET fetch_url = $2, fetch_field = $3, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, rawURL, field).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the URL", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if rawURL == "" {
+		respond(s, ic, fmt.Sprintf("Reminder %d will just post its message again.", id))
+		return
+	}
+	sample = truncate(sample, 200)
+	respond(s, ic, fmt.Sprintf("Reminder %d will fetch its text each time it fires. Right now it would say:\n> %s",
+		id, strings.ReplaceAll(sample, "\n", "\n> ")))
+}
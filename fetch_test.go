@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeQuotes serves the paths the fetch tests use, and swaps it in as
+// fetchClient for the length of the test.
+func fakeQuotes(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/text":
+			w.Write([]byte("  Stay hydrated.\n"))
+		case "/json":
+			w.Write([]byte(`{"data": {"quotes": [{"text": "Ship it.", "votes": 3}], "tags": ["a", "b"]}}`))
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", maxFetchBytes+100)))
+		default:
+			http.Error(w, "nope", http.StatusNotFound)
+		}
+	}))
+	old := fetchClient
+	fetchClient = srv.Client()
+	t.Cleanup(func() {
+		fetchClient = old
+		srv.Close()
+	})
+	return srv
+}
+
+func TestFetchText(t *testing.T) {
+	srv := fakeQuotes(t)
+	ctx := context.Background()
+	for _, c := range []struct {
+		path, field, want string
+	}{
+		{"/text", "", "Stay hydrated."},
+		{"/json", "data.quotes.0.text", "Ship it."},
+		{"/json", "data.quotes.0.votes", "3"},
+		{"/json", "data.tags", `["a","b"]`},
+	} {
+		if got, err := fetchText(ctx, srv.URL+c.path, c.field); err != nil || got != c.want {
+			t.Errorf("%s %q: fetchText = %q, %v; want %q", c.path, c.field, got, err, c.want)
+		}
+	}
+	if got, err := fetchText(ctx, srv.URL+"/big", ""); err != nil || len(got) != maxFetchBytes {
+		t.Errorf("big body: %d bytes, %v; want it cut at %d", len(got), err, maxFetchBytes)
+	}
+	if _, err := fetchText(ctx, srv.URL+"/missing", ""); err == nil || err.Error() != "404 Not Found" {
+		t.Errorf("404: err = %v", err)
+	}
+	if _, err := fetchText(ctx, srv.URL+"/text", "q"); err == nil {
+		t.Error("a field into a non-JSON body should fail")
+	}
+}
+
+func TestSelectField(t *testing.T) {
+	v := map[string]any{"a": []any{map[string]any{"b": "deep"}}, "n": nil}
+	for path, want := range map[string]string{
+		"a.0.b": "deep",
+		"n":     "null",
+		"a":     `[{"b":"deep"}]`,
+	} {
+		if got, err := selectField(v, path); err != nil || got != want {
+			t.Errorf("selectField(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	for path, want := range map[string]string{
+		"x":       `no field "x"`,
+		"a.1":     `no index "1"`,
+		"a.one":   `no index "one"`,
+		"a.0.b.c": `no field "c"`,
+	} {
+		if _, err := selectField(v, path); err == nil || err.Error() != want {
+			t.Errorf("selectField(%q) = %v, want %q", path, err, want)
+		}
+	}
+}
+
+func TestDynamicMessage(t *testing.T) {
+	srv := fakeQuotes(t)
+	ctx := context.Background()
+	for _, c := range []struct {
+		name string
+		r    Reminder
+		want string
+	}{
+		{"placeholder", Reminder{Message: "Quote of the day: {fetched} 🎉", FetchURL: srv.URL + "/json", FetchField: "data.quotes.0.text"},
+			"Quote of the day: Ship it. 🎉"},
+		{"appended", Reminder{Message: "Today:", FetchURL: srv.URL + "/text"}, "Today:\nStay hydrated."},
+		{"fetch fails", Reminder{ID: 9, Message: "Quote: {fetched}", FetchURL: srv.URL + "/missing"}, "Quote:"},
+		{"fetch fails, no placeholder", Reminder{ID: 9, Message: "Today:", FetchURL: srv.URL + "/missing"}, "Today:"},
+	} {
+		if got := dynamicMessage(ctx, c.r); got != c.want {
+			t.Errorf("%s: dynamicMessage = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFetchClientStaysPublic(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("fetchClient reached a loopback server")
+	}))
+	defer srv.Close()
+	if _, err := fetchText(context.Background(), srv.URL, ""); err == nil || !strings.Contains(err.Error(), "is not a public address") {
+		t.Errorf("err = %v, want the loopback address refused", err)
+	}
+
+	for addr, public := range map[string]bool{
+		"127.0.0.1:443":              false,
+		"10.1.2.3:443":               false,
+		"192.168.0.10:80":            false,
+		"169.254.1.1:80":             false,
+		"[::1]:443":                  false,
+		"[fd00::1]:443":              false,
+		"0.0.0.0:443":                false,
+		"8.8.8.8:443":                true,
+		"[2001:4860:4860::8888]:443": true,
+	} {
+		if err := publicOnly("tcp", addr, nil); (err == nil) != public {
+			t.Errorf("publicOnly(%s) = %v, want public %t", addr, err, public)
+		}
+	}
+}
+
+func TestCheckFetchURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://zenquotes.io/api/today": true,
+		"http://zenquotes.io/api/today":  false,
+		"https://":                       false,
+		"zenquotes.io":                   false,
+		"file:///etc/passwd":             false,
+	} {
+		if err := checkFetchURL(raw); (err == nil) != ok {
+			t.Errorf("checkFetchURL(%q) = %v, want ok %t", raw, err, ok)
+		}
+	}
+}
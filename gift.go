@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// gift is a reminder offered to another user, waiting for them to accept.
+type gift struct {
+	ID                          int
+	FromUser, ToUser            string
+	ChannelID, GuildID, Message string
+	Hour, Min                   int
+	TZ                          string
+}
+
+// handleGift offers a daily reminder to another user. They get a DM with
+// accept and decline buttons; nothing is scheduled until they accept, and
+// then the reminder is theirs.
+func handleGift(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	g := gift{FromUser: ic.Member.User.ID, ChannelID: ic.ChannelID, GuildID: ic.GuildID}
+	var target *discordgo.User
+	var clock string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "user":
+			target = ic.ApplicationCommandData().Resolved.Users[opt.Value.(string)]
+		case "time":
+			clock = opt.StringValue()
+		case "timezone":
+			g.TZ = opt.StringValue()
+		case "message":
+			g.Message = opt.StringValue()
+		}
+	}
+	if target == nil {
+		respond(s, ic, "I couldn't find that user.")
+		return
+	}
+	if target.Bot {
+		respond(s, ic, "Bots can't be given reminders.")
+		return
+	}
+	if target.ID == g.FromUser {
+		respond(s, ic, "Use /remind for your own reminders.")
+		return
+	}
+	g.ToUser = target.ID
+
+	var err error
+	if g.Hour, g.Min, err = parseClock(clock); err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	if g.TZ == "" {
+		if g.TZ, err = defaultTZ(ctx, db, g.ToUser, g.GuildID); err != nil {
+			respondErr(s, ic, "looking up their timezone", err)
+			return
+		}
+	}
+	if _, err := time.LoadLocation(g.TZ); err != nil {
+		respond(s, ic, invalidTZ(g.TZ))
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`INSERT INTO gifts (from_user, to_user, channel_id, guild_id, message, hour, minute, tz)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id`,
+		g.FromUser, g.ToUser, g.ChannelID, g.GuildID, g.Message, g.Hour, g.Min, g.TZ).Scan(&g.ID); err != nil {
+		respondErr(s, ic, "saving the gift", err)
+		return
+	}
+
+	if err := sendGiftOffer(s, g); err != nil {
+		log.Printf("gift %d offer: %v", g.ID, err)
+		if _, derr := db.Exec(ctx, `DELETE FROM gifts WHERE id=$1`, g.ID); derr != nil {
+			log.Printf("drop gift %d: %v", g.ID, derr)
+		}
+		respond(s, ic, fmt.Sprintf("I couldn't DM <@%s>, so the reminder wasn't offered. They may have DMs turned off.", g.ToUser))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("🎁 I’ve asked <@%s> whether they want this reminder. It starts once they accept.", g.ToUser))
+}
+
+// sendGiftOffer DMs the recipient of g with accept and decline buttons.
+func sendGiftOffer(s *discordgo.Session, g gift) error {
+	ch, err := s.UserChannelCreate(g.ToUser)
+	if err != nil {
+		return err
+	}
+	id := strconv.Itoa(g.ID)
+	_, err = s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s> would like to remind you every day at %02d:%02d %s in <#%s>:\n> %s",
+			g.FromUser, g.Hour, g.Min, g.TZ, g.ChannelID, g.Message),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Accept", Style: discordgo.SuccessButton, CustomID: "gift:accept:" + id},
+				discordgo.Button{Label: "Decline", Style: discordgo.SecondaryButton, CustomID: "gift:decline:" + id},
+			}},
+		},
+	})
+	return err
+}
+
+// giftButton handles accept and decline on a gift offer. Either way the
+// offer is used up.
+func giftButton(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	action, rawID, _ := strings.Cut(strings.TrimPrefix(ic.MessageComponentData().CustomID, "gift:"), ":")
+	id, _ := strconv.Atoi(rawID)
+	presser := ic.User
+	if ic.Member != nil {
+		presser = ic.Member.User
+	}
+
+	// swap the buttons out; the outcome is edited in below
+	empty := []discordgo.MessageComponent{}
+	if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: ic.Message.Content, Components: empty},
+	}); err != nil {
+		log.Printf("ack gift %d: %v", id, err)
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	var g gift
+	err := db.QueryRow(ctx,
+		`DELETE FROM gifts WHERE id=$1 AND to_user=$2
+		 RETURNING id, from_user, to_user, channel_id, guild_id, message, hour, minute, tz`,
+		id, presser.ID).Scan(&g.ID, &g.FromUser, &g.ToUser, &g.ChannelID, &g.GuildID, &g.Message, &g.Hour, &g.Min, &g.TZ)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respond(s, ic, ic.Message.Content+"\n\nThis offer has already been answered.")
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "answering the gift", err)
+		return
+	}
+
+	if action != "accept" {
+		respond(s, ic, ic.Message.Content+"\n\nDeclined.")
+		if err := sendDM(s, g.FromUser, fmt.Sprintf("<@%s> declined your reminder “%s”.", g.ToUser, g.Message)); err != nil {
+			log.Printf("gift %d decline DM: %v", g.ID, err)
+		}
+		return
+	}
+
+	loc, err := time.LoadLocation(g.TZ)
+	if err != nil {
+		respondErr(s, ic, "accepting the gift", err)
+		return
+	}
+	row := Reminder{
+		UserID:    g.ToUser,
+		ChannelID: g.ChannelID,
+		GuildID:   g.GuildID,
+		Message:   g.Message,
+		Hour:      g.Hour,
+		Min:       g.Min,
+		TZ:        g.TZ,
+		Active:    true,
+	}
+	if !saveNewReminder(ctx, db, clk, s, ic, &row, loc) {
+		return
+	}
+	respond(s, ic, fmt.Sprintf("%s\n\nAccepted ✅ It’s your reminder %d now; /stop %d turns it off.", ic.Message.Content, row.ID, row.ID))
+	if err := sendDM(s, g.FromUser, fmt.Sprintf("<@%s> accepted your reminder “%s”.", g.ToUser, g.Message)); err != nil {
+		log.Printf("gift %d accept DM: %v", g.ID, err)
+	}
+}
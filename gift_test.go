@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// giftTo is /gift from u1 to user, with user resolved the way Discord
+// sends it.
+func giftTo(user *discordgo.User, opts ...any) *discordgo.InteractionCreate {
+	ic := slash("gift", "u1", append([]any{"user", user.ID}, opts...)...)
+	data := ic.Data.(discordgo.ApplicationCommandInteractionData)
+	data.Resolved = &discordgo.ApplicationCommandInteractionDataResolved{
+		Users: map[string]*discordgo.User{user.ID: user},
+	}
+	ic.Data = data
+	return ic
+}
+
+func TestGiftRefuses(t *testing.T) {
+	tests := []struct {
+		user *discordgo.User
+		want string
+	}{
+		{&discordgo.User{ID: "u1"}, "Use /remind for your own reminders."},
+		{&discordgo.User{ID: "b1", Bot: true}, "Bots can't be given reminders."},
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(nil)
+		handleGift(context.Background(), nil, s, giftTo(tt.user, "time", "09:00", "message", "hi"))
+		if got := f.replies(t); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("gift to %s: replies %q, want %q", tt.user.ID, got, tt.want)
+		}
+	}
+}
+
+func TestGiftAcceptAndDecline(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		rows, _ := db.Query(ctx, `DELETE FROM reminders WHERE user_id = 'test-gift-to' RETURNING id`)
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			unschedule(id)
+		}
+		rows.Close()
+		db.Exec(ctx, `DELETE FROM gifts WHERE to_user = 'test-gift-to'`)
+	})
+	to := &discordgo.User{ID: "test-gift-to"}
+
+	// offer returns the custom IDs of the offer's buttons
+	offer := func(msg string) (accept, decline string) {
+		t.Helper()
+		s, f := newFakeDiscord(nil)
+		handleGift(ctx, db, s, giftTo(to, "time", "07:00", "timezone", "UTC", "message", msg))
+		posts := f.posts(t)
+		if len(posts) != 1 || posts[0].ChannelID != "dm-test-gift-to" {
+			t.Fatalf("offer posts = %+v (replies %q), want one DM to the recipient", posts, f.replies(t))
+		}
+		var row struct {
+			Components []struct {
+				CustomID string `json:"custom_id"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(posts[0].Components[0], &row); err != nil || len(row.Components) != 2 {
+			t.Fatalf("offer buttons %s: %v", posts[0].Components[0], err)
+		}
+		return row.Components[0].CustomID, row.Components[1].CustomID
+	}
+	owned := func(msg string) bool {
+		var n int
+		if err := db.QueryRow(ctx,
+			`SELECT count(*) FROM reminders WHERE user_id = 'test-gift-to' AND message = $1 AND active`, msg).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+
+	accept, _ := offer("stretch")
+	if owned("stretch") {
+		t.Fatal("the reminder started before it was accepted")
+	}
+	// only the recipient can answer
+	s, f := newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(accept, "someone-else"))
+	if got := f.replies(t); len(got) != 1 || !strings.HasSuffix(got[0], "This offer has already been answered.") || owned("stretch") {
+		t.Errorf("someone else accepting: replies %q", got)
+	}
+	s, f = newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(accept, "test-gift-to"))
+	if !owned("stretch") {
+		t.Errorf("accepting didn't give them the reminder (replies %q)", f.replies(t))
+	}
+	if posts := f.posts(t); len(posts) != 1 || posts[0].ChannelID != "dm-u1" || !strings.Contains(posts[0].Content, "accepted") {
+		t.Errorf("the giver was told %+v", posts)
+	}
+	// and only once
+	s, f = newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(accept, "test-gift-to"))
+	if got := f.replies(t); len(got) != 1 || !strings.HasSuffix(got[0], "This offer has already been answered.") {
+		t.Errorf("accepting twice: replies %q", got)
+	}
+
+	_, decline := offer("floss")
+	s, f = newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(decline, "test-gift-to"))
+	if owned("floss") {
+		t.Error("a declined gift was scheduled")
+	}
+	if got := f.replies(t); len(got) != 1 || !strings.HasSuffix(got[0], "Declined.") {
+		t.Errorf("declining: replies %q", got)
+	}
+	if posts := f.posts(t); len(posts) != 1 || posts[0].ChannelID != "dm-u1" || !strings.Contains(posts[0].Content, "declined") {
+		t.Errorf("the giver was told %+v", posts)
+	}
+}
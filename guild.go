@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// handleGlobalPause sets or clears the guild-wide pause. Paused reminders
+// stay active and scheduled; fireReminder just skips them.
+func handleGlobalPause(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate, paused bool) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, paused) VALUES ($1,$2)
+		 ON CONFLICT (guild_id) DO UPDATE SET paused = EXCLUDED.paused`,
+		ic.GuildID, paused); err != nil {
+		respondErr(s, ic, "saving the server setting", err)
+		return
+	}
+
+	if paused {
+		respond(s, ic, "⏸️ All reminders in this server are paused. Use /globalresume to turn them back on.")
+		return
+	}
+	respond(s, ic, "▶️ Reminders in this server are back on.")
+}
+
+// handleSetGuildTZ sets the server-wide default timezone used when a
+// member has no preference of their own.
+func handleSetGuildTZ(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+
+	tz := ic.ApplicationCommandData().Options[0].StringValue()
+	if _, err := time.LoadLocation(tz); err != nil {
+		respond(s, ic, invalidTZ(tz))
+		return
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, tz) VALUES ($1,$2)
+		 ON CONFLICT (guild_id) DO UPDATE SET tz = EXCLUDED.tz`,
+		ic.GuildID, tz); err != nil {
+		respondErr(s, ic, "saving the server setting", err)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("This server's default timezone is now %s.", tz))
+}
+
+// handleGreeting turns the "Hey <nickname>," greeting on reminders in this
+// server on or off. The pings are sent either way.
+func handleGreeting(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+
+	on := ic.ApplicationCommandData().Options[0].BoolValue()
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, greet_nickname) VALUES ($1,$2)
+		 ON CONFLICT (guild_id) DO UPDATE SET greet_nickname = EXCLUDED.greet_nickname`,
+		ic.GuildID, on); err != nil {
+		respondErr(s, ic, "saving the server setting", err)
+		return
+	}
+
+	if on {
+		respond(s, ic, "👋 Reminders here will greet people by their server nickname.")
+		return
+	}
+	respond(s, ic, "Reminders here will just ping, without a greeting.")
+}
+
+var channelMentionRe = regexp.MustCompile(`^<#(\d{17,20})>$`)
+
+// parseChannelList turns "<#1> <#2>, 3" into channel IDs, dropping
+// duplicates. The error is meant for the user.
+func parseChannelList(raw string) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, f := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}) {
+		id := f
+		if m := channelMentionRe.FindStringSubmatch(f); m != nil {
+			id = m[1]
+		} else if !snowflakeRe.MatchString(f) {
+			return nil, fmt.Errorf("%q isn't a channel mention.", f)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// channelAllowed reports whether reminders may post in channelID under
+// the guild's allowlist. No list means any channel; a thread is allowed
+// when its parent is.
+func channelAllowed(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, guildID, channelID string) (bool, error) {
+	var allowed []string
+	err := db.QueryRow(ctx,
+		`SELECT reminder_channels FROM guild_prefs WHERE guild_id=$1`, guildID).Scan(&allowed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(allowed) == 0 || slices.Contains(allowed, channelID) {
+		return true, nil
+	}
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		if ch, err = s.Channel(channelID); err != nil {
+			return false, nil
+		}
+	}
+	return ch.IsThread() && slices.Contains(allowed, ch.ParentID), nil
+}
+
+// handleSetReminderChannels sets which channels reminders may be created
+// for in this server. No channels clears the list.
+func handleSetReminderChannels(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+
+	var raw string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "channels" {
+			raw = opt.StringValue()
+		}
+	}
+	ids, err := parseChannelList(raw)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	for _, id := range ids {
+		if channelGuild(s, id) != ic.GuildID {
+			respond(s, ic, fmt.Sprintf("<#%s> isn't a channel in this server.", id))
+			return
+		}
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, reminder_channels) VALUES ($1,$2)
+		 ON CONFLICT (guild_id) DO UPDATE SET reminder_channels = EXCLUDED.reminder_channels`,
+		ic.GuildID, ids); err != nil {
+		respondErr(s, ic, "saving the server setting", err)
+		return
+	}
+
+	if len(ids) == 0 {
+		respond(s, ic, "Reminders can be created for any channel again.")
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminders can now only be created for <#%s>.", strings.Join(ids, ">, <#")))
+}
+
+// upcomingFire is one reminder due inside an /upcoming window.
+type upcomingFire struct {
+	r    Reminder
+	next time.Time
+}
+
+// upcomingWithin picks the reminders in rs whose next fire after now is
+// no later than now+window, soonest first.
+func upcomingWithin(rs []Reminder, now time.Time, window time.Duration) []upcomingFire {
+	var out []upcomingFire
+	for _, r := range rs {
+		next, err := nextFire(r, now)
+		if err != nil || next.Sub(now) > window {
+			continue
+		}
+		out = append(out, upcomingFire{r, next})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].next.Before(out[j].next) })
+	return out
+}
+
+// handleUpcoming lists every reminder in the server due within the next
+// few minutes (an hour by default). Manage Server only.
+func handleUpcoming(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+	window := time.Hour
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "minutes" {
+			window = time.Duration(opt.IntValue()) * time.Minute
+		}
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT `+reminderColumns+` FROM reminders WHERE guild_id=$1 AND active`, ic.GuildID)
+	if err != nil {
+		respondErr(s, ic, "listing upcoming reminders", err)
+		return
+	}
+	var rs []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := scanReminder(rows, &r); err == nil {
+			rs = append(rs, r)
+		}
+	}
+	rows.Close()
+
+	due := upcomingWithin(rs, clk.Now(), window)
+	if len(due) == 0 {
+		respond(s, ic, fmt.Sprintf("Nothing fires in the next %s.", window))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Firing in the next %s:\n", window)
+	for i, u := range due {
+		line := fmt.Sprintf("• <t:%d:t> **%d** <#%s> by <@%s>: %s\n",
+			u.next.Unix(), u.r.ID, u.r.ChannelID, u.r.UserID, u.r.Message)
+		if b.Len()+len(line) > 1900 {
+			fmt.Fprintf(&b, "…and %d more", len(due)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	respond(s, ic, b.String())
+}
+
+// handleSetFallback sets where reminders go when the bot can no longer
+// post in their channel: another channel, the owner's DMs, or nowhere.
+func handleSetFallback(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+
+	var fallback *string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "channel":
+			id := opt.ChannelValue(nil).ID
+			fallback = &id
+		case "dm":
+			if opt.BoolValue() && fallback == nil {
+				dm := fallbackDM
+				fallback = &dm
+			}
+		}
+	}
+	if fallback != nil && *fallback != fallbackDM && channelGuild(s, *fallback) != ic.GuildID {
+		respond(s, ic, "That channel isn't in this server.")
+		return
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, fallback_channel) VALUES ($1,$2)
+		 ON CONFLICT (guild_id) DO UPDATE SET fallback_channel = EXCLUDED.fallback_channel`,
+		ic.GuildID, fallback); err != nil {
+		respondErr(s, ic, "saving the server setting", err)
+		return
+	}
+
+	switch {
+	case fallback == nil:
+		respond(s, ic, "Reminders I can't post any more will just fail, as before.")
+	case *fallback == fallbackDM:
+		respond(s, ic, "Reminders I can't post any more will be DMed to their owners.")
+	default:
+		respond(s, ic, fmt.Sprintf("Reminders I can't post any more will go to <#%s>.", *fallback))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGlobalPauseSkipsFires(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE guild_id = 'test-pause-g'`)
+		db.Exec(context.Background(), `DELETE FROM guild_prefs WHERE guild_id = 'test-pause-g'`)
+	})
+
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, message, hour, minute, tz, active, guild_id)
+		 VALUES ('test-pause-u', 'c1', 'stretch', 9, 0, 'UTC', true, 'test-pause-g')
+		 RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, paused) VALUES ('test-pause-g', true)`); err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, f := newFakeDiscord(nil)
+	fireCount := func() int {
+		var n int
+		if err := db.QueryRow(ctx, `SELECT fire_count FROM reminders WHERE id=$1`, id).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	fireReminder(db, realClock{}, s, r, time.UTC)
+	if got := f.posts(t); len(got) != 0 || fireCount() != 0 {
+		t.Fatalf("paused server: %d posts, fire_count %d; want nothing", len(got), fireCount())
+	}
+
+	if _, err := db.Exec(ctx, `UPDATE guild_prefs SET paused = false WHERE guild_id = 'test-pause-g'`); err != nil {
+		t.Fatal(err)
+	}
+	fireReminder(db, realClock{}, s, r, time.UTC)
+	if got := f.posts(t); len(got) != 1 || fireCount() != 1 {
+		t.Errorf("resumed server: %d posts, fire_count %d; want one fire", len(got), fireCount())
+	}
+}
+
+func TestParseChannelList(t *testing.T) {
+	const a, b = "100000000000000001", "100000000000000002"
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"<#" + a + "> <#" + b + ">", []string{a, b}, false},
+		{"<#" + a + ">," + b + ", <#" + a + ">", []string{a, b}, false},
+		{"#general", nil, true},
+		{"<@" + a + ">", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseChannelList(tt.raw)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("parseChannelList(%q) = %v, %v; want %v, error %t", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetReminderChannelsNeedsAdmin(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleSetReminderChannels(context.Background(), nil, s, slash("setreminderchannels", "u1", "channels", "<#100000000000000001>"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "You need the Manage Server permission to do that." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestChannelAllowlist(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM guild_prefs WHERE guild_id IN ('g1', 'g-open')`)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-allow'`)
+	})
+	if _, err := db.Exec(ctx,
+		`INSERT INTO guild_prefs (guild_id, reminder_channels) VALUES ('g1', '{c1}'), ('g-open', '{}')`); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := newFakeDiscord(nil)
+	s.State.GuildAdd(&discordgo.Guild{ID: "g1"})
+	s.State.ChannelAdd(&discordgo.Channel{ID: "t1", GuildID: "g1", ParentID: "c1", Type: discordgo.ChannelTypeGuildPublicThread})
+	s.State.ChannelAdd(&discordgo.Channel{ID: "t2", GuildID: "g1", ParentID: "c2", Type: discordgo.ChannelTypeGuildPublicThread})
+
+	tests := []struct {
+		guild, channel string
+		want           bool
+	}{
+		{"g1", "c1", true},
+		{"g1", "c2", false},
+		{"g1", "t1", true},  // thread under an allowed channel
+		{"g1", "t2", false}, // and under one that isn't
+		{"g-open", "c2", true},
+		{"g-unset", "c2", true},
+	}
+	for _, tt := range tests {
+		got, err := channelAllowed(ctx, db, s, tt.guild, tt.channel)
+		if err != nil || got != tt.want {
+			t.Errorf("channelAllowed(%s, %s) = %t, %v; want %t", tt.guild, tt.channel, got, err, tt.want)
+		}
+	}
+
+	// creating a reminder enforces it, admins included
+	ic := slash("remind", "test-allow", "time", "09:00", "timezone", "UTC", "message", "hi")
+	ic.ChannelID = "c2"
+	ic.Member.Permissions = discordgo.PermissionManageServer
+	s, f := newFakeDiscord(nil)
+	handleRemind(ctx, db, realClock{}, s, ic)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Reminders aren't allowed in <#c2> on this server." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestUpcomingWithin(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	rs := []Reminder{
+		{ID: 1, TZ: "UTC", Hour: 9, Min: 45},
+		{ID: 2, TZ: "UTC", Hour: 9, Min: 10},
+		{ID: 3, TZ: "UTC", Hour: 10, Min: 0},         // exactly at the end of the hour
+		{ID: 4, TZ: "UTC", Hour: 10, Min: 1},         // just past it
+		{ID: 5, TZ: "UTC", Hour: 9, Min: 0},          // fires now, so next is tomorrow
+		{ID: 6, TZ: "Asia/Tokyo", Hour: 18, Min: 30}, // 09:30 UTC
+		{ID: 7, TZ: "UTC", Mode: modeCron, CronSpec: "bad"},
+	}
+	var got []int
+	for _, u := range upcomingWithin(rs, now, time.Hour) {
+		got = append(got, u.r.ID)
+	}
+	if want := []int{2, 6, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("upcoming in an hour = %v, want %v", got, want)
+	}
+
+	got = nil
+	for _, u := range upcomingWithin(rs, now, 15*time.Minute) {
+		got = append(got, u.r.ID)
+	}
+	if want := []int{2}; !slices.Equal(got, want) {
+		t.Errorf("upcoming in 15 minutes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+)
+
+// maxLeadMin caps /headsup at a day ahead.
+const maxLeadMin = 24 * 60
+
+// leadSchedule fires lead before every fire of next.
+type leadSchedule struct {
+	next cron.Schedule
+	lead time.Duration
+}
+
+func (l leadSchedule) Next(t time.Time) time.Time {
+	n := l.next.Next(t.Add(l.lead))
+	if n.IsZero() {
+		return n
+	}
+	return n.Add(-l.lead)
+}
+
+// checkLead makes sure a heads-up lead minutes early still lands after
+// the previous fire of r. The error is meant for the user.
+func checkLead(r Reminder, minutes int, now time.Time) error {
+	times, err := nextFires(r, now, 2)
+	if err != nil || len(times) < 2 {
+		return err
+	}
+	if gap := times[1].Sub(times[0]); time.Duration(minutes)*time.Minute >= gap {
+		return fmt.Errorf("The next two fires of reminder %d are only %d minutes apart, so the heads-up has to be shorter than that.", r.ID, int(gap.Minutes()))
+	}
+	return nil
+}
+
+// headsUp posts the lead-minute warning for r's upcoming fire: the
+// reminder text, prefixed, without buttons or fallbacks. It's skipped
+// wherever the fire itself would be.
+func headsUp(db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder, loc *time.Location) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	var active, paused bool
+	_ = db.QueryRow(ctx,
+		`SELECT r.active AND NOT r.skip_next, COALESCE(g.paused, false)
+		   FROM reminders r
+		   LEFT JOIN guild_prefs g ON g.guild_id = r.guild_id
+		  WHERE r.id=$1`, r.ID).Scan(&active, &paused)
+	if !active || paused {
+		return
+	}
+	now := clk.Now().In(loc)
+	due := now.Add(time.Duration(r.LeadMin) * time.Minute)
+	if boosted(r, now) || !onFireDay(r, due) || limitReached(r, due) {
+		return
+	}
+
+	r.Message = localizeMessage(r.Message, userLanguage(ctx, db, r.UserID), due)
+	msg := truncate(fmt.Sprintf("(in %d min) %s", r.LeadMin, renderReminder(r, "")), maxMessageLen)
+	if dryRun {
+		log.Printf("DRY RUN: would send heads-up for reminder %d to %s: %q", r.ID, r.ChannelID, msg)
+		return
+	}
+	channelID, err := postChannel(s, r)
+	if err != nil {
+		logRepeated("heads-up for reminder %d: %v", r.ID, err)
+		return
+	}
+	sendLimit.take(1)
+	if _, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         msg,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: r.pinged()},
+		Flags:           r.messageFlags(),
+	}); err != nil {
+		logRepeated("heads-up for reminder %d: %v", r.ID, err)
+	}
+}
+
+// handleHeadsUp sets how many minutes before each fire of a reminder a
+// heads-up is posted, 0 = none.
+func handleHeadsUp(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var minutes int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "minutes":
+			minutes = int(opt.IntValue()) // 10
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	if minutes > 0 {
+		if err := checkLead(r, minutes, clk.Now()); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET lead_min = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, minutes).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the heads-up", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if minutes == 0 {
+		respond(s, ic, fmt.Sprintf("No more heads-up before reminder %d.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("⏰ I'll give you a heads-up %d minutes before each fire of reminder %d.", minutes, id))
+}
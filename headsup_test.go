@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestLeadSchedule(t *testing.T) {
+	daily, _ := cron.ParseStandard("0 9 * * *")
+	lead := leadSchedule{daily, 10 * time.Minute}
+	at := func(d, h, m int) time.Time { return time.Date(2026, 10, d, h, m, 0, 0, time.UTC) }
+	for _, c := range []struct{ from, want time.Time }{
+		{at(14, 8, 0), at(14, 8, 50)},
+		{at(14, 8, 49), at(14, 8, 50)},
+		{at(14, 8, 50), at(15, 8, 50)}, // strictly after, like cron
+		{at(14, 8, 55), at(15, 8, 50)}, // the fire itself is still ahead
+		{at(14, 9, 30), at(15, 8, 50)},
+	} {
+		if got := lead.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("Next(%s) = %s, want %s", c.from.Format("02 15:04"), got.Format("02 15:04"), c.want.Format("02 15:04"))
+		}
+	}
+
+	never := leadSchedule{rruleSchedule{rule: rrule{freq: "MONTHLY", interval: 12, byMonth: []int{30}},
+		anchor: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), loc: time.UTC}, time.Hour}
+	if got := never.Next(at(14, 0, 0)); !got.IsZero() {
+		t.Errorf("a schedule that never fires gave a heads-up at %s", got)
+	}
+}
+
+func TestCheckLead(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		name    string
+		r       Reminder
+		minutes int
+		wantErr string
+	}{
+		{"daily, an hour early", Reminder{ID: 1, Hour: 9, TZ: "UTC"}, 60, ""},
+		{"daily, just under a day", Reminder{ID: 1, Hour: 9, TZ: "UTC"}, 24*60 - 1, ""},
+		{"daily, a whole day", Reminder{ID: 1, Hour: 9, TZ: "UTC"}, 24 * 60, "only 1440 minutes apart"},
+		{"every 30 min, 29 early", Reminder{ID: 2, Mode: modeInterval, IntervalMin: 30, TZ: "UTC"}, 29, ""},
+		{"every 30 min, 30 early", Reminder{ID: 2, Mode: modeInterval, IntervalMin: 30, TZ: "UTC"}, 30, "reminder 2 are only 30 minutes apart"},
+		{"once, ten hours early", Reminder{ID: 3, Mode: modeOnce, Hour: 9, TZ: "UTC", Until: &until}, 600, ""},
+	} {
+		err := checkLead(c.r, c.minutes, now)
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", c.name, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("%s: err = %v, want one saying %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestScheduleOneAddsHeadsUp(t *testing.T) {
+	s, _ := newFakeDiscord(nil)
+	const id = 2147483001
+	t.Cleanup(func() { unschedule(id) })
+	if err := reschedule(nil, realClock{}, s, Reminder{ID: id, Hour: 9, TZ: "UTC", Active: true, LeadMin: 15}); err != nil {
+		t.Fatal(err)
+	}
+
+	cronsMu.Lock()
+	entries := crons[id].Entries()
+	cronsMu.Unlock()
+	if len(entries) != 2 {
+		t.Fatalf("%d entries, want the fire and its heads-up", len(entries))
+	}
+	from := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Schedule.Next(from).Format("15:04"))
+	}
+	if !(got[0] == "09:00" && got[1] == "08:45") && !(got[0] == "08:45" && got[1] == "09:00") {
+		t.Errorf("entries fire at %v, want 09:00 and 08:45", got)
+	}
+}
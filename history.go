@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// logFire records one fire of r in reminder_log with text, the post as it
+// was rendered and sent, so it can be shown again later even if r has
+// changed since.
+func logFire(ctx context.Context, db *pgxpool.Pool, r Reminder, text string, ok bool) {
+	if _, err := db.Exec(ctx,
+		`INSERT INTO reminder_log (reminder_id, user_id, channel_id, message, success)
+		 VALUES ($1,$2,$3,$4,$5)`,
+		r.ID, r.UserID, r.ChannelID, text, ok); err != nil {
+		log.Printf("log fire of reminder %d: %v", r.ID, err)
+	}
+}
+
+// firedEntry is one row of reminder_log.
+type firedEntry struct {
+	ReminderID int
+	ChannelID  string
+	Message    string
+	FiredAt    time.Time
+}
+
+// lastFired is the latest successful fire of any of userID's reminders.
+func lastFired(ctx context.Context, db *pgxpool.Pool, userID string) (firedEntry, error) {
+	var e firedEntry
+	err := db.QueryRow(ctx,
+		`SELECT reminder_id, channel_id, message, fired_at FROM reminder_log
+		  WHERE user_id=$1 AND success
+		  ORDER BY fired_at DESC LIMIT 1`, userID).Scan(&e.ReminderID, &e.ChannelID, &e.Message, &e.FiredAt)
+	return e, err
+}
+
+// handleLast shows the caller their most recently fired reminder again,
+// for when the ping scrolled past. With dm it goes to their DMs instead.
+func handleLast(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var dm bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "dm" {
+			dm = opt.BoolValue()
+		}
+	}
+
+	e, err := lastFired(ctx, db, ic.Member.User.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respond(s, ic, "None of your reminders has fired yet.")
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "finding your last reminder", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Your last reminder (ID %d), sent <t:%d:R> in <#%s>:\n%s",
+		e.ReminderID, e.FiredAt.Unix(), e.ChannelID, e.Message)
+	msg = truncate(msg, maxMessageLen)
+	if !dm {
+		respond(s, ic, msg)
+		return
+	}
+	if err := sendDM(s, ic.Member.User.ID, msg); err != nil {
+		respond(s, ic, dmTestResult(err))
+		return
+	}
+	respond(s, ic, "Sent it to your DMs.")
+}
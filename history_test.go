@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleLast(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() { db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id LIKE 'test-last%'`) })
+
+	s, f := newFakeDiscord(nil)
+	handleLast(ctx, db, s, slash("last", "test-last"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "None of your reminders has fired yet." {
+		t.Errorf("before any fire: replies = %q", got)
+	}
+
+	var mine, theirs int
+	for _, row := range []struct {
+		user string
+		id   *int
+	}{{"test-last", &mine}, {"test-last-other", &theirs}} {
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz)
+			 VALUES ($1, 'c1', 'g1', 'edited since', 9, 0, 'UTC') RETURNING id`, row.user).Scan(row.id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the log keeps the message as sent; a failed send and someone else's
+	// later fire don't count
+	logFire(ctx, db, Reminder{ID: mine, UserID: "test-last", ChannelID: "c7"}, "an hour ago", true)
+	db.Exec(ctx, `UPDATE reminder_log SET fired_at = now() - interval '1 hour' WHERE reminder_id = $1`, mine)
+	logFire(ctx, db, Reminder{ID: mine, UserID: "test-last", ChannelID: "c8"}, "just now", true)
+	logFire(ctx, db, Reminder{ID: mine, UserID: "test-last", ChannelID: "c9"}, "didn't go out", false)
+	logFire(ctx, db, Reminder{ID: theirs, UserID: "test-last-other", ChannelID: "c1"}, "not yours", true)
+
+	e, err := lastFired(ctx, db, "test-last")
+	if err != nil || e.ReminderID != mine || e.ChannelID != "c8" || e.Message != "just now" {
+		t.Fatalf("lastFired = %+v, %v", e, err)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleLast(ctx, db, s, slash("last", "test-last"))
+	want := fmt.Sprintf("Your last reminder (ID %d), sent <t:%d:R> in <#c8>:\njust now", mine, e.FiredAt.Unix())
+	if got := f.replies(t); len(got) != 1 || got[0] != want {
+		t.Errorf("replies = %q, want %q", got, want)
+	}
+
+	s, f = newFakeDiscord(nil)
+	handleLast(ctx, db, s, slash("last", "test-last", "dm", true))
+	posts := f.posts(t)
+	if len(posts) != 1 || posts[0].ChannelID != "dm-test-last" || !strings.HasSuffix(posts[0].Content, "just now") {
+		t.Errorf("dm posts = %+v", posts)
+	}
+	if got := f.replies(t); len(got) != 1 || got[0] != "Sent it to your DMs." {
+		t.Errorf("dm replies = %q", got)
+	}
+}
+
+// A fire logs the post as it went out, not the stored template.
+func TestFireLogsRenderedText(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-log-text'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-log-text', 'c1', 'g1', $1, 9, 0, 'UTC', true) RETURNING id`, greetingToken+" # standup").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, f := newFakeDiscord(nil)
+	fireReminder(db, realClock{}, s, r, time.UTC)
+	posts := f.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("%d posts, want 1", len(posts))
+	}
+	e, err := lastFired(ctx, db, "test-log-text")
+	if err != nil || e.Message != posts[0].Content {
+		t.Errorf("logged %q, %v; want the post %q", e.Message, err, posts[0].Content)
+	}
+	if strings.Contains(e.Message, greetingToken) {
+		t.Errorf("logged %q with the token still in it", e.Message)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// inspectFires is how many upcoming fire times /inspect shows.
+const inspectFires = 5
+
+// handleInspect dumps a reminder's stored fields, its cron spec and its
+// next few fire times, for debugging schedules. Owner or admin only.
+func handleInspect(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	id, ok := resolveRef(ctx, db, s, ic, ic.ApplicationCommandData().Options[0].StringValue())
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || (r.UserID != ic.Member.User.ID && !(isAdmin(ic) && r.GuildID == ic.GuildID)) {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	respond(s, ic, formatInspect(r, clk.Now()))
+}
+
+// formatInspect renders the /inspect report for r as of now.
+func formatInspect(r Reminder, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Reminder %d**\n", r.ID)
+	fmt.Fprintf(&b, "owner: <@%s>, channel: <#%s>, active: %t\n", r.UserID, r.ChannelID, r.Active)
+	if r.Name != "" {
+		fmt.Fprintf(&b, "name: %q\n", r.Name)
+	}
+	fmt.Fprintf(&b, "message: %q\n", r.Message)
+	fmt.Fprintf(&b, "schedule: %s\n", describeSchedule(r))
+	if spec, _, err := buildSpec(r, now); err != nil {
+		fmt.Fprintf(&b, "cron spec: invalid (%v)\n", err)
+	} else {
+		fmt.Fprintf(&b, "cron spec: `%s`\n", spec)
+	}
+	if len(r.Extra) > 0 {
+		fmt.Fprintf(&b, "also pings: <@%s>\n", strings.Join(r.Extra, ">, <@"))
+	}
+	if r.MaxFires > 0 {
+		fmt.Fprintf(&b, "fires: %d of %d\n", r.FireCount, r.MaxFires)
+	} else {
+		fmt.Fprintf(&b, "fires: %d\n", r.FireCount)
+	}
+	if r.Until != nil {
+		fmt.Fprintf(&b, "until: %s\n", r.Until.Format("2006-01-02"))
+	}
+	if r.LastFired != nil {
+		fmt.Fprintf(&b, "last fired: <t:%d:f>\n", r.LastFired.Unix())
+	}
+	if r.Failures > 0 {
+		fmt.Fprintf(&b, "consecutive failed sends: %d\n", r.Failures)
+	}
+	fmt.Fprintf(&b, "created: <t:%d:f>, updated: <t:%d:f>\n", r.CreatedAt.Unix(), r.UpdatedAt.Unix())
+	if next, ok := scheduledNext(r.ID); !ok {
+		b.WriteString("scheduler: not scheduled\n")
+	} else if next.IsZero() {
+		b.WriteString("scheduler: scheduled, starting up\n")
+	} else {
+		fmt.Fprintf(&b, "scheduler: next run <t:%d:f>\n", next.Unix())
+	}
+
+	times, err := nextFires(r, now, inspectFires)
+	if err != nil {
+		fmt.Fprintf(&b, "next fires: can't compute (%v)\n", err)
+		return b.String()
+	}
+	b.WriteString("next fires:\n")
+	for _, t := range times {
+		fmt.Fprintf(&b, "• %s\n", t.Format("Mon 2006-01-02 15:04 MST"))
+	}
+	return b.String()
+}
+
+// previewFires is how many fire times /preview lists.
+const previewFires = 10
+
+// handlePreview lists the next fire times of one of the caller's
+// reminders, or of a cron spec they're thinking of using, so they can see
+// what a schedule really means before relying on it.
+func handlePreview(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref, spec, tz string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "cron":
+			spec = strings.TrimSpace(opt.StringValue()) // "30 9 * * 1-5"
+		case "timezone":
+			tz = opt.StringValue() // "Europe/Paris"
+		}
+	}
+	if (ref == "") == (spec == "") {
+		respond(s, ic, "Give either a reminder id or a cron spec.")
+		return
+	}
+
+	var r Reminder
+	if ref != "" {
+		id, ok := resolveRef(ctx, db, s, ic, ref)
+		if !ok {
+			return
+		}
+		var err error
+		if r, err = loadReminder(ctx, db, id); err != nil || r.UserID != ic.Member.User.ID {
+			respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+			return
+		}
+	} else {
+		if tz == "" {
+			var err error
+			if tz, err = defaultTZ(ctx, db, ic.Member.User.ID, ic.GuildID); err != nil {
+				respondErr(s, ic, "looking up your timezone", err)
+				return
+			}
+		}
+		r = Reminder{Mode: modeCron, CronSpec: spec, TZ: tz}
+	}
+
+	respond(s, ic, formatPreview(r, clk.Now()))
+}
+
+// formatPreview renders r's upcoming fires for /preview, stopping early
+// where its until date or fire limit ends it.
+func formatPreview(r Reminder, now time.Time) string {
+	times, err := nextFires(r, now, previewFires)
+	if err != nil {
+		return fmt.Sprintf("That schedule doesn't work: %v", err)
+	}
+
+	left := previewFires
+	if r.MaxFires > 0 {
+		left = min(left, r.MaxFires-r.FireCount)
+	}
+	var b strings.Builder
+	if r.ID != 0 {
+		fmt.Fprintf(&b, "Next fires of reminder %d, %s:\n", r.ID, describeSchedule(r))
+	} else {
+		fmt.Fprintf(&b, "Next fires of `%s`, in %s:\n", r.CronSpec, r.TZ)
+	}
+	n := 0
+	for _, t := range times {
+		if n == left || (r.Until != nil && localDate(t).After(*r.Until)) {
+			break
+		}
+		fmt.Fprintf(&b, "• %s\n", t.Format("Monday 2 January 2006, 15:04"))
+		n++
+	}
+	if n < len(times) {
+		b.WriteString("…and then no more.")
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatPreviewCronSpec(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, paris) // a Friday
+	got := formatPreview(Reminder{Mode: modeCron, CronSpec: "30 9 * * 1-5", TZ: "Europe/Paris"}, now)
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if lines[0] != "Next fires of `30 9 * * 1-5`, in Europe/Paris:" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if len(lines) != 1+previewFires {
+		t.Fatalf("got %d fires, want %d:\n%s", len(lines)-1, previewFires, got)
+	}
+	if lines[1] != "• Monday 19 October 2026, 09:30" {
+		t.Errorf("first fire = %q, want the Monday after", lines[1])
+	}
+	// the clocks go back on the 25th; the fire stays at 09:30 local
+	if lines[6] != "• Monday 26 October 2026, 09:30" {
+		t.Errorf("sixth fire = %q", lines[6])
+	}
+	if strings.Contains(got, "no more") {
+		t.Error("an unbounded spec shouldn't say it ends")
+	}
+}
+
+func TestFormatPreviewStopsAtLimits(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, paris)
+	daily := Reminder{ID: 7, Mode: modeDaily, Hour: 9, TZ: "Europe/Paris"}
+
+	capped := daily
+	capped.MaxFires, capped.FireCount = 5, 3
+	got := formatPreview(capped, now)
+	if !strings.HasPrefix(got, "Next fires of reminder 7, ") {
+		t.Errorf("header of %q", got)
+	}
+	if n := strings.Count(got, "•"); n != 2 {
+		t.Errorf("fire limit 5 with 3 used listed %d fires:\n%s", n, got)
+	}
+	if !strings.HasSuffix(got, "…and then no more.") {
+		t.Errorf("capped preview should say it ends:\n%s", got)
+	}
+
+	until := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	ending := daily
+	ending.Until = &until
+	got = formatPreview(ending, now)
+	if n := strings.Count(got, "•"); n != 2 || !strings.Contains(got, "Sunday 18 October") || strings.Contains(got, "19 October") {
+		t.Errorf("until the 18th should list the 17th and 18th only:\n%s", got)
+	}
+}
+
+func TestFormatPreviewBadSpec(t *testing.T) {
+	got := formatPreview(Reminder{Mode: modeCron, CronSpec: "61 * * * *", TZ: "UTC"}, time.Now())
+	if !strings.HasPrefix(got, "That schedule doesn't work: ") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestHandlePreview(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	clk := newFakeClock(time.Date(2026, 10, 16, 10, 0, 0, 0, paris))
+
+	for _, ic := range []struct {
+		name string
+		opts []any
+	}{
+		{"neither", nil},
+		{"both", []any{"id", "42", "cron", "0 9 * * *"}},
+	} {
+		s, f := newFakeDiscord(nil)
+		handlePreview(context.Background(), nil, clk, s, slash("preview", "u1", ic.opts...))
+		if got := f.replies(t); len(got) != 1 || got[0] != "Give either a reminder id or a cron spec." {
+			t.Errorf("%s: replies = %q", ic.name, got)
+		}
+	}
+
+	// with a timezone given a spec needs no database
+	s, f := newFakeDiscord(nil)
+	handlePreview(context.Background(), nil, clk, s, slash("preview", "u1", "cron", " 30 9 * * 1-5 ", "timezone", "Europe/Paris"))
+	got := f.replies(t)
+	if len(got) != 1 || !strings.Contains(got[0], "• Monday 19 October 2026, 09:30") {
+		t.Errorf("replies = %q", got)
+	}
+}
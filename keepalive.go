@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// publicURL is where the bot's HTTP server can be reached from outside,
+// e.g. https://kermit.onrender.com. It enables the self-ping and calendar
+// feeds; "" leaves both off.
+var publicURL string
+
+// selfPingInterval is how often selfPing hits PUBLIC_URL. Render's free
+// tier sleeps after 15 minutes without traffic.
+var selfPingInterval = 10 * time.Minute
+
+// selfPing GETs url every interval so the host sees traffic and keeps the
+// instance awake. Failures are logged and otherwise ignored; the next tick
+// tries again. It returns once stop is closed; nil runs forever.
+func selfPing(url string, interval time.Duration, stop <-chan struct{}) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		resp, err := client.Get(url)
+		if err != nil {
+			log.Printf("self-ping %s: %v", url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("self-ping %s: %s", url, resp.Status)
+		}
+	}
+}
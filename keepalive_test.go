@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSelfPingTiming(t *testing.T) {
+	var mu sync.Mutex
+	var hits []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	const interval = 50 * time.Millisecond
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		selfPing(srv.URL, interval, stop)
+		close(done)
+	}()
+	time.Sleep(4*interval + interval/2)
+	close(stop)
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) < 3 || len(hits) > 4 {
+		t.Fatalf("%d pings in 4.5 intervals, want about 4", len(hits))
+	}
+	if first := hits[0].Sub(start); first < interval {
+		t.Errorf("first ping after %v, want no sooner than one interval", first)
+	}
+	for i := 1; i < len(hits); i++ {
+		if gap := hits[i].Sub(hits[i-1]); gap < interval/2 {
+			t.Errorf("pings %d and %d only %v apart", i-1, i, gap)
+		}
+	}
+
+	n := len(hits)
+	mu.Unlock()
+	time.Sleep(2 * interval)
+	mu.Lock()
+	if len(hits) != n {
+		t.Errorf("pinged %d more times after stop", len(hits)-n)
+	}
+}
+
+func TestSelfPingSurvivesFailures(t *testing.T) {
+	calls := make(chan int, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- 1
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	stop := make(chan struct{})
+	go selfPing(srv.URL, 10*time.Millisecond, stop)
+	defer close(stop)
+	for range 2 {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatal("self-ping stopped after a 503")
+		}
+	}
+}
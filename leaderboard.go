@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const boardPageSize = 10
+
+// boardQueries count per member of a guild, most first, leaving out
+// members who opted out: active reminders, or successful fires from
+// reminder_log. Each row also carries how many members there are in all.
+var boardQueries = map[string]string{
+	"active": `SELECT user_id, COUNT(*), COUNT(*) OVER ()
+		FROM reminders
+		WHERE guild_id = $1 AND active
+		  AND user_id NOT IN (SELECT user_id FROM user_prefs WHERE leaderboard_optout)
+		GROUP BY user_id
+		ORDER BY 2 DESC, user_id
+		LIMIT $2 OFFSET $3`,
+	"fired": `SELECT l.user_id, COUNT(*), COUNT(*) OVER ()
+		FROM reminder_log l
+		JOIN reminders r ON r.id = l.reminder_id
+		WHERE r.guild_id = $1 AND l.success
+		  AND l.user_id NOT IN (SELECT user_id FROM user_prefs WHERE leaderboard_optout)
+		GROUP BY l.user_id
+		ORDER BY 2 DESC, l.user_id
+		LIMIT $2 OFFSET $3`,
+}
+
+// boardEntry is one line of the leaderboard.
+type boardEntry struct {
+	UserID string
+	Count  int
+}
+
+// loadBoard reads one page of guildID's leaderboard, ranked by `by`, and
+// how many members are on it in all.
+func loadBoard(ctx context.Context, db *pgxpool.Pool, guildID, by string, page int) ([]boardEntry, int, error) {
+	rows, err := db.Query(ctx, boardQueries[by], guildID, boardPageSize, page*boardPageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []boardEntry
+	total := 0
+	for rows.Next() {
+		var e boardEntry
+		if err := rows.Scan(&e.UserID, &e.Count, &total); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// boardPage renders a page of the leaderboard with its Prev/Next buttons.
+func boardPage(entries []boardEntry, total int, by string, page int) *discordgo.InteractionResponseData {
+	if total == 0 {
+		return &discordgo.InteractionResponseData{Content: "Nobody is on the leaderboard yet."}
+	}
+	pages := (total + boardPageSize - 1) / boardPageSize
+
+	title := "Most active reminders"
+	if by == "fired" {
+		title = "Most reminded"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏆 **%s** (page %d/%d)\n", title, page+1, pages)
+	for i, e := range entries {
+		fmt.Fprintf(&b, "%d. <@%s> %d\n", page*boardPageSize+i+1, e.UserID, e.Count)
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: b.String(),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label: "◀ Prev", Style: discordgo.SecondaryButton,
+					CustomID: boardCustomID(by, page-1), Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label: "Next ▶", Style: discordgo.SecondaryButton,
+					CustomID: boardCustomID(by, page+1), Disabled: page >= pages-1,
+				},
+			}},
+		},
+	}
+}
+
+// boardCustomID encodes a page request as "board:<by>:<page>".
+func boardCustomID(by string, page int) string {
+	return "board:" + by + ":" + strconv.Itoa(page)
+}
+
+// handleLeaderboard shows the server's leaderboard. hide_me first takes
+// the caller off it, or puts them back.
+func handleLeaderboard(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	by := "active"
+	var hide *bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "by":
+			by = opt.StringValue() // "fired"
+		case "hide_me":
+			v := opt.BoolValue()
+			hide = &v
+		}
+	}
+
+	var note string
+	if hide != nil {
+		if _, err := db.Exec(ctx,
+			`INSERT INTO user_prefs (user_id, leaderboard_optout) VALUES ($1,$2)
+			 ON CONFLICT (user_id) DO UPDATE SET leaderboard_optout = EXCLUDED.leaderboard_optout`,
+			ic.Member.User.ID, *hide); err != nil {
+			respondErr(s, ic, "saving your preference", err)
+			return
+		}
+		note = "\nYou're back on the leaderboard."
+		if *hide {
+			note = "\nYou're off the leaderboard now."
+		}
+	}
+
+	entries, total, err := loadBoard(ctx, db, ic.GuildID, by, 0)
+	if err != nil {
+		respondErr(s, ic, "loading the leaderboard", err)
+		return
+	}
+	data := boardPage(entries, total, by, 0)
+	data.Content += note
+	respondWith(s, ic, data)
+}
+
+// boardButton turns the leaderboard's page.
+func boardButton(db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	by, rawPage, _ := strings.Cut(strings.TrimPrefix(ic.MessageComponentData().CustomID, "board:"), ":")
+	page, err := strconv.Atoi(rawPage)
+	if _, known := boardQueries[by]; err != nil || !known || page < 0 {
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	entries, total, err := loadBoard(ctx, db, ic.GuildID, by, page)
+	if err != nil {
+		log.Printf("leaderboard page %d of %s: %v", page, ic.GuildID, err)
+		return
+	}
+	if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: boardPage(entries, total, by, page),
+	}); err != nil {
+		log.Printf("leaderboard page %d of %s: %v", page, ic.GuildID, err)
+	}
+}
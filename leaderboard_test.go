@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBoardPage(t *testing.T) {
+	if got := boardPage(nil, 0, "active", 0); got.Content != "Nobody is on the leaderboard yet." || got.Components != nil {
+		t.Errorf("empty board = %+v", got)
+	}
+
+	entries := []boardEntry{{"11", 4}, {"12", 2}}
+	data := boardPage(entries, 12, "fired", 1)
+	if want := "🏆 **Most reminded** (page 2/2)\n11. <@11> 4\n12. <@12> 2\n"; data.Content != want {
+		t.Errorf("content = %q, want %q", data.Content, want)
+	}
+	row := data.Components[0].(discordgo.ActionsRow).Components
+	prev, next := row[0].(discordgo.Button), row[1].(discordgo.Button)
+	if prev.CustomID != "board:fired:0" || prev.Disabled {
+		t.Errorf("prev = %q disabled %t", prev.CustomID, prev.Disabled)
+	}
+	if next.CustomID != "board:fired:2" || !next.Disabled {
+		t.Errorf("next on the last page = %q disabled %t", next.CustomID, next.Disabled)
+	}
+
+	first := boardPage(entries, 12, "active", 0)
+	row = first.Components[0].(discordgo.ActionsRow).Components
+	if !row[0].(discordgo.Button).Disabled || row[1].(discordgo.Button).Disabled {
+		t.Error("the first page should only page forward")
+	}
+	if !strings.HasPrefix(first.Content, "🏆 **Most active reminders** (page 1/2)\n1. <@11> 4\n") {
+		t.Errorf("first page = %q", first.Content)
+	}
+}
+
+func TestLoadBoard(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		db.Exec(ctx, `DELETE FROM reminders WHERE user_id LIKE 'test-board-%'`)
+		db.Exec(ctx, `DELETE FROM user_prefs WHERE user_id LIKE 'test-board-%'`)
+	})
+
+	insert := func(user, guild string, active bool, n int) []int {
+		var ids []int
+		for i := range n {
+			var id int
+			if err := db.QueryRow(ctx,
+				`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+				 VALUES ($1, 'c1', $2, $3, 9, 0, 'UTC', $4) RETURNING id`,
+				user, guild, fmt.Sprintf("%s %t %d", guild, active, i), active).Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	a := insert("test-board-a", "test-board-g", true, 3)
+	insert("test-board-a", "test-board-g", false, 1) // stopped ones don't count
+	b := insert("test-board-b", "test-board-g", true, 1)
+	insert("test-board-b", "test-board-elsewhere", true, 5) // nor other servers'
+	c := insert("test-board-c", "test-board-g", true, 5)
+	if _, err := db.Exec(ctx, `INSERT INTO user_prefs (user_id, leaderboard_optout) VALUES ('test-board-c', true)`); err != nil {
+		t.Fatal(err)
+	}
+
+	fire := func(id int, user string, ok bool, n int) {
+		for range n {
+			logFire(ctx, db, Reminder{ID: id, UserID: user, ChannelID: "c1"}, "x", ok)
+		}
+	}
+	fire(a[0], "test-board-a", true, 1)
+	fire(a[0], "test-board-a", false, 4) // failed sends don't count
+	fire(b[0], "test-board-b", true, 3)
+	fire(c[0], "test-board-c", true, 9)
+
+	for _, tc := range []struct {
+		by   string
+		want []boardEntry
+	}{
+		{"active", []boardEntry{{"test-board-a", 3}, {"test-board-b", 1}}},
+		{"fired", []boardEntry{{"test-board-b", 3}, {"test-board-a", 1}}},
+	} {
+		got, total, err := loadBoard(ctx, db, "test-board-g", tc.by, 0)
+		if err != nil || total != 2 || !slices.Equal(got, tc.want) {
+			t.Errorf("by %s: %v of %d, %v; want %v of 2", tc.by, got, total, err, tc.want)
+		}
+	}
+	if got, _, err := loadBoard(ctx, db, "test-board-g", "active", 1); err != nil || len(got) != 0 {
+		t.Errorf("page past the end: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxLinkLabel is Discord's limit on a button label.
+const maxLinkLabel = 80
+
+// linkURL fills the placeholders of r's link: {user} is the owner's ID
+// and {reminder} the reminder's.
+func linkURL(r Reminder) string {
+	return strings.NewReplacer(
+		"{user}", url.PathEscape(r.UserID),
+		"{reminder}", strconv.Itoa(r.ID),
+	).Replace(r.LinkURL)
+}
+
+// linkButton is the button opening r's link, if it has one.
+func linkButton(r Reminder) (discordgo.Button, bool) {
+	if r.LinkURL == "" {
+		return discordgo.Button{}, false
+	}
+	return discordgo.Button{Label: r.LinkLabel, Style: discordgo.LinkButton, URL: linkURL(r)}, true
+}
+
+// checkLinkURL validates /link's url, placeholders filled in with sample
+// values. The error is meant for the user.
+func checkLinkURL(raw string) error {
+	u, err := url.Parse(linkURL(Reminder{ID: 1, UserID: "1", LinkURL: raw}))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("The URL must be an http:// or https:// link.")
+	}
+	return nil
+}
+
+// handleLink puts a button opening a URL on every fire of a reminder, or
+// takes it off when no URL is given.
+func handleLink(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref, label, rawURL string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "label":
+			label = strings.TrimSpace(opt.StringValue()) // "Log it"
+		case "url":
+			rawURL = strings.TrimSpace(opt.StringValue()) // "https://habits.example/log?u={user}"
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if rawURL != "" {
+		if err := checkLinkURL(rawURL); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+		if label == "" {
+			label = "Open"
+		}
+	} else {
+		label = ""
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET link_label = $2, link_url = $3, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, label, rawURL).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the link", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if rawURL == "" {
+		respond(s, ic, fmt.Sprintf("Reminder %d won't have a link button any more.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("🔗 Each fire of reminder %d gets a **%s** button opening <%s>.", id, label, linkURL(r)))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLinkURL(t *testing.T) {
+	r := Reminder{ID: 42, UserID: "1234", LinkURL: "https://habits.example/log?u={user}&r={reminder}"}
+	if got, want := linkURL(r), "https://habits.example/log?u=1234&r=42"; got != want {
+		t.Errorf("linkURL = %q, want %q", got, want)
+	}
+	r.LinkURL = "https://habits.example/{user}/{user}"
+	r.UserID = "a b/c"
+	if got, want := linkURL(r), "https://habits.example/a%20b%2Fc/a%20b%2Fc"; got != want {
+		t.Errorf("linkURL = %q, want %q with every {user} escaped", got, want)
+	}
+}
+
+func TestLinkButton(t *testing.T) {
+	if _, ok := linkButton(Reminder{ID: 1}); ok {
+		t.Error("a reminder without a link got a button")
+	}
+
+	b, ok := linkButton(Reminder{ID: 7, UserID: "99", LinkLabel: "Log it", LinkURL: "https://habits.example/{reminder}"})
+	if !ok {
+		t.Fatal("no button for a reminder with a link")
+	}
+	if b.Style != discordgo.LinkButton || b.Label != "Log it" || b.URL != "https://habits.example/7" {
+		t.Errorf("button = %+v", b)
+	}
+	if b.CustomID != "" {
+		t.Error("a link button can't have a custom ID")
+	}
+}
+
+func TestFiredButtonsCarryLink(t *testing.T) {
+	r := Reminder{ID: 7, UserID: "99", LinkLabel: "Log it", LinkURL: "https://habits.example/{user}"}
+	row := firedButtons(r)[0].(discordgo.ActionsRow).Components
+	if len(row) != 3 {
+		t.Fatalf("%d buttons, want snooze, dismiss and the link", len(row))
+	}
+	if b := row[2].(discordgo.Button); b.URL != "https://habits.example/99" {
+		t.Errorf("last button = %+v, want the link", b)
+	}
+
+	r.LinkURL = ""
+	if row := firedButtons(r)[0].(discordgo.ActionsRow).Components; len(row) != 2 {
+		t.Errorf("%d buttons without a link, want 2", len(row))
+	}
+}
+
+func TestCheckLinkURL(t *testing.T) {
+	for _, raw := range []string{
+		"https://habits.example/log?u={user}",
+		"http://habits.example/{reminder}",
+	} {
+		if err := checkLinkURL(raw); err != nil {
+			t.Errorf("checkLinkURL(%q) = %v", raw, err)
+		}
+	}
+	for _, raw := range []string{
+		"habits.example/log",
+		"ftp://habits.example/log",
+		"javascript:alert(1)",
+		"https://",
+		"https://habits example/%zz",
+	} {
+		if err := checkLinkURL(raw); err == nil {
+			t.Errorf("checkLinkURL(%q) accepted it", raw)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// descriptionLocales holds translated command descriptions, keyed by
+// "command" for a command and "command.option" for one of its options.
+// Anything missing shows in English.
+var descriptionLocales = map[discordgo.Locale]map[string]string{
+	discordgo.French: {
+		"remind":              "Créer un rappel quotidien",
+		"remind.time":         "HH:MM",
+		"remind.timezone":     "Nom du fuseau horaire",
+		"remind.message":      "Texte",
+		"remind.users":        "Autres personnes à mentionner, p. ex. @a @b",
+		"remind.max_fires":    "Arrêter après ce nombre de rappels",
+		"remind.until":        "Dernier jour du rappel, AAAA-MM-JJ",
+		"remind.channel":      "Publier là-bas plutôt qu'ici",
+		"remind.silent":       "Mentionner sans notification push",
+		"remind.priority":     "Haute se remarque, basse ne notifie pas",
+		"remind.monthday":     "Jour du mois (1-31) ou « last », au lieu de tous les jours",
+		"remind.name":         "Nom court à utiliser à la place de l'ID",
+		"remind.mirrors":      "Publier aussi dans ces salons, p. ex. #a #b",
+		"remind.raw":          "Garder les titres, blocs de code et mentions tels quels",
+		"remind.delete_after": "Supprimer chaque message après ce nombre de minutes",
+		"remind.rrule":        "Règle iCalendar comme FREQ=WEEKLY;INTERVAL=2;BYDAY=TU, au lieu de tous les jours",
+
+		"remindme":          "Rappel quotidien ici, dans ton fuseau par défaut",
+		"remindme.time":     "HH:MM",
+		"remindme.message":  "Texte",
+		"remindme.timezone": "Nom du fuseau horaire (par défaut celui de /settz)",
+
+		"remindat":         "Rappeler une seule fois à une heure précise",
+		"remindat.when":    "ISO 8601, p. ex. 2025-06-01T18:30:00-04:00",
+		"remindat.message": "Texte",
+
+		"gift":          "Proposer un rappel quotidien à quelqu'un d'autre",
+		"gift.user":     "Pour qui",
+		"gift.time":     "HH:MM",
+		"gift.message":  "Texte",
+		"gift.timezone": "Nom du fuseau horaire (par défaut le sien)",
+
+		"remindform": "Créer un rappel quotidien dans une fenêtre",
+
+		"settz":          "Choisir ton fuseau horaire par défaut",
+		"settz.timezone": "Nom du fuseau horaire",
+		"retz":           "Déplacer tous tes rappels vers un nouveau fuseau horaire",
+		"retz.timezone":  "Nom du fuseau horaire",
+
+		"timeformat":        "Afficher l'heure sur 24 heures ou sur 12 heures",
+		"timeformat.format": "Format d'heure à utiliser",
+
+		"prefs":      "Afficher tes préférences enregistrées",
+		"clearprefs": "Réinitialiser tes préférences aux valeurs du serveur",
+
+		"setguildtz":          "Choisir le fuseau horaire par défaut du serveur (admin)",
+		"setguildtz.timezone": "Nom du fuseau horaire",
+
+		"upcoming":         "Rappels prévus bientôt dans tout le serveur (admin)",
+		"upcoming.minutes": "Jusqu'où regarder, en minutes (60 par défaut)",
+
+		"setreminderchannels":          "Limiter les salons où les rappels peuvent être publiés (admin)",
+		"setreminderchannels.channels": "p. ex. #général #todo ; laisser vide pour tout autoriser",
+
+		"setfallback":         "Où vont les rappels si je ne peux pas publier dans leur salon (admin)",
+		"setfallback.channel": "Publier ici à la place",
+		"setfallback.dm":      "Envoyer en MP au propriétaire à la place",
+
+		"greeting":          "Saluer les membres par leur pseudo dans les rappels (admin)",
+		"greeting.nickname": "Commencer les rappels par « Salut <pseudo>, »",
+
+		"webhook":        "Publier un rappel sous un nom et un avatar personnalisés",
+		"webhook.id":     "ID ou nom du rappel",
+		"webhook.name":   "Nom à afficher ; laisser vide pour publier en tant que bot",
+		"webhook.avatar": "URL de l'image d'avatar",
+
+		"link":       "Ajouter un bouton ouvrant un lien à chaque envoi d'un rappel",
+		"link.id":    "ID ou nom du rappel",
+		"link.url":   "Lien, {user} et {reminder} sont remplacés ; laisser vide pour retirer",
+		"link.label": "Texte du bouton (par défaut : Open)",
+
+		"fetch":       "Remplir le message d'un rappel avec le texte d'une URL à chaque envoi",
+		"fetch.id":    "ID ou nom du rappel",
+		"fetch.url":   "Lien https://, placé à {fetched} dans le message ; laisser vide pour arrêter",
+		"fetch.field": "Chemin du texte dans une réponse JSON, p. ex. data.quote ou 0.q",
+
+		"escalate":         "Mentionner à nouveau si personne ne réagit ✅ à temps",
+		"escalate.id":      "ID ou nom du rappel",
+		"escalate.minutes": "Délai avant de mentionner à nouveau, 0 = désactivé",
+
+		"headsup":         "Être prévenu quelques minutes avant un rappel",
+		"headsup.id":      "ID ou nom du rappel",
+		"headsup.minutes": "Combien de temps avant, 0 = désactivé",
+
+		"remindpoll":             "Publier un sondage quotidien",
+		"remindpoll.time":        "HH:MM",
+		"remindpoll.timezone":    "Nom du fuseau horaire",
+		"remindpoll.question":    "Question du sondage",
+		"remindpoll.answers":     "Réponses séparées par |, p. ex. Oui | Non",
+		"remindpoll.multiselect": "Autoriser plusieurs réponses",
+		"remindpoll.hours":       "Durée d'ouverture du sondage (24 par défaut)",
+
+		"stop":    "Annuler un rappel",
+		"stop.id": "ID ou nom du rappel",
+
+		"list":      "Afficher tes rappels actifs",
+		"list.sort": "Ordre d'affichage (par défaut : prochain envoi)",
+
+		"between":          "Lister tes rappels envoyés entre deux heures",
+		"between.from":     "HH:MM",
+		"between.to":       "HH:MM, avant from pour passer minuit",
+		"between.timezone": "Fuseau des heures (par défaut : le tien)",
+
+		"last":    "Réafficher le dernier rappel que je t'ai envoyé",
+		"last.dm": "L'envoyer en message privé",
+
+		"leaderboard":         "Qui a le plus de rappels sur ce serveur",
+		"leaderboard.by":      "Quoi compter (par défaut : rappels actifs)",
+		"leaderboard.hide_me": "Ne pas apparaître au classement, ou false pour revenir",
+
+		"streak": "Voir combien de jours d'affilée tu as confirmé tes rappels",
+
+		"snooze":       "Renvoyer un rappel une fois, plus tard",
+		"snooze.id":    "ID ou nom du rappel",
+		"snooze.for":   "Dans combien de temps, p. ex. 30m",
+		"snooze.until": "Heure de la journée, HH:MM",
+
+		"shift":         "Avancer ou retarder un rappel définitivement",
+		"shift.id":      "ID ou nom du rappel",
+		"shift.minutes": "Minutes de décalage, négatif pour plus tôt",
+
+		"convert":             "Passer un rappel en quotidien, hebdomadaire ou mensuel",
+		"convert.id":          "ID ou nom du rappel",
+		"convert.mode":        "Nouvelle fréquence",
+		"convert.days":        "Pour hebdomadaire : jours comme mon, wed, fri",
+		"convert.every_weeks": "Pour hebdomadaire : seulement toutes les N semaines, à partir de celle-ci",
+		"convert.monthday":    "Pour mensuel : jour du mois (1-31) ou « last »",
+
+		"boost":       "Faire sonner un rappel plus souvent pendant un moment",
+		"boost.id":    "ID ou nom du rappel",
+		"boost.every": "Minutes entre deux envois pendant le boost, vide pour l'arrêter",
+		"boost.hours": "Durée du boost en heures",
+
+		"cooldown":         "Ne jamais envoyer un rappel deux fois en moins de ce nombre de minutes",
+		"cooldown.id":      "ID ou nom du rappel",
+		"cooldown.minutes": "Écart minimal entre deux envois, 0 = désactivé",
+
+		"replychain":         "Faire répondre chaque envoi d'un rappel au précédent",
+		"replychain.id":      "ID ou nom du rappel",
+		"replychain.enabled": "Répondre à l'envoi précédent",
+
+		"snoozeall": "Mettre en attente jusqu'à demain tous tes rappels restants du jour",
+
+		"voiceonly":          "N'envoyer un rappel que si tu es dans un salon vocal",
+		"voiceonly.id":       "ID ou nom du rappel",
+		"voiceonly.enabled":  "Sauter les envois quand tu n'es pas en vocal",
+		"language":           "Langue de {greeting} dans tes rappels",
+		"language.language":  "Langue à utiliser",
+		"dmize":              "Envoyer un de tes rappels en MP au lieu de son salon",
+		"dmize.id":           "ID ou nom du rappel",
+		"undmize":            "Renvoyer un rappel dans son salon",
+		"undmize.id":         "ID ou nom du rappel",
+		"window":             "Envoyer un rappel quotidien à une heure aléatoire entre deux heures",
+		"window.id":          "ID ou nom du rappel",
+		"window.from":        "Heure au plus tôt, HH:MM (24 h)",
+		"window.to":          "Heure au plus tard, HH:MM (24 h)",
+		"templates":          "Lister les rappels partagés par ce serveur",
+		"subscribe":          "Obtenir ta propre copie d'un rappel partagé, envoyée ici",
+		"subscribe.name":     "Nom du modèle, voir /templates",
+		"subscribe.timezone": "Fuseau horaire où garder son heure (par défaut celui du modèle)",
+		"publish":            "Partager un de tes rappels comme modèle (admin)",
+		"publish.id":         "ID ou nom du rappel",
+		"publish.name":       "Nom du modèle pour /subscribe",
+		"unpublish":          "Arrêter de partager un modèle (admin)",
+		"unpublish.name":     "Nom du modèle",
+
+		"daycount":         "Terminer chaque envoi d'un rappel par son numéro, p. ex. (day 42)",
+		"daycount.id":      "ID ou nom du rappel",
+		"daycount.enabled": "Afficher le numéro",
+
+		"testfire":    "Envoyer un rappel une fois dans une minute, pour vérifier qu'il fonctionne",
+		"testfire.id": "ID ou nom du rappel",
+
+		"inspect":    "Afficher les détails enregistrés d'un rappel et ses prochains envois",
+		"inspect.id": "ID ou nom du rappel",
+
+		"preview":          "Lister les prochains envois d'un rappel ou d'une expression cron",
+		"preview.id":       "ID ou nom du rappel",
+		"preview.cron":     "Expression cron à essayer, p. ex. 30 9 * * 1-5",
+		"preview.timezone": "Fuseau horaire de l'expression cron (par défaut celui de /settz)",
+
+		"testdm": "Vérifier que je peux t'envoyer des messages privés",
+
+		"calendar":       "Obtenir un flux agenda de tes rappels",
+		"calendar.reset": "Créer un nouveau lien et désactiver l'ancien",
+
+		"transfer":      "Donner un rappel à quelqu'un d'autre (admin)",
+		"transfer.id":   "ID ou nom du rappel",
+		"transfer.user": "Nouveau propriétaire",
+
+		"digest":          "Résumé hebdomadaire de tes rappels en MP",
+		"digest.enabled":  "Envoyer le résumé",
+		"digest.timezone": "Fuseau horaire pour le dimanche soir",
+
+		"globalpause":  "Mettre en sourdine tous les rappels du serveur (admin)",
+		"capacity":     "La charge de cette instance du bot (admin)",
+		"reload":       "Replanifier tous les rappels depuis la base de données (admin)",
+		"globalresume": "Annuler /globalpause (admin)",
+
+		"timezones":        "Lister les noms de fuseaux horaires valides",
+		"checktz":          "Vérifier un nom de fuseau horaire et voir l'heure qu'il y est",
+		"checktz.timezone": "Nom du fuseau, p. ex. America/Toronto",
+		"timezones.region": "Préfixe, p. ex. America",
+	},
+}
+
+// localizeCommands fills in DescriptionLocalizations on cmds and their
+// options from descriptionLocales.
+func localizeCommands(cmds []*discordgo.ApplicationCommand) {
+	for _, cmd := range cmds {
+		if l := localized(cmd.Name); l != nil {
+			cmd.DescriptionLocalizations = &l
+		}
+		for _, opt := range cmd.Options {
+			opt.DescriptionLocalizations = localized(cmd.Name + "." + opt.Name)
+		}
+	}
+}
+
+// localized collects the translations of one description key, or nil if
+// there are none.
+func localized(key string) map[discordgo.Locale]string {
+	var out map[discordgo.Locale]string
+	for loc, strs := range descriptionLocales {
+		if d, ok := strs[key]; ok {
+			if out == nil {
+				out = map[discordgo.Locale]string{}
+			}
+			out[loc] = d
+		}
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// repeatLogWindow is how long an identical log line for a reminder is
+// held back after it's been written once.
+const repeatLogWindow = 15 * time.Minute
+
+var repeatLogs = newThrottle(realClock{}, repeatLogWindow)
+
+// logRepeated is log.Printf for errors that can recur on every fire of a
+// reminder whose channel keeps failing. Each distinct line is written
+// once per repeatLogWindow; the next one that gets through says how many
+// were suppressed meanwhile.
+func logRepeated(format string, args ...any) {
+	line := fmt.Sprintf(format, args...)
+	ok, held := repeatLogs.allowNow(line)
+	if !ok {
+		return
+	}
+	if held > 0 {
+		line += fmt.Sprintf(" (suppressed %d identical errors)", held)
+	}
+	log.Print(line)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRepeatedWindow(t *testing.T) {
+	old := repeatLogs
+	clk := newFakeClock(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	repeatLogs = newThrottle(clk, repeatLogWindow)
+	t.Cleanup(func() { repeatLogs = old })
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+
+	lines := func() []string {
+		out := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		logs.Reset()
+		if len(out) == 1 && out[0] == "" {
+			return nil
+		}
+		return out
+	}
+
+	for range 3 {
+		logRepeated("send reminder %d: %v", 7, "403 Forbidden")
+	}
+	logRepeated("send reminder %d: %v", 8, "403 Forbidden") // another reminder isn't held back
+	got := lines()
+	if len(got) != 2 || got[0] != "send reminder 7: 403 Forbidden" || got[1] != "send reminder 8: 403 Forbidden" {
+		t.Fatalf("first burst logged %q", got)
+	}
+
+	clk.Advance(repeatLogWindow - time.Second)
+	logRepeated("send reminder %d: %v", 7, "403 Forbidden")
+	logRepeated("send reminder %d: %v", 7, "404 Not Found") // a different error is its own line
+	if got := lines(); len(got) != 1 || got[0] != "send reminder 7: 404 Not Found" {
+		t.Errorf("inside the window logged %q", got)
+	}
+
+	clk.Advance(time.Second)
+	logRepeated("send reminder %d: %v", 7, "403 Forbidden")
+	if got := lines(); len(got) != 1 || got[0] != "send reminder 7: 403 Forbidden (suppressed 3 identical errors)" {
+		t.Errorf("after the window logged %q", got)
+	}
+
+	clk.Advance(repeatLogWindow)
+	logRepeated("send reminder %d: %v", 7, "403 Forbidden")
+	if got := lines(); len(got) != 1 || got[0] != "send reminder 7: 403 Forbidden" {
+		t.Errorf("with nothing suppressed logged %q", got)
+	}
+}
@@ -2,32 +2,94 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/robfig/cron/v3"
 )
 
-var crons = make(map[int]*cron.Cron)
+// crons holds one runner per reminder ID. Handlers, cron callbacks and
+// restoreJobs all touch it, so every access goes through cronsMu.
+// cronVersions has the updated_at of the row each runner was built from,
+// for reconcile, and cronEntries the ID of the fire's entry in it.
+var (
+	crons        = make(map[int]*cron.Cron)
+	cronVersions = make(map[int]time.Time)
+	cronEntries  = make(map[int]cron.EntryID)
+	cronsMu      sync.Mutex
+)
 
 type Reminder struct {
 	ID        int
 	UserID    string
 	ChannelID string
+	GuildID   string
 	Message   string
 	Hour      int
 	Min       int
 	TZ        string
 	Active    bool
-	CronID    cron.EntryID
+	Extra     []string   // additional user IDs pinged alongside UserID
+	MaxFires  int        // stop after this many fires, 0 = unlimited
+	FireCount int        // fires so far
+	Until     *time.Time // last day (inclusive) it may fire, nil = forever
+	LastFired *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Poll      *pollDef     // posted as a poll instead of plain text when set
+	Failures  int          // consecutive failed sends
+	CronID    cron.EntryID // the fire's entry in its runner, once scheduled
+
+	// scheduling mode and its parameters; see buildSpec
+	Mode        string
+	Days        string // cron day-of-week list for weekly, e.g. "1,3,5"
+	EveryWeeks  int    // weekly only fires every this many weeks, counted from Anchor
+	Anchor      *time.Time
+	MonthDay    int    // day of month for monthly
+	IntervalMin int    // minutes between fires for interval
+	CronSpec    string // raw 5-field spec for cron
+	WindowStart int    // window fires between these minutes of the day
+	WindowEnd   int
+
+	WebhookName   string     // posted through a webhook under this name when set
+	WebhookAvatar string     // avatar URL for the webhook post
+	EscalateMin   int        // re-ping once if not acked within this many minutes, 0 = never
+	ReplyChain    bool       // each fire replies to the previous one
+	LastMessageID string     // first message of the latest fire
+	MinGapMin     int        // skip fires closer than this many minutes to the last one
+	Silent        bool       // post without push notifications
+	Priority      string     // priorityLow, priorityNormal or priorityHigh
+	Name          string     // optional, unique among the owner's active reminders
+	RRule         string     // iCalendar recurrence rule for rrule mode
+	BoostMin      int        // while boosted, fire every this many minutes instead
+	BoostUntil    *time.Time // when the boost ends, nil = not boosted
+	Mirrors       []string   // other channels each fire is also posted in
+	RawMarkdown   bool       // post Message as written, see escapeMessage
+	LeadMin       int        // post a heads-up this many minutes before each fire, 0 = none
+	DeleteAfter   int        // seconds until each fire's post is deleted, 0 = kept
+	FetchURL      string     // text fetched from here at fire time goes into Message
+	FetchField    string     // dotted path to the text in FetchURL's JSON, "" = whole body
+	ShowCount     bool       // end each post with fireCounter
+	LinkLabel     string     // label of the button opening LinkURL
+	LinkURL       string     // put on each fire as a button, see linkURL
+	VoiceOnly     bool       // only fire while the owner is in voice, see voiceGated
+	Delivery      string     // deliverChannel or deliverDM, see postChannel
 }
 
 func main() {
@@ -38,43 +100,108 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
+	keepAlive := os.Getenv("KEEP_ALIVE") != "off" // always-on hosts don't need it
+	publicURL = os.Getenv("PUBLIC_URL")           // optional, enables the self-ping and calendar feeds
+	selfPingInterval = envDuration("SELF_PING_INTERVAL", selfPingInterval)
+	statusChannel := os.Getenv("STATUS_CHANNEL_ID") // optional
+	catchupWindow = envDuration("CATCHUP_WINDOW", catchupWindow)
+	maxPerChannel = envInt("MAX_REMINDERS_PER_CHANNEL", maxPerChannel)
+	maxSendFailures = envInt("MAX_SEND_FAILURES", maxSendFailures)
+	failureGrace = envDuration("FAILURE_GRACE", failureGrace)
+	dbTimeout = envDuration("DB_TIMEOUT", dbTimeout)
+	minFireGap = envDuration("MIN_FIRE_GAP", minFireGap)
+	minLead = envDuration("MIN_LEAD_TIME", minLead)
+	reconcileInterval = envDuration("RECONCILE_INTERVAL", reconcileInterval)
+	sendLimit = newTokenBucket(envInt("MAX_SENDS_PER_SECOND", 40)) // Discord allows 50 globally
+	if dryRun = os.Getenv("DRY_RUN") != ""; dryRun {
+		log.Print("DRY RUN: reminders will be logged, not sent")
+	}
+	discordTimeout := envDuration("DISCORD_TIMEOUT", 20*time.Second)
+	shardCount := max(envInt("SHARD_COUNT", 1), 1)
+	presence := presenceConfigFromEnv()
+	setWebhookKey(os.Getenv("WEBHOOK_KEY"))         // optional, enables /webhook
+	analyticsURL = os.Getenv("ANALYTICS_WEBHOOK")   // optional
+	errorChannel = os.Getenv("ERROR_CHANNEL_ID")    // optional
+	memberEvents = os.Getenv("MEMBER_EVENTS") != "" // optional, needs the Server Members intent
+	operators = envSet("OPERATOR_IDS")              // optional, enables /reload and /capacity
+
+	// everything that asks what time it is gets this; tests pass a fake
+	var clk Clock = realClock{}
 
 	// =========== PostGres ===============
-	db, err := pgx.Connect(context.Background(), dsn)
+	// a pool rather than a single conn: handlers and cron callbacks query
+	// concurrently, and a query that hits its deadline closes its conn
+	db, err := pgxpool.New(context.Background(), dsn)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close(context.Background())
+	defer db.Close()
 
 	if _, err := db.Exec(context.Background(), schema); err != nil {
 		log.Fatal(err)
 	}
 
 	// =========== Discord ===============
-	dg, err := discordgo.New("Bot " + token)
+	// every shard gets the same handlers; each only sees its own guilds
+	shards, err = openShards(token, shardCount, discordTimeout, func(s *discordgo.Session) {
+		s.AddHandler(onSlash(db, clk))
+		s.AddHandler(onComponent(db, clk))
+		s.AddHandler(onModalSubmit(db, clk))
+		s.AddHandler(onReactionAdd(db, clk))
+		if memberEvents {
+			s.Identify.Intents |= discordgo.IntentsGuildMembers
+			s.AddHandler(onMemberRemove(db))
+		}
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer closeShards(shards)
+	dg := shards[0]
 
-	dg.AddHandler(onSlash(db))
-	if err := dg.Open(); err != nil {
+	// register the slash commands; without them the bot is useless.
+	// commands are global, so once is enough however many shards there are
+	if err := ensureCommands(dg); err != nil {
 		log.Fatal(err)
 	}
-	defer dg.Close()
-
-	ensureCommands(dg) // register /remind and /stop (once)
 
 	// job restore
 
-	restoreJobs(db, dg) // rebuild jobs in memory using live session
+	ctx := context.Background()
+	restored := restoreJobs(ctx, db, clk, dg) // rebuild jobs in memory using live session
+	restoreSnoozes(ctx, db, clk, dg)
+	restoreAcks(ctx, db, clk, dg)
+	startDigest(db, clk, dg)
+	startReconcile(db, clk, dg)
+
+	if statusChannel != "" {
+		msg := fmt.Sprintf("KermitTheBot is online, %d reminders restored", restored)
+		if _, err := dg.ChannelMessageSend(statusChannel, msg); err != nil {
+			log.Printf("status announcement to %s: %v", statusChannel, err)
+		}
+	}
+
+	for _, s := range shards {
+		go rotatePresence(db, s, presence) // presence is per gateway connection
+	}
 
 	// keeps render awake
-	go func() {
+	if keepAlive {
 		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte("ok"))
 		})
-		log.Fatal(http.ListenAndServe(":"+port, nil))
-	}()
+		if publicURL != "" {
+			go selfPing(publicURL, selfPingInterval, nil)
+		}
+	}
+	if publicURL != "" {
+		http.HandleFunc("/calendar/", calendarFeed(db, clk))
+	}
+	if keepAlive || publicURL != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(":"+port, nil))
+		}()
+	}
 
 	// shutdown
 	stop := make(chan os.Signal, 1)
@@ -84,6 +211,15 @@ func main() {
 
 // ======= Helpers ========
 
+// dbTimeout bounds the DB work done for one interaction, one fire or one
+// restore step, so a hung database can't pile up goroutines forever.
+var dbTimeout = 10 * time.Second
+
+// dbCtx is a fresh context bounded by dbTimeout.
+func dbCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), dbTimeout)
+}
+
 func mustEnv(k string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -92,197 +228,1956 @@ func mustEnv(k string) string {
 	return v
 }
 
-func onSlash(db *pgx.Conn) func(*discordgo.Session, *discordgo.InteractionCreate) {
+// envSet reads a comma-separated list from k, e.g. "123,456", as a set.
+func envSet(k string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range strings.Split(os.Getenv(k), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+// envInt reads a non-negative integer from k, falling back to def when
+// unset or malformed.
+func envInt(k string, def int) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("%s=%q ignored, want a non-negative integer", k, v)
+		return def
+	}
+	return n
+}
+
+// envDuration reads a duration like "90m" from k, falling back to def when
+// unset or malformed.
+func envDuration(k string, def time.Duration) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("%s=%q ignored, want a duration like 2h", k, v)
+		return def
+	}
+	return d
+}
+
+// ephemeralCommands reply only to the user who ran them.
+var ephemeralCommands = map[string]bool{
+	"inspect":    true,
+	"remindmsg":  true, // the "Remind me about this" modal
+	"prefs":      true,
+	"clearprefs": true,
+	"upcoming":   true,
+	"preview":    true,
+	"calendar":   true, // the link is a secret
+	"testdm":     true,
+	"last":       true,
+	"snoozeall":  true,
+	"capacity":   true,
+}
+
+func onSlash(db *pgxpool.Pool, clk Clock) func(*discordgo.Session, *discordgo.InteractionCreate) {
 	return func(s *discordgo.Session, ic *discordgo.InteractionCreate) {
 		// we only want slash commands
 		if ic.Type != discordgo.InteractionApplicationCommand {
 			return
 		}
+		// handlers read ic.Member; commands registered by older builds can
+		// still be run in DMs, where it's nil
+		if ic.Member == nil {
+			s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: "My commands only work in a server."},
+			})
+			return
+		}
 
-		switch ic.ApplicationCommandData().Name {
+		if modal := modalCommands[ic.ApplicationCommandData().Name]; modal != nil {
+			if err := s.InteractionRespond(ic.Interaction, modal(ic)); err != nil {
+				log.Printf("modal for /%s: %v", ic.ApplicationCommandData().Name, err)
+			}
+			return
+		}
+
+		// acknowledge straight away so slow DB work can't overrun Discord's
+		// 3-second window; respond then edits this deferred reply
+		var flags discordgo.MessageFlags
+		if ephemeralCommands[ic.ApplicationCommandData().Name] {
+			flags = discordgo.MessageFlagsEphemeral
+		}
+		if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: flags},
+		}); err != nil {
+			log.Printf("defer /%s: %v", ic.ApplicationCommandData().Name, err)
+			return
+		}
 
-		// =========== Remind ===============
+		ctx, cancel := dbCtx()
+		defer cancel()
+
+		logUnknownOptions(ic.ApplicationCommandData())
+
+		// handlers read required options without checking, often as
+		// Options[0], so a payload missing one stops here instead
+		if name := missingOption(ic.ApplicationCommandData()); name != "" {
+			log.Printf("/%s: required option %q missing", ic.ApplicationCommandData().Name, name)
+			respond(s, ic, fmt.Sprintf("The %s option is missing. Please run the command again.", name))
+			return
+		}
+
+		switch ic.ApplicationCommandData().Name {
 		case "remind":
+			handleRemind(ctx, db, clk, s, ic)
+		case "remindme":
+			handleRemindMe(ctx, db, clk, s, ic)
+		case "remindat":
+			handleRemindAt(ctx, db, clk, s, ic)
+		case "gift":
+			handleGift(ctx, db, s, ic)
+		case "stop":
+			handleStop(ctx, db, s, ic)
+		case "timezones":
+			handleTimezones(s, ic)
+		case "checktz":
+			handleCheckTZ(ctx, db, clk, s, ic)
+		case "transfer":
+			handleTransfer(ctx, db, clk, s, ic)
+		case "digest":
+			handleDigest(ctx, db, s, ic)
+		case "list":
+			handleList(ctx, db, clk, s, ic)
+		case "globalpause":
+			handleGlobalPause(ctx, db, s, ic, true)
+		case "globalresume":
+			handleGlobalPause(ctx, db, s, ic, false)
+		case "remindpoll":
+			handleRemindPoll(ctx, db, clk, s, ic)
+		case "snooze":
+			handleSnooze(ctx, db, clk, s, ic)
+		case "testfire":
+			handleTestFire(ctx, db, clk, s, ic)
+		case "replychain":
+			handleReplyChain(ctx, db, clk, s, ic)
+		case "cooldown":
+			handleCooldown(ctx, db, clk, s, ic)
+		case "shift":
+			handleShift(ctx, db, clk, s, ic)
+		case "inspect":
+			handleInspect(ctx, db, clk, s, ic)
+		case "preview":
+			handlePreview(ctx, db, clk, s, ic)
+		case "calendar":
+			handleCalendar(ctx, db, s, ic)
+		case "testdm":
+			handleTestDM(s, ic)
+		case "timeformat":
+			handleTimeFormat(ctx, db, s, ic)
+		case "language":
+			handleLanguage(ctx, db, s, ic)
+		case "convert":
+			handleConvert(ctx, db, clk, s, ic)
+		case "streak":
+			handleStreak(ctx, db, clk, s, ic)
+		case "boost":
+			handleBoost(ctx, db, clk, s, ic)
+		case "last":
+			handleLast(ctx, db, s, ic)
+		case "headsup":
+			handleHeadsUp(ctx, db, clk, s, ic)
+		case "between":
+			handleBetween(ctx, db, clk, s, ic)
+		case "fetch":
+			handleFetch(ctx, db, clk, s, ic)
+		case "reload":
+			handleReload(ctx, db, clk, s, ic)
+		case "capacity":
+			handleCapacity(ctx, db, s, ic)
+		case "leaderboard":
+			handleLeaderboard(ctx, db, s, ic)
+		case "daycount":
+			handleDayCount(ctx, db, clk, s, ic)
+		case "snoozeall":
+			handleSnoozeAll(ctx, db, clk, s, ic)
+		case "link":
+			handleLink(ctx, db, clk, s, ic)
+		case "voiceonly":
+			handleVoiceOnly(ctx, db, clk, s, ic)
+		case "window":
+			handleWindow(ctx, db, clk, s, ic)
+		case "dmize":
+			handleDMize(ctx, db, clk, s, ic, true)
+		case "undmize":
+			handleDMize(ctx, db, clk, s, ic, false)
+		case "publish":
+			handlePublish(ctx, db, s, ic)
+		case "unpublish":
+			handleUnpublish(ctx, db, s, ic)
+		case "templates":
+			handleTemplates(ctx, db, s, ic)
+		case "subscribe":
+			handleSubscribe(ctx, db, clk, s, ic)
+		case "settz":
+			handleSetTZ(ctx, db, s, ic)
+		case "retz":
+			handleRetz(ctx, db, clk, s, ic)
+		case "prefs":
+			handlePrefs(ctx, db, s, ic)
+		case "clearprefs":
+			handleClearPrefs(ctx, db, s, ic)
+		case "setguildtz":
+			handleSetGuildTZ(ctx, db, s, ic)
+		case "setreminderchannels":
+			handleSetReminderChannels(ctx, db, s, ic)
+		case "upcoming":
+			handleUpcoming(ctx, db, clk, s, ic)
+		case "setfallback":
+			handleSetFallback(ctx, db, s, ic)
+		case "greeting":
+			handleGreeting(ctx, db, s, ic)
+		case "webhook":
+			handleWebhook(ctx, db, clk, s, ic)
+		case "escalate":
+			handleEscalate(ctx, db, clk, s, ic)
+		default:
+			// registered on Discord's side but not handled here, e.g. a
+			// command left over from a newer or older build
+			log.Printf("unhandled command /%s", ic.ApplicationCommandData().Name)
+			respond(s, ic, "Unknown command. It may have been removed; try again in a little while.")
+		}
+	}
+}
 
-			var timeStr, tzStr, msgStr string
-			for _, opt := range ic.ApplicationCommandData().Options {
-				switch opt.Name {
-				case "time":
-					timeStr = opt.StringValue() // "06:35"
-				case "timezone":
-					tzStr = opt.StringValue() // "America/Toronto"
-				case "message":
-					msgStr = opt.StringValue() // "uwu"
-				}
+// logUnknownOptions logs any option on data that its command definition
+// doesn't declare, which means the registered commands and the handlers
+// have drifted apart.
+func logUnknownOptions(data discordgo.ApplicationCommandInteractionData) {
+	for _, cmd := range commands {
+		if cmd.Name != data.Name {
+			continue
+		}
+		for _, opt := range data.Options {
+			known := false
+			for _, def := range cmd.Options {
+				known = known || def.Name == opt.Name
 			}
-			if timeStr == "" || tzStr == "" || msgStr == "" {
-				respond(s, ic, "All three options (time, timezone, message) are required.")
-				return
+			if !known {
+				log.Printf("/%s: unexpected option %q", data.Name, opt.Name)
 			}
+		}
+		return
+	}
+}
 
-			// HH:MM validation
-			parts := strings.Split(timeStr, ":")
-			if len(parts) != 2 {
-				respond(s, ic, "Time must be HH:MM (24‑hour).")
-				return
+// missingOption returns the first option data's command definition
+// requires that data doesn't carry, or "" if they're all there.
+func missingOption(data discordgo.ApplicationCommandInteractionData) string {
+	for _, cmd := range commands {
+		if cmd.Name != data.Name {
+			continue
+		}
+		for _, def := range cmd.Options {
+			if !def.Required {
+				continue
 			}
-			hour, min := atoi(parts[0]), atoi(parts[1])
-			if hour < 0 || hour > 23 || min < 0 || min > 59 {
-				respond(s, ic, "Time must be a valid 24‑hour clock value.")
-				return
+			found := false
+			for _, opt := range data.Options {
+				found = found || (opt.Name == def.Name && opt.Value != nil)
 			}
-
-			// timezone validation
-			loc, err := time.LoadLocation(tzStr)
-			if err != nil {
-				respond(s, ic, "Invalid timezone name.")
-				return
+			if !found {
+				return def.Name
 			}
+		}
+		return ""
+	}
+	return ""
+}
 
-			// save to Database
-			row := Reminder{
-				UserID:    ic.Member.User.ID,
-				ChannelID: ic.ChannelID,
-				Message:   msgStr,
-				Hour:      hour,
-				Min:       min,
-				TZ:        tzStr,
-				Active:    true,
-			}
+// =========== Remind ===============
 
-			err = db.QueryRow(context.Background(),
-				`INSERT INTO reminders
-			(user_id,channel_id,message,hour,minute,tz,active)
-			VALUES ($1,$2,$3,$4,$5,$6,true)
-			ON CONFLICT ON CONSTRAINT uniq_user_time
-			DO UPDATE SET active=true,
-						channel_id = EXCLUDED.channel_id
-			RETURNING id`,
-				row.UserID, row.ChannelID, row.Message, row.Hour, row.Min, row.TZ,
-			).Scan(&row.ID)
-
-			if err != nil {
-				respond(s, ic, "Database error while saving your reminder.")
-				return
-			}
+// remindInput holds the raw options shared by /remind and /remindme.
+type remindInput struct {
+	Time, TZ, Message, Users, Until string
+	MaxFires                        int
+	ChannelID                       string // where to post, "" = here
+	Silent                          bool
+	MonthDay                        string // "15" or "last", "" = every day
+	Priority                        string
+	Name                            string
+	RRule                           string // "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"
+	EveryMin                        int    // fire every this many minutes, 0 = at time
+	Cron                            string // "*/30 9-17 * * 1-5"
+	Mirrors                         string // "<#123> <#456>"
+	AllowOverlap                    bool   // the user confirmed the overlap warning
+	Raw                             bool   // don't escape markdown in Message
+	DeleteAfterMin                  int    // delete each post after this many minutes, 0 = never
+}
+
+func readRemindInput(ic *discordgo.InteractionCreate) remindInput {
+	var in remindInput
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "time":
+			in.Time = opt.StringValue() // "06:35"
+		case "timezone":
+			in.TZ = opt.StringValue() // "America/Toronto"
+		case "message":
+			in.Message = opt.StringValue() // "uwu"
+		case "users":
+			in.Users = opt.StringValue() // "<@123>, <@456>"
+		case "max_fires":
+			in.MaxFires = int(opt.IntValue()) // 10
+		case "until":
+			in.Until = opt.StringValue() // "2025-12-31"
+		case "channel":
+			in.ChannelID = opt.ChannelValue(nil).ID
+		case "silent":
+			in.Silent = opt.BoolValue()
+		case "monthday":
+			in.MonthDay = opt.StringValue() // "15", "last"
+		case "priority":
+			in.Priority = opt.StringValue() // "high"
+		case "name":
+			in.Name = strings.TrimSpace(opt.StringValue()) // "standup"
+		case "rrule":
+			in.RRule = strings.ToUpper(strings.TrimSpace(opt.StringValue())) // "FREQ=MONTHLY;BYDAY=1MO"
+		case "every":
+			in.EveryMin = int(opt.IntValue()) // 90
+		case "cron":
+			in.Cron = strings.TrimSpace(opt.StringValue()) // "0 9 * * 1-5"
+		case "mirrors":
+			in.Mirrors = opt.StringValue() // "<#123> <#456>"
+		case "raw":
+			in.Raw = opt.BoolValue()
+		case "delete_after":
+			in.DeleteAfterMin = int(opt.IntValue()) // 5
+		}
+	}
+	return in
+}
 
-			// schedule the cron job
-			scheduleOne(db, row, s, loc)
+func handleRemind(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	in := readRemindInput(ic)
+	if in.Time == "" || in.TZ == "" || in.Message == "" {
+		respond(s, ic, "All three options (time, timezone, message) are required.")
+		return
+	}
+	createReminder(ctx, db, clk, s, ic, in)
+}
 
-			respond(s, ic,
-				fmt.Sprintf("Got it! I’ll remind you every day at %02d:%02d %s (ID %d)",
-					hour, min, tzStr, row.ID))
+// handleRemindMe is /remind with the timezone defaulted from the user's
+// preference, then the server's, then UTC.
+func handleRemindMe(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	in := readRemindInput(ic)
+	if in.Time == "" || in.Message == "" {
+		respond(s, ic, "Both time and message are required.")
+		return
+	}
+	if in.TZ == "" {
+		tz, err := defaultTZ(ctx, db, ic.Member.User.ID, ic.GuildID)
+		if err != nil {
+			respondErr(s, ic, "looking up your timezone", err)
+			return
+		}
+		in.TZ = tz
+	}
+	createReminder(ctx, db, clk, s, ic, in)
+}
 
-		case "stop":
-			if len(ic.ApplicationCommandData().Options) == 0 {
-				respond(s, ic, "Usage: /stop <reminder‑ID>")
-				return
-			}
-			id := int(ic.ApplicationCommandData().Options[0].IntValue())
+// createReminder validates a daily reminder for the invoking user in the
+// current channel, saves and schedules it, and replies either way.
+func createReminder(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate, in remindInput) {
+	// HH:MM validation
+	hour, min, err := parseClock(in.Time)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+
+	// timezone validation
+	loc, err := time.LoadLocation(in.TZ)
+	if err != nil {
+		respondWith(s, ic, tzFixPrompt(ic, in))
+		return
+	}
+
+	// limits validation
+	if in.MaxFires < 0 {
+		respond(s, ic, "max_fires must be at least 1.")
+		return
+	}
+	var until *time.Time
+	if in.Until != "" {
+		d, err := time.Parse("2006-01-02", in.Until)
+		if err != nil {
+			respond(s, ic, "Until must be a date like 2025-12-31.")
+			return
+		}
+		if d.Before(localDate(clk.Now().In(loc))) {
+			respond(s, ic, "Until can't be in the past.")
+			return
+		}
+		until = &d
+	}
+
+	// extra mentions validation
+	extra, err := parseUserList(in.Users, ic.Member.User.ID)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	for _, id := range extra {
+		if _, err := s.User(id); err != nil {
+			respond(s, ic, fmt.Sprintf("I couldn't find user %s.", id))
+			return
+		}
+	}
+
+	// schedule mode validation
+	picked := 0
+	for _, set := range []bool{in.MonthDay != "", in.RRule != "", in.EveryMin != 0, in.Cron != ""} {
+		if set {
+			picked++
+		}
+	}
+	if picked > 1 {
+		respond(s, ic, "Use only one of monthday, rrule, every and cron.")
+		return
+	}
+	mode, monthDay := modeDaily, 0
+	switch {
+	case in.MonthDay != "":
+		if mode, monthDay, err = parseMonthDay(in.MonthDay); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+	case in.RRule != "":
+		if _, err := parseRRule(in.RRule); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+		mode = modeRRule
+	case in.EveryMin != 0:
+		if in.EveryMin < int(minBoostEvery) || in.EveryMin > maxEvery {
+			respond(s, ic, fmt.Sprintf("Every must be between %d and %d minutes.", int(minBoostEvery), maxEvery))
+			return
+		}
+		mode = modeInterval
+	case in.Cron != "":
+		if _, err := cron.ParseStandard(in.Cron); err != nil {
+			respond(s, ic, "That cron spec doesn't work: "+err.Error())
+			return
+		}
+		mode = modeCron
+	}
+
+	// priority validation
+	priority := priorityNormal
+	switch in.Priority {
+	case "", priorityNormal:
+	case priorityLow, priorityHigh:
+		priority = in.Priority
+	default:
+		respond(s, ic, "Priority must be low, normal or high.")
+		return
+	}
+
+	// name validation
+	if in.Name != "" {
+		if err := checkName(in.Name); err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+	}
 
-			// mark inactive in DB
-			if _, err := db.Exec(context.Background(),
-				`UPDATE reminders SET active=false WHERE id=$1`, id); err != nil {
-				respond(s, ic, "Database error while stopping reminder.")
+	// target channel validation
+	channelID := ic.ChannelID
+	if in.ChannelID != "" && in.ChannelID != ic.ChannelID {
+		if msg := checkPostChannel(s, ic, in.ChannelID); msg != "" {
+			respond(s, ic, msg)
+			return
+		}
+		channelID = in.ChannelID
+	}
+	var mirrors []string
+	if in.Mirrors != "" {
+		ids, err := parseChannelList(in.Mirrors)
+		if err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+		for _, id := range ids {
+			if id == channelID {
+				continue
+			}
+			if msg := checkPostChannel(s, ic, id); msg != "" {
+				respond(s, ic, msg)
 				return
 			}
+			mirrors = append(mirrors, id)
+		}
+		if len(mirrors) > maxMirrors {
+			respond(s, ic, fmt.Sprintf("A reminder can be mirrored to at most %d channels.", maxMirrors))
+			return
+		}
+	}
 
-			// cancel the cron runner if it exists
-			if c, ok := crons[id]; ok {
-				c.Stop()
-				delete(crons, id)
-			}
+	// save to Database
+	row := Reminder{
+		UserID:    ic.Member.User.ID,
+		ChannelID: channelID,
+		GuildID:   ic.GuildID,
+		Message:   in.Message,
+		Hour:      hour,
+		Min:       min,
+		TZ:        in.TZ,
+		Active:    true,
+		Extra:     extra,
+		MaxFires:  in.MaxFires,
+		Until:     until,
+		Silent:    in.Silent,
+		Mode:      mode,
+		MonthDay:  monthDay,
+		Priority:  priority,
+		Name:      in.Name,
+		RRule:     in.RRule,
+		Mirrors:   mirrors,
+
+		IntervalMin: in.EveryMin,
+		CronSpec:    in.Cron,
 
-			respond(s, ic, fmt.Sprintf("Reminder %d stopped ✅", id))
+		RawMarkdown: in.Raw,
+		DeleteAfter: in.DeleteAfterMin * 60,
+	}
+
+	if !in.AllowOverlap {
+		id, message, err := overlapping(ctx, db, row)
+		if err != nil {
+			respondErr(s, ic, "saving your reminder", err)
+			return
+		}
+		if id != 0 {
+			respondWith(s, ic, overlapPrompt(ic, in, id, message))
+			return
 		}
 	}
-}
 
-func respond(s *discordgo.Session, ic *discordgo.InteractionCreate, msg string) {
-	s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{Content: msg},
-	})
+	movedFrom, err := existingChannel(ctx, db, row)
+	if err != nil {
+		respondErr(s, ic, "saving your reminder", err)
+		return
+	}
+
+	if !saveNewReminder(ctx, db, clk, s, ic, &row, loc) {
+		return
+	}
+
+	msg := fmt.Sprintf("Got it! I’ll remind you %s (ID %d)",
+		describeScheduleClock(row, uses12h(ctx, db, row.UserID)), row.ID)
+	if row.MaxFires == 1 {
+		msg += ", just once"
+	} else if row.MaxFires > 0 {
+		msg += fmt.Sprintf(", %d times", row.MaxFires)
+	}
+	if row.Until != nil {
+		msg += ", until " + row.Until.Format("2006-01-02")
+	}
+	if channelID != ic.ChannelID {
+		msg += " in <#" + channelID + ">"
+	}
+	if len(mirrors) > 0 {
+		msg += ", also in <#" + strings.Join(mirrors, ">, <#") + ">"
+	}
+	if row.Silent {
+		msg += ", silently"
+	}
+	if row.Priority != priorityNormal {
+		msg += ", " + row.Priority + " priority"
+	}
+	if row.Name != "" {
+		msg += fmt.Sprintf(", named %q", row.Name)
+	}
+	if movedFrom != "" && movedFrom != row.ChannelID {
+		respondWith(s, ic, movedNote(msg, row.ID, movedFrom, row.ChannelID))
+		return
+	}
+	respond(s, ic, msg)
 }
 
-func restoreJobs(db *pgx.Conn, ses *discordgo.Session) {
-	rows, _ := db.Query(context.Background(),
-		`SELECT id,user_id,channel_id,message,hour,minute,tz
-		   FROM reminders
-		  WHERE active`)
-	defer rows.Close()
+// checkPostChannel makes sure a reminder created by ic may post in
+// channelID: it's in the same server, and both the user and the bot can
+// send messages there. It returns what to tell the user, or "" if fine.
+func checkPostChannel(s *discordgo.Session, ic *discordgo.InteractionCreate, channelID string) string {
+	if channelGuild(s, channelID) != ic.GuildID {
+		return "That channel isn't in this server."
+	}
+	const need = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+	if p, err := s.UserChannelPermissions(ic.Member.User.ID, channelID); err != nil || p&need != need {
+		return fmt.Sprintf("You can't post in <#%s>.", channelID)
+	}
+	if p, err := s.UserChannelPermissions(s.State.User.ID, channelID); err != nil || p&need != need {
+		return fmt.Sprintf("I can't post in <#%s>. Ask an admin to let me send messages there.", channelID)
+	}
+	return ""
+}
 
-	for rows.Next() {
-		var r Reminder
-		if err := rows.Scan(&r.ID, &r.UserID, &r.ChannelID,
-			&r.Message, &r.Hour, &r.Min, &r.TZ); err != nil {
-			continue
+// saveNewReminder runs the checks shared by every creation command, then
+// saves row and schedules it, filling in row.ID. Saving the same message at
+// the same time again updates the existing reminder, but only while it's
+// off or keeps its schedule and limits; an active one is never quietly
+// turned into something else. On failure it has already replied to the
+// user.
+func saveNewReminder(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate, row *Reminder, loc *time.Location) bool {
+	// the server's channel allowlist binds admins too
+	for _, ch := range append([]string{row.ChannelID}, row.Mirrors...) {
+		if ok, err := channelAllowed(ctx, db, s, row.GuildID, ch); err != nil {
+			respondErr(s, ic, "saving your reminder", err)
+			return false
+		} else if !ok {
+			respond(s, ic, fmt.Sprintf("Reminders aren't allowed in <#%s> on this server.", ch))
+			return false
 		}
-		loc, err := time.LoadLocation(r.TZ)
+	}
+
+	// per-channel cap; admins may go over it
+	if maxPerChannel > 0 && !isAdmin(ic) {
+		n, err := channelReminderCount(ctx, db, row.ChannelID,
+			row.UserID, row.Hour, row.Min, row.TZ, row.Message)
 		if err != nil {
-			continue
+			respondErr(s, ic, "saving your reminder", err)
+			return false
+		}
+		if n >= maxPerChannel {
+			respond(s, ic, fmt.Sprintf("This channel already has %d active reminders, the most allowed. Try another channel or ask an admin.", n))
+			return false
+		}
+	}
+
+	// insert inside a transaction so a row whose job can't be
+	// scheduled is never left behind claiming to be active
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		respondErr(s, ic, "saving your reminder", err)
+		return false
+	}
+	defer tx.Rollback(ctx)
+
+	var inserted bool // false when the upsert hit an existing row
+	err = tx.QueryRow(ctx,
+		`INSERT INTO reminders
+	(user_id,channel_id,message,hour,minute,tz,active,extra_users,max_fires,until_date,guild_id,poll,
+	 mode,days,month_day,interval_min,cron_spec,silent,priority,name,rrule,mirror_channels,raw_markdown,
+	 delete_after_seconds)
+	VALUES ($1,$2,$3,$4,$5,$6,true,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,NULLIF($19,''),$20,$21,$22,$23)
+	ON CONFLICT ON CONSTRAINT uniq_user_time
+	DO UPDATE SET active=true,
+				channel_id = EXCLUDED.channel_id,
+				guild_id = EXCLUDED.guild_id,
+				poll = EXCLUDED.poll,
+				extra_users = EXCLUDED.extra_users,
+				max_fires = EXCLUDED.max_fires,
+				until_date = EXCLUDED.until_date,
+				mode = EXCLUDED.mode,
+				days = EXCLUDED.days,
+				month_day = EXCLUDED.month_day,
+				interval_min = EXCLUDED.interval_min,
+				cron_spec = EXCLUDED.cron_spec,
+				silent = EXCLUDED.silent,
+				priority = EXCLUDED.priority,
+				name = EXCLUDED.name,
+				rrule = EXCLUDED.rrule,
+				mirror_channels = EXCLUDED.mirror_channels,
+				raw_markdown = EXCLUDED.raw_markdown,
+				delete_after_seconds = EXCLUDED.delete_after_seconds,
+				fire_count = 0,
+				consecutive_failures = 0,
+				first_failure_at = NULL,
+				updated_at = now()
+	WHERE NOT reminders.active
+	   OR (reminders.mode, reminders.days, reminders.month_day, reminders.interval_min,
+	       reminders.cron_spec, reminders.rrule, reminders.max_fires, reminders.until_date)
+	      IS NOT DISTINCT FROM
+	      (EXCLUDED.mode, EXCLUDED.days, EXCLUDED.month_day, EXCLUDED.interval_min,
+	       EXCLUDED.cron_spec, EXCLUDED.rrule, EXCLUDED.max_fires, EXCLUDED.until_date)
+	RETURNING id, created_at, updated_at, xmax = 0`,
+		row.UserID, row.ChannelID, row.Message, row.Hour, row.Min, row.TZ, row.Extra,
+		row.MaxFires, row.Until, row.GuildID, row.Poll,
+		modeOrDaily(row.Mode), row.Days, row.MonthDay, row.IntervalMin, row.CronSpec, row.Silent,
+		priorityOrNormal(row.Priority), row.Name, row.RRule, mirrorsOrEmpty(row.Mirrors),
+		row.RawMarkdown, row.DeleteAfter,
+	).Scan(&row.ID, &row.CreatedAt, &row.UpdatedAt, &inserted)
+
+	if isUniqueViolation(err) {
+		respond(s, ic, fmt.Sprintf("You already have a reminder called %q.", row.Name))
+		return false
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the upsert's WHERE kept an active reminder on another schedule
+		var id int
+		if err := tx.QueryRow(ctx,
+			`SELECT id FROM reminders WHERE user_id=$1 AND hour=$2 AND minute=$3 AND tz=$4 AND message=$5`,
+			row.UserID, row.Hour, row.Min, row.TZ, row.Message).Scan(&id); err != nil {
+			respondErr(s, ic, "saving your reminder", err)
+			return false
 		}
+		respond(s, ic, fmt.Sprintf("You already have reminder %d with this message at this time, on a different schedule. "+
+			"Change or delete that one, or word this one differently.", id))
+		return false
+	}
+	if err != nil {
+		respondErr(s, ic, "saving your reminder", err)
+		return false
+	}
+
+	// schedule the cron job
+	if err := scheduleOne(db, clk, *row, s, loc); err != nil {
+		respondErr(s, ic, "scheduling your reminder (nothing was saved)", err)
+		return false
+	}
 
-		scheduleOne(db, r, ses, loc)
+	if err := tx.Commit(ctx); err != nil {
+		restoreRunner(db, clk, s, row.ID, inserted)
+		respondErr(s, ic, "saving your reminder", err)
+		return false
+	}
+	return true
+}
+
+// restoreRunner undoes scheduleOne for a save whose commit failed. The DB
+// still holds whatever was there before, so a new row's runner goes, and
+// an upserted row gets back the runner it had if it was active.
+func restoreRunner(db *pgxpool.Pool, clk Clock, s *discordgo.Session, id int, inserted bool) {
+	unschedule(id)
+	if inserted {
+		return
+	}
+	ctx, cancel := dbCtx()
+	defer cancel()
+	old, err := loadReminder(ctx, db, id)
+	if err != nil {
+		log.Printf("restore reminder %d after failed save: %v", id, err)
+		return
+	}
+	if !old.Active {
+		return
+	}
+	if err := reschedule(db, clk, s, old); err != nil {
+		log.Printf("restore reminder %d after failed save: %v", id, err)
 	}
 }
 
-func scheduleOne(db *pgx.Conn, r Reminder, s *discordgo.Session, loc *time.Location) {
+// maxPerChannel caps active reminders per channel, 0 = no cap.
+var maxPerChannel = 0
 
-	if s == nil {
+// channelReminderCount counts the active reminders in a channel, not
+// counting the one identified by the remaining arguments, which an upsert
+// would just reactivate.
+func channelReminderCount(ctx context.Context, db *pgxpool.Pool, channelID, userID string, hour, min int, tz, msg string) (int, error) {
+	var n int
+	err := db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM reminders
+		  WHERE channel_id=$1 AND active
+		    AND NOT (user_id=$2 AND hour=$3 AND minute=$4 AND tz=$5 AND message=$6)`,
+		channelID, userID, hour, min, tz, msg).Scan(&n)
+	return n, err
+}
+
+func handleStop(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if len(ic.ApplicationCommandData().Options) == 0 {
+		respond(s, ic, "Usage: /stop <reminder‑ID or name>")
+		return
+	}
+	id, ok := resolveRef(ctx, db, s, ic, ic.ApplicationCommandData().Options[0].StringValue())
+	if !ok {
 		return
 	}
 
-	if old, ok := crons[r.ID]; ok {
-		old.Stop()
+	if err := deactivate(ctx, db, id); err != nil {
+		respondErr(s, ic, "stopping reminder", err)
+		return
 	}
 
-	c := cron.New(cron.WithLocation(loc))
+	respond(s, ic, fmt.Sprintf("Reminder %d stopped ✅", id))
+}
 
-	spec := fmt.Sprintf("%d %d * * *", r.Min, r.Hour)
+// handleTransfer hands a reminder over to another user, e.g. when its
+// owner leaves the team. Manage Server only.
+func handleTransfer(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to transfer reminders.")
+		return
+	}
 
-	_, _ = c.AddFunc(spec, func() {
-		var active bool
-		_ = db.QueryRow(context.Background(),
-			"SELECT active FROM reminders WHERE id=$1", r.ID).Scan(&active)
-		if !active {
-			return
+	var ref string
+	var target *discordgo.User
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "user":
+			target = ic.ApplicationCommandData().Resolved.Users[opt.Value.(string)]
 		}
+	}
+	if ref == "" || target == nil {
+		respond(s, ic, "Usage: /transfer <reminder‑ID or name> <user>")
+		return
+	}
+	if target.Bot {
+		respond(s, ic, "Reminders can't be transferred to a bot.")
+		return
+	}
 
-		s.ChannelMessageSend(r.ChannelID, "<@"+r.UserID+"> "+r.Message)
-	})
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || channelGuild(s, r.ChannelID) != ic.GuildID {
+		respond(s, ic, fmt.Sprintf("Reminder %d doesn't exist in this server.", id))
+		return
+	}
 
-	c.Start()
+	r, err = transferReminder(ctx, db, id, target.ID)
+	if isUniqueViolation(err) {
+		respond(s, ic, fmt.Sprintf("<@%s> already has an identical reminder, or one with the same name.", target.ID))
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "transferring reminder", err)
+		return
+	}
 
-	crons[r.ID] = c
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	respond(s, ic, fmt.Sprintf("Reminder %d now belongs to <@%s> ✅", id, target.ID))
 }
 
-func atoi(s string) int {
-	i, _ := strconv.Atoi(s)
-	return i
+// transferReminder makes userID the owner of reminder id and returns it
+// as updated.
+func transferReminder(ctx context.Context, db *pgxpool.Pool, id int, userID string) (Reminder, error) {
+	// the new owner is pinged as the owner now, so drop them from the extras
+	var r Reminder
+	err := db.QueryRow(ctx,
+		`UPDATE reminders
+		    SET user_id = $2, extra_users = array_remove(extra_users, $2),
+		        updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, userID).Scan(reminderDest(&r)...)
+	return r, err
 }
 
-func ensureCommands(dg *discordgo.Session) {
-	appID := dg.State.User.ID
-	cmds, _ := dg.ApplicationCommands(appID, "")
-	if len(cmds) > 0 {
+func handleList(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	by := "next"
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "sort" {
+			by = opt.StringValue() // "created"
+		}
+	}
+
+	rs, err := userReminders(ctx, db, ic.Member.User.ID)
+	if err != nil {
+		respondErr(s, ic, "listing your reminders", err)
+		return
+	}
+	if len(rs) == 0 {
+		respond(s, ic, "You have no active reminders.")
 		return
-	} // already registered
+	}
 
-	_, _ = dg.ApplicationCommandCreate(appID, "", &discordgo.ApplicationCommand{
-		Name: "remind", Description: "Create a daily reminder",
-		Options: []*discordgo.ApplicationCommandOption{
+	fires := listOrder(rs, clk.Now(), by)
+	h12 := uses12h(ctx, db, ic.Member.User.ID)
+	var b strings.Builder
+	b.WriteString("Your reminders:\n")
+	for i, f := range fires {
+		line := formatReminderLine(f.r, f.next, h12) + ", created " + f.r.CreatedAt.Format("2006-01-02") + "\n"
+		if b.Len()+len(line) > 1900 {
+			fmt.Fprintf(&b, "…and %d more", len(fires)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	respond(s, ic, b.String())
+}
+
+// listOrder sorts rs for /list: by next fire (the default), by creation,
+// or by the time of day they next fire at. Reminders whose next fire
+// can't be worked out are left out.
+func listOrder(rs []Reminder, now time.Time, by string) []upcomingFire {
+	fires := make([]upcomingFire, 0, len(rs))
+	for _, r := range rs {
+		next, err := nextFire(r, now)
+		if err != nil {
+			continue
+		}
+		fires = append(fires, upcomingFire{r, next})
+	}
+
+	var less func(a, b upcomingFire) bool
+	switch by {
+	case "created":
+		less = func(a, b upcomingFire) bool { return a.r.CreatedAt.Before(b.r.CreatedAt) }
+	case "time":
+		clockOf := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+		less = func(a, b upcomingFire) bool { return clockOf(a.next) < clockOf(b.next) }
+	default:
+		less = func(a, b upcomingFire) bool { return a.next.Before(b.next) }
+	}
+	sort.SliceStable(fires, func(i, j int) bool { return less(fires[i], fires[j]) })
+	return fires
+}
+
+func handleTimezones(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var region string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		if opt.Name == "region" {
+			region = opt.StringValue() // "America"
+		}
+	}
+	respondWith(s, ic, timezonesPage(region, 0))
+}
+
+// isAdmin reports whether the invoking member has Manage Server.
+func isAdmin(ic *discordgo.InteractionCreate) bool {
+	return ic.Member != nil && ic.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// operators are the users who run the bot itself, set by OPERATOR_IDS.
+// Commands that act on or report about every server the bot is in are
+// theirs alone; a server's admins only get say over their own server.
+var operators map[string]bool
+
+// isOperator reports whether ic's user is one of the bot's operators.
+func isOperator(ic *discordgo.InteractionCreate) bool {
+	return ic.Member != nil && operators[ic.Member.User.ID]
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// channelGuild returns the guild a channel belongs to, or "" if it can't be
+// resolved.
+func channelGuild(s *discordgo.Session, channelID string) string {
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		if ch, err = s.Channel(channelID); err != nil {
+			return ""
+		}
+	}
+	return ch.GuildID
+}
+
+// onComponent handles button presses on messages the bot sent.
+func onComponent(db *pgxpool.Pool, clk Clock) func(*discordgo.Session, *discordgo.InteractionCreate) {
+	return func(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+		if ic.Type != discordgo.InteractionMessageComponent {
+			return
+		}
+
+		customID := ic.MessageComponentData().CustomID
+		switch {
+		case strings.HasPrefix(customID, "tz:"):
+			prefix, page, ok := parseTZCustomID(customID)
+			if !ok {
+				return
+			}
+			s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: timezonesPage(prefix, page),
+			})
+		case strings.HasPrefix(customID, "tzfix:"):
+			acceptTZFix(db, clk, s, ic, customID)
+		case strings.HasPrefix(customID, "clearprefs:"):
+			clearPrefsButton(db, s, ic)
+		case strings.HasPrefix(customID, "gift:"):
+			giftButton(db, clk, s, ic)
+		case strings.HasPrefix(customID, "fired:"):
+			firedButton(db, clk, s, ic)
+		case strings.HasPrefix(customID, "overlap:"):
+			overlapButton(db, clk, s, ic)
+		case strings.HasPrefix(customID, "keepch:"):
+			keepChannelButton(db, clk, s, ic)
+		case strings.HasPrefix(customID, "board:"):
+			boardButton(db, s, ic)
+		case strings.HasPrefix(customID, "snoozeall:"):
+			snoozeAllButton(db, clk, s, ic)
+		}
+	}
+}
+
+// respond replies with msg. Validation failures go straight through here
+// with a message telling the user what to fix; failures on our side use
+// respondErr instead.
+func respond(s *discordgo.Session, ic *discordgo.InteractionCreate, msg string) {
+	respondWith(s, ic, &discordgo.InteractionResponseData{Content: msg})
+}
+
+// respondErr reports a system error (DB, Discord API). The detail is logged
+// under a short error ID, and the user only gets the ID to quote to an
+// admin. doing says what failed, e.g. "saving your reminder".
+func respondErr(s *discordgo.Session, ic *discordgo.InteractionCreate, doing string, err error) {
+	id := newErrorID()
+	attrs := []any{"error_id", id, "command", commandName(ic), "err", err}
+	if ic.Member != nil {
+		attrs = append(attrs, "user", ic.Member.User.ID, "guild", ic.GuildID)
+	}
+	slog.Error("command failed while "+doing, attrs...)
+
+	respond(s, ic, fmt.Sprintf("Sorry, something went wrong on my end while %s. "+
+		"If it keeps happening, tell an admin error code `%s`.", doing, id))
+	reportError(s, "command:"+doing, fmt.Sprintf("/%s failed while %s (error `%s`): %v", commandName(ic), doing, id, err))
+}
+
+// newErrorID returns a short random code that ties a user report to a log
+// line.
+func newErrorID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// respondWith fills in the deferred reply onSlash sent for ic. If the
+// original response can't be edited the result is sent as a followup
+// instead, so the user still sees it.
+func respondWith(s *discordgo.Session, ic *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	edit := &discordgo.WebhookEdit{Content: &data.Content}
+	if data.Components != nil {
+		edit.Components = &data.Components
+	}
+	_, err := s.InteractionResponseEdit(ic.Interaction, edit)
+	if err == nil {
+		return
+	}
+	log.Printf("edit reply to /%s: %v", commandName(ic), err)
+
+	if ephemeralCommands[commandName(ic)] {
+		data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if _, err := s.FollowupMessageCreate(ic.Interaction, true, &discordgo.WebhookParams{
+		Content:    data.Content,
+		Components: data.Components,
+		Flags:      data.Flags,
+	}); err != nil {
+		log.Printf("followup to /%s: %v", commandName(ic), err)
+	}
+}
+
+// commandName names what ic is for in logs: the slash command, or the
+// custom ID of the button pressed or modal submitted.
+func commandName(ic *discordgo.InteractionCreate) string {
+	switch ic.Type {
+	case discordgo.InteractionMessageComponent:
+		return ic.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		return ic.ModalSubmitData().CustomID
+	}
+	return ic.ApplicationCommandData().Name
+}
+
+// catchupWindow is how far back restoreJobs replays fires missed while the
+// bot was down. Anything older is dropped rather than flooding channels
+// after a long outage; 0 disables catch-up.
+var catchupWindow = 2 * time.Hour
+
+// restoreJobs schedules every active reminder, replays any fire missed
+// within catchupWindow, and returns how many were scheduled.
+func restoreJobs(ctx context.Context, db *pgxpool.Pool, clk Clock, ses *discordgo.Session) int {
+	qctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	rows, _ := db.Query(qctx,
+		`SELECT `+reminderColumns+`
+		   FROM reminders
+		  WHERE active`)
+
+	var rs []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := scanReminder(rows, &r); err != nil {
+			continue
+		}
+		rs = append(rs, r)
+	}
+	rows.Close()
+	cancel()
+
+	n := 0
+	now := clk.Now()
+	for _, r := range rs {
+		if r.GuildID == "" {
+			rctx, cancel := context.WithTimeout(ctx, dbTimeout)
+			backfillGuild(rctx, db, ses, &r)
+			cancel()
+		}
+
+		loc, err := time.LoadLocation(r.TZ)
+		if err != nil {
+			continue
+		}
+
+		if err := scheduleOne(db, clk, r, ses, loc); err != nil {
+			log.Printf("restore reminder %d: %v", r.ID, err)
+			continue
+		}
+		n++
+
+		if missed, ok := missedFire(r, now, catchupWindow); ok {
+			log.Printf("catching up reminder %d missed at %s", r.ID, missed.Format(time.RFC3339))
+			fireReminder(db, clk, sessionFor(r.GuildID, ses), r, loc)
+		}
+	}
+	return n
+}
+
+// backfillGuild fills in guild_id for reminders created before it was
+// stored, by looking up the reminder's channel.
+func backfillGuild(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, r *Reminder) {
+	g := channelGuild(s, r.ChannelID)
+	if g == "" {
+		return
+	}
+	if _, err := db.Exec(ctx,
+		`UPDATE reminders SET guild_id=$2 WHERE id=$1`, r.ID, g); err != nil {
+		log.Printf("backfill guild for reminder %d: %v", r.ID, err)
+		return
+	}
+	r.GuildID = g
+}
+
+// missedFire returns the first fire of r that should have happened after
+// its last fire or its last change, whichever is later, but before now, as
+// long as it falls within window. A reminder that has never fired counts
+// from when it was created or last changed, so a one-shot due while the bot
+// was down still goes out.
+func missedFire(r Reminder, now time.Time, window time.Duration) (time.Time, bool) {
+	if window <= 0 {
+		return time.Time{}, false
+	}
+	since := r.UpdatedAt
+	if since.Before(r.CreatedAt) {
+		since = r.CreatedAt
+	}
+	if r.LastFired != nil && r.LastFired.After(since) {
+		since = *r.LastFired
+	}
+	missed, err := nextFire(r, since)
+	if err != nil || !missed.Before(now) {
+		return time.Time{}, false
+	}
+	return missed, !missed.Before(now.Add(-window))
+}
+
+// loadReminder fetches a single reminder by ID.
+func loadReminder(ctx context.Context, db *pgxpool.Pool, id int) (Reminder, error) {
+	var r Reminder
+	err := scanReminder(db.QueryRow(ctx,
+		`SELECT `+reminderColumns+` FROM reminders WHERE id=$1`, id), &r)
+	return r, err
+}
+
+// reschedule replaces r's cron runner after r has been changed.
+func reschedule(db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder) error {
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		return err
+	}
+	return scheduleOne(db, clk, r, s, loc)
+}
+
+// errInactive is scheduleOne's refusal of a reminder that's been turned
+// off; callers check r.Active and unschedule instead.
+var errInactive = errors.New("reminder is not active")
+
+// scheduleOne (re)creates the cron runner for r. The previous runner, if
+// any, is only replaced once the new job has been added successfully.
+// Inactive reminders are never scheduled.
+func scheduleOne(db *pgxpool.Pool, clk Clock, r Reminder, s *discordgo.Session, loc *time.Location) error {
+
+	if s == nil {
+		return errors.New("no discord session")
+	}
+	if !r.Active {
+		return errInactive
+	}
+	s = sessionFor(r.GuildID, s)
+
+	now := clk.Now()
+	sched, opts, err := buildSchedule(r, now)
+	if err != nil {
+		return err
+	}
+	c := cron.New(opts...)
+	r.CronID = c.Schedule(sched, cron.FuncJob(func() { fireReminder(db, clk, s, r, loc) }))
+	if r.LeadMin > 0 {
+		lead := leadSchedule{sched, time.Duration(r.LeadMin) * time.Minute}
+		c.Schedule(lead, cron.FuncJob(func() { headsUp(db, clk, s, r, loc) }))
+	}
+	if boosted(r, now) {
+		armBoostEnd(db, clk, s, r)
+	}
+
+	// swap under the lock so concurrent (re)schedules of the same reminder,
+	// e.g. restoreJobs running twice, always leave exactly one runner
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+
+	if old, ok := crons[r.ID]; ok {
+		old.Stop()
+	}
+
+	c.Start()
+
+	crons[r.ID] = c
+	cronVersions[r.ID] = r.UpdatedAt
+	cronEntries[r.ID] = r.CronID
+	return nil
+}
+
+// fireReminder runs one scheduled fire of r. The until date and max_fires
+// are both checked on every fire and whichever is reached first ends the
+// reminder; either one alone works the same way. The until date itself
+// still fires, and so does the fire that reaches max_fires.
+func fireReminder(db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder, loc *time.Location) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	var active, paused, skip bool
+	_ = db.QueryRow(ctx,
+		`SELECT r.active, r.fire_count, r.last_fired, r.last_message_id, r.skip_next, COALESCE(g.paused, false)
+		   FROM reminders r
+		   LEFT JOIN guild_prefs g ON g.guild_id = r.guild_id
+		  WHERE r.id=$1`, r.ID).Scan(&active, &r.FireCount, &r.LastFired, &r.LastMessageID, &skip, &paused)
+	if !active || paused {
+		return
+	}
+
+	now := clk.Now().In(loc)
+	if !onFireDay(r, now) {
+		return
+	}
+	if gap := fireGap(r); tooSoon(r.LastFired, now, gap) {
+		log.Printf("skip reminder %d: fired less than %s ago", r.ID, gap)
+		return
+	}
+	if skip {
+		// held by /snoozeall: this fire is the one skipped. It counts as
+		// the last fire so catch-up after a restart doesn't send it anyway
+		if _, err := db.Exec(ctx,
+			`UPDATE reminders SET skip_next = false, last_fired = $2 WHERE id=$1`, r.ID, clk.Now()); err != nil {
+			log.Printf("unskip reminder %d: %v", r.ID, err)
+		}
+		return
+	}
+	if voiceGated(s, r) {
+		log.Printf("skip reminder %d: owner not in voice", r.ID)
+		return
+	}
+	if limitReached(r, now) {
+		if err := deactivate(ctx, db, r.ID); err != nil {
+			log.Printf("expire reminder %d: %v", r.ID, err)
+		}
+		return
+	}
+
+	if r.FetchURL != "" {
+		r.Message = dynamicMessage(ctx, r)
+	}
+	d := loadDelivery(ctx, db, s, r, now)
+	if r.ReplyChain {
+		d.replyTo = r.LastMessageID
+	}
+	d.text = renderFire(s, r, d) // once, for the post and the log alike
+	sent, sendErr := sendReminder(s, r, d)
+	if sendErr != nil {
+		logRepeated("send reminder %d: %v", r.ID, sendErr)
+	}
+	mirrorReminder(s, r, d)
+	if sent != nil && r.DeleteAfter > 0 {
+		deleteAfter(s, r.ID, sent, time.Duration(r.DeleteAfter)*time.Second)
+	}
+	emitFire(fireEvent{ReminderID: r.ID, UserID: r.UserID, Timestamp: clk.Now(), Success: sendErr == nil})
+	if sent != nil && r.EscalateMin > 0 {
+		awaitAck(ctx, db, clk, s, r, sent)
+	}
+
+	// the send may have used up most of the first deadline
+	ctx, cancel = dbCtx()
+	defer cancel()
+
+	// a failed send still counts as a fire, and extends the failure streak
+	r.FireCount++
+	var failures int
+	var failingSince *time.Time
+	sentID := ""
+	if sent != nil {
+		sentID = sent.ID
+	}
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders
+		    SET fire_count = fire_count + 1, last_fired = $2,
+		        consecutive_failures = CASE WHEN $3 THEN consecutive_failures + 1 ELSE 0 END,
+		        first_failure_at = CASE WHEN $3 THEN COALESCE(first_failure_at, $2) END,
+		        last_message_id = COALESCE(NULLIF($4, ''), last_message_id)
+		  WHERE id=$1
+		RETURNING consecutive_failures, first_failure_at`,
+		r.ID, clk.Now(), sendErr != nil, sentID).Scan(&failures, &failingSince); err != nil {
+		log.Printf("count reminder %d: %v", r.ID, err)
+		reportError(s, "db:count", fmt.Sprintf("Recording a fire of reminder %d failed: %v", r.ID, err))
+	}
+	logFire(ctx, db, r, d.text, sendErr == nil)
+	if failures > 1 {
+		reportError(s, fmt.Sprintf("send:%d", r.ID), fmt.Sprintf("Reminder %d has failed to post in <#%s> %d times in a row: %v", r.ID, r.ChannelID, failures, sendErr))
+	}
+	if failedTooLong(failures, failingSince, clk.Now()) {
+		disableFailing(ctx, db, s, r, failures, sendErr)
+		return
+	}
+	if limitReached(r, now) {
+		if err := deactivate(ctx, db, r.ID); err != nil {
+			log.Printf("expire reminder %d: %v", r.ID, err)
+		}
+	}
+}
+
+// maxSendFailures is how many sends in a row may fail before a reminder is
+// switched off, 0 = never. It only applies with no failureGrace.
+var maxSendFailures = 5
+
+// failureGrace is how long a reminder may keep failing to send before
+// it's switched off, however many fires that is, so an outage of a few
+// hours doesn't cost anyone their reminders. 0 goes by maxSendFailures.
+var failureGrace = 3 * 24 * time.Hour
+
+// failedTooLong reports whether a reminder with this failure streak, its
+// first failure at since, should be switched off as of now.
+func failedTooLong(failures int, since *time.Time, now time.Time) bool {
+	if failures == 0 {
+		return false
+	}
+	if failureGrace > 0 {
+		return since != nil && now.Sub(*since) >= failureGrace
+	}
+	return maxSendFailures > 0 && failures >= maxSendFailures
+}
+
+// disableFailing turns off a reminder that keeps failing to send and tells
+// its owner by DM why.
+func disableFailing(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, r Reminder, failures int, cause error) {
+	if err := deactivate(ctx, db, r.ID); err != nil {
+		log.Printf("disable failing reminder %d: %v", r.ID, err)
+		return
+	}
+	log.Printf("disabled reminder %d after %d failed sends", r.ID, failures)
+	reportError(s, "disabled", fmt.Sprintf("Reminder %d in <#%s> was turned off after %d failed sends: %v", r.ID, r.ChannelID, failures, cause))
+
+	msg := fmt.Sprintf("⚠️ I turned off your reminder %d (%q) after %d failed attempts to post it in <#%s>. "+
+		"Last error: %v. Check my permissions there and create it again.",
+		r.ID, r.Message, failures, r.ChannelID, cause)
+	if err := sendDM(s, r.UserID, msg); err != nil {
+		log.Printf("DM owner of reminder %d: %v", r.ID, err)
+	}
+}
+
+// limitReached reports whether r has used up its fires or is past its
+// until date as of now (in r's timezone).
+func limitReached(r Reminder, now time.Time) bool {
+	if r.MaxFires > 0 && r.FireCount >= r.MaxFires {
+		return true
+	}
+	return r.Until != nil && localDate(now).After(*r.Until)
+}
+
+// localDate is now's calendar date as a UTC midnight, comparable with DATE
+// columns.
+func localDate(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// deactivate marks a reminder inactive and cancels its cron runner.
+func deactivate(ctx context.Context, db *pgxpool.Pool, id int) error {
+	if _, err := db.Exec(ctx,
+		`UPDATE reminders SET active=false, updated_at=now() WHERE id=$1`, id); err != nil {
+		return err
+	}
+
+	// cancel the cron runner if it exists
+	unschedule(id)
+	return nil
+}
+
+// unschedule stops and forgets the cron runner for a reminder, if any.
+func unschedule(id int) {
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+
+	if c, ok := crons[id]; ok {
+		// the fire by its entry, then whatever else the runner holds
+		c.Remove(cronEntries[id])
+		c.Stop()
+		delete(crons, id)
+	}
+	delete(cronVersions, id)
+	delete(cronEntries, id)
+}
+
+// scheduledNext is when the fire entry of reminder id's runner will next
+// run, as the scheduler itself sees it. It's false if id isn't scheduled.
+func scheduledNext(id int) (time.Time, bool) {
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+
+	c, ok := crons[id]
+	if !ok {
+		return time.Time{}, false
+	}
+	e := c.Entry(cronEntries[id])
+	return e.Next, e.Valid()
+}
+
+// parseClock validates an "HH:MM" 24-hour time. The hour may drop its
+// leading zero but the minute must have two digits. The error is meant
+// for the user and names the field that's wrong.
+func parseClock(s string) (hour, min int, err error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok || !isDigits(h) || !isDigits(m) || len(h) > 2 || len(m) != 2 {
+		return 0, 0, errors.New("Time must be HH:MM (24‑hour).")
+	}
+	hour, _ = strconv.Atoi(h)
+	min, _ = strconv.Atoi(m)
+	if hour > 23 {
+		return 0, 0, errors.New("Hour must be 00–23.")
+	}
+	if min > 59 {
+		return 0, 0, errors.New("Minute must be 00–59.")
+	}
+	return hour, min, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var zero, one = 0.0, 1.0
+
+// adminOnly hides a command from members without Manage Server until a
+// server's admins say otherwise; the handlers still check isAdmin.
+var adminOnly int64 = discordgo.PermissionManageServer
+
+// /shift moves by less than a day either way
+var minShift, maxShift = -24*60 + 1.0, 24*60 - 1.0
+
+// /boost and /remind every fire at most every 5 minutes, and /remind
+// every at least daily
+var minBoostEvery = 5.0
+
+const maxEvery = 24 * 60
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name: remindAboutName, Type: discordgo.MessageApplicationCommand,
+	},
+	{
+		Name: "remind", Description: "Create a daily reminder",
+		Options: []*discordgo.ApplicationCommandOption{
 			{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "HH:MM", Required: true},
 			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name", Required: true},
 			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Text", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "users", Description: "Other users to ping, e.g. @a @b"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "max_fires", Description: "Stop after this many reminders", MinValue: &one},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "until", Description: "Last day to remind, YYYY-MM-DD"},
+			{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Post there instead of here",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews,
+					discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread}},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Ping without a push notification"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "priority", Description: "High stands out, low doesn't ping",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "high", Value: priorityHigh}, {Name: "normal", Value: priorityNormal}, {Name: "low", Value: priorityLow},
+				}},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "monthday", Description: "Day of the month (1-31) or \"last\", instead of every day", MaxLength: 4},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Short name to use instead of the ID", MaxLength: maxNameLen},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "mirrors", Description: "Also post in these channels, e.g. #a #b"},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "raw", Description: "Keep headings, code blocks and mentions in the message as written"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "delete_after", Description: "Delete each post after this many minutes", MinValue: &one, MaxValue: maxDeleteAfterMin},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "rrule", Description: "iCalendar rule like FREQ=WEEKLY;INTERVAL=2;BYDAY=TU, instead of every day", MaxLength: 200},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "every", Description: "Fire every this many minutes instead of daily at time", MinValue: &minBoostEvery, MaxValue: maxEvery},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "cron", Description: "5-field cron spec like 0 9 * * 1-5, in timezone, instead of daily at time", MaxLength: 100},
 		},
-	})
-	_, _ = dg.ApplicationCommandCreate(appID, "", &discordgo.ApplicationCommand{
+	},
+	{
+		Name: "remindme", Description: "Quick daily reminder here, in your default timezone",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "HH:MM", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Text", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name (defaults to /settz)"},
+		},
+	},
+	{
+		Name: "remindat", Description: "Remind once at an exact time",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "when", Description: "ISO 8601, e.g. 2025-06-01T18:30:00-04:00", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Text", Required: true},
+		},
+	},
+	{
+		Name: "dmize", Description: "Send one of your reminders to your DMs instead of its channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+		},
+	},
+	{
+		Name: "undmize", Description: "Post a reminder in its channel again",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+		},
+	},
+	{
+		Name: "window", Description: "Fire a daily reminder at a random time between two times",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "from", Description: "Earliest time, HH:MM (24h)", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "to", Description: "Latest time, HH:MM (24h)", Required: true},
+		},
+	},
+	{
+		Name: "templates", Description: "List the reminders this server shares",
+	},
+	{
+		Name: "subscribe", Description: "Get your own copy of a shared reminder, posting here",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Template name, see /templates", Required: true, MaxLength: maxNameLen},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name to keep its time in (defaults to the template's)"},
+		},
+	},
+	{
+		Name: "publish", Description: "Share one of your reminders as a template (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Template name members subscribe by", Required: true, MaxLength: maxNameLen},
+		},
+	},
+	{
+		Name: "unpublish", Description: "Stop sharing a template (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Template name", Required: true, MaxLength: maxNameLen},
+		},
+	},
+	{
+		Name: "gift", Description: "Offer a daily reminder to someone else",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Who it's for", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "HH:MM", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Text", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name (defaults to theirs)"},
+		},
+	},
+	{
+		Name: "remindform", Description: "Create a daily reminder in a dialog",
+	},
+	{
+		Name: "settz", Description: "Set your default timezone",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name", Required: true},
+		},
+	},
+	{
+		Name: "timeformat", Description: "Show times as 24-hour or 12-hour",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "format", Description: "Clock to use", Required: true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "24-hour (18:30)", Value: "24h"}, {Name: "12-hour (6:30 PM)", Value: "12h"},
+				}},
+		},
+	},
+	{
+		Name: "language", Description: "Language for {greeting} in your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "language", Description: "Language to use", Required: true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "English", Value: langEnglish}, {Name: "Français", Value: langFrench},
+				}},
+		},
+	},
+	{
+		Name: "prefs", Description: "Show your saved preferences",
+	},
+	{
+		Name: "clearprefs", Description: "Reset your preferences to the server defaults",
+	},
+	{
+		Name: "retz", Description: "Move all your reminders to a new timezone",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name", Required: true},
+		},
+	},
+	{
+		Name: "setguildtz", Description: "Set this server's default timezone (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name", Required: true},
+		},
+	},
+	{
+		Name: "upcoming", Description: "Reminders firing soon anywhere in this server (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "How far ahead to look (default 60)", MinValue: &one, MaxValue: 7 * 24 * 60},
+		},
+	},
+	{
+		Name: "setreminderchannels", Description: "Limit which channels reminders can post in (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "channels", Description: "e.g. #general #todo; leave out to allow all"},
+		},
+	},
+	{
+		Name: "setfallback", Description: "Where reminders go if I can't post in their channel (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Post here instead",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews}},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dm", Description: "DM the owner instead"},
+		},
+	},
+	{
+		Name: "greeting", Description: "Address members by nickname in reminders (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "nickname", Description: "Start reminders with \"Hey <nickname>,\"", Required: true},
+		},
+	},
+	{
+		Name: "webhook", Description: "Post a reminder under a custom name and avatar",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Name to post as; leave out to post as the bot", MaxLength: 80},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "avatar", Description: "Avatar image URL"},
+		},
+	},
+	{
+		Name: "link", Description: "Add a button opening a link to every fire of a reminder",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "url", Description: "Link, {user} and {reminder} are filled in; leave out to remove", MaxLength: 512},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "label", Description: "Button text (default: Open)", MaxLength: maxLinkLabel},
+		},
+	},
+	{
+		Name: "fetch", Description: "Fill a reminder's message with text from a URL each time it fires",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "url", Description: "https:// link, goes where {fetched} is in the message; leave out to stop"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "field", Description: "Path to the text in a JSON reply, e.g. data.quote or 0.q"},
+		},
+	},
+	{
+		Name: "escalate", Description: "Ping again if nobody reacts ✅ in time",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "Wait this long before re-pinging, 0 = off", Required: true, MinValue: &zero, MaxValue: maxEscalateMin},
+		},
+	},
+	{
+		Name: "headsup", Description: "Get a heads-up some minutes before a reminder",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "How long before, 0 = off", Required: true, MinValue: &zero, MaxValue: maxLeadMin},
+		},
+	},
+	{
+		Name: "remindpoll", Description: "Post a daily poll",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "HH:MM", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "question", Description: "Poll question", Required: true, MaxLength: maxPollQuestion},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "answers", Description: "Answers separated by |, e.g. Yes | No", Required: true},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "multiselect", Description: "Allow picking several answers"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "hours", Description: "How long the poll stays open (default 24)", MinValue: &one, MaxValue: maxPollHours},
+		},
+	},
+	{
 		Name: "stop", Description: "Cancel a reminder",
 		Options: []*discordgo.ApplicationCommandOption{
-			{Type: discordgo.ApplicationCommandOptionInteger, Name: "id", Description: "Reminder ID", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+		},
+	},
+	{
+		Name: "list", Description: "Show your active reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "sort", Description: "Order to list them in (default: next fire)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "next fire", Value: "next"}, {Name: "creation", Value: "created"}, {Name: "time of day", Value: "time"},
+				}},
+		},
+	},
+	{
+		Name: "between", Description: "List your reminders that fire between two times of day",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "from", Description: "HH:MM", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "to", Description: "HH:MM, earlier than from to span midnight", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name for from and to (defaults to /settz)"},
+		},
+	},
+	{
+		Name: "last", Description: "Show the last reminder I sent you again",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dm", Description: "Send it to your DMs"},
+		},
+	},
+	{
+		Name: "leaderboard", Description: "Who in this server has the most reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "by", Description: "What to count (default: active reminders)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "active reminders", Value: "active"},
+					{Name: "times reminded", Value: "fired"},
+				}},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "hide_me", Description: "Leave yourself off the leaderboard, or false to come back"},
+		},
+	},
+	{
+		Name: "streak", Description: "Show how many days in a row you've acknowledged your reminders",
+	},
+	{
+		Name: "shift", Description: "Move a reminder earlier or later for good",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "Minutes to move by, negative for earlier", Required: true, MinValue: &minShift, MaxValue: maxShift},
+		},
+	},
+	{
+		Name: "convert", Description: "Change a reminder between daily, weekly and monthly",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "mode", Description: "New schedule", Required: true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "daily", Value: modeDaily}, {Name: "weekly", Value: modeWeekly}, {Name: "monthly", Value: modeMonthly},
+				}},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "days", Description: "For weekly: days like mon, wed, fri"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "every_weeks", Description: "For weekly: only every this many weeks, starting this week", MinValue: &one, MaxValue: maxEveryWeeks},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "monthday", Description: "For monthly: day of the month (1-31) or \"last\"", MaxLength: 4},
+		},
+	},
+	{
+		Name: "boost", Description: "Make a reminder fire more often for a while",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "every", Description: "Minutes between fires while boosted, leave out to end a boost", MinValue: &minBoostEvery, MaxValue: 24 * 60},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "hours", Description: "How long the boost lasts", MinValue: &one, MaxValue: maxBoostHours},
+		},
+	},
+	{
+		Name: "cooldown", Description: "Never fire a reminder twice within this many minutes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "Minimum gap between fires, 0 = off", Required: true, MinValue: &zero, MaxValue: maxCooldownMin},
+		},
+	},
+	{
+		Name: "replychain", Description: "Make each fire of a reminder reply to the previous one",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Reply to the previous fire", Required: true},
 		},
+	},
+	{
+		Name: "voiceonly", Description: "Only fire a reminder while you're in a voice channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Skip fires while you're not in voice", Required: true},
+		},
+	},
+	{
+		Name: "daycount", Description: "End each fire of a reminder with its count, e.g. (day 42)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Show the count", Required: true},
+		},
+	},
+	{
+		Name: "snoozeall", Description: "Hold all your reminders still due today until tomorrow",
+	},
+	{
+		Name: "testfire", Description: "Send a reminder once in a minute, to check it works",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+		},
+	},
+	{
+		Name: "snooze", Description: "Send a reminder once more, later",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "for", Description: "How long, e.g. 30m"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "until", Description: "Time of day, HH:MM"},
+		},
+	},
+	{
+		Name: "inspect", Description: "Show a reminder's stored details and next fire times",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+		},
+	},
+	{
+		Name: "preview", Description: "List the next fire times of a reminder or a cron spec",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "cron", Description: "Cron spec to try, e.g. 30 9 * * 1-5"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name for the cron spec (defaults to /settz)"},
+		},
+	},
+	{
+		Name: "testdm", Description: "Check that I can send you DMs",
+	},
+	{
+		Name: "calendar", Description: "Get a calendar feed of your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "reset", Description: "Make a new link and disable the old one"},
+		},
+	},
+	{
+		Name: "transfer", Description: "Give a reminder to another user (admin)",
+		DefaultMemberPermissions: &adminOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Reminder ID or name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "New owner", Required: true},
+		},
+	},
+	{
+		Name: "digest", Description: "Weekly DM summary of your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Send the digest", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name for Sunday evening"},
+		},
+	},
+	{
+		Name: "reload", Description: "Reschedule every reminder from the database (admin)",
+		DefaultMemberPermissions: &adminOnly,
+	},
+	{
+		Name: "capacity", Description: "How loaded this bot instance is (admin)",
+		DefaultMemberPermissions: &adminOnly,
+	},
+	{
+		Name: "globalpause", Description: "Silence every reminder in this server (admin)",
+		DefaultMemberPermissions: &adminOnly,
+	},
+	{
+		Name: "globalresume", Description: "Undo /globalpause (admin)",
+		DefaultMemberPermissions: &adminOnly,
+	},
+	{
+		Name: "timezones", Description: "List valid timezone names",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "region", Description: "Prefix, e.g. America", MaxLength: 32},
+		},
+	},
+	{
+		Name: "checktz", Description: "Check a timezone name and see the time there",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "timezone", Description: "TZ name, e.g. America/Toronto", Required: true},
+		},
+	},
+}
+
+// ensureCommands registers every command in one bulk overwrite, so boots
+// stay clear of Discord's daily command-create limit and commands dropped
+// from the list go away too. A rate limit or failure is retried; it only
+// errors once registerAttempts tries haven't got through.
+func ensureCommands(dg *discordgo.Session) error {
+	appID := dg.State.User.ID
+	localizeCommands(commands)
+	guildOnly(commands)
+
+	err := withRetry(registerAttempts, time.Sleep, func() error {
+		_, err := dg.ApplicationCommandBulkOverwrite(appID, "", commands)
+		return err
 	})
+	if err != nil {
+		return fmt.Errorf("register commands: %w", err)
+	}
+	return nil
+}
+
+// guildOnly keeps cmds out of DMs; every handler works on a server member.
+func guildOnly(cmds []*discordgo.ApplicationCommand) {
+	noDM := false
+	for _, cmd := range cmds {
+		cmd.DMPermission = &noDM
+		cmd.Contexts = &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+	}
+}
+
+// registerAttempts is how many times registering the commands is tried.
+const registerAttempts = 5
+
+// withRetry calls fn up to attempts times, sleeping between tries for as
+// long as retryDelay says. Errors retrying won't fix are returned at once.
+func withRetry(attempts int, sleep func(time.Duration), fn func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		d, ok := retryDelay(err, i)
+		if !ok || i == attempts-1 {
+			break
+		}
+		log.Printf("retrying in %s: %v", d, err)
+		sleep(d)
+	}
+	return err
+}
+
+// retryDelay is how long to wait before retrying after err on the given
+// attempt (0-based), and whether to retry at all. Rate limits wait as long
+// as Discord asks; server errors and timeouts back off exponentially from
+// a second.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	backoff := time.Second << attempt
+
+	var rl *discordgo.RateLimitError
+	if errors.As(err, &rl) && rl.RateLimit != nil && rl.TooManyRequests != nil {
+		return max(rl.RetryAfter, 100*time.Millisecond), true
+	}
+	var rerr *discordgo.RESTError
+	if errors.As(err, &rerr) && rerr.Response != nil {
+		switch code := rerr.Response.StatusCode; {
+		case code == http.StatusTooManyRequests:
+			if secs, perr := strconv.ParseFloat(rerr.Response.Header.Get("Retry-After"), 64); perr == nil {
+				return time.Duration(secs * float64(time.Second)), true
+			}
+			return backoff, true
+		case code >= 500:
+			return backoff, true
+		default:
+			return 0, false // a bad command definition won't fix itself
+		}
+	}
+	// no response at all: timeouts, dropped connections
+	return backoff, true
 }
 
 const schema = `
@@ -296,4 +2191,179 @@ CREATE TABLE IF NOT EXISTS reminders (
 	tz          TEXT,
 	active      BOOLEAN DEFAULT TRUE,
 	CONSTRAINT uniq_user_time UNIQUE (user_id, hour, minute, tz, message)
+);
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS extra_users TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS max_fires   INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS fire_count  INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS until_date  DATE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS last_fired  TIMESTAMPTZ;
+-- updated_at tracks changes to the reminder itself, not fire bookkeeping
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS created_at  TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS updated_at  TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS user_prefs (
+	user_id       TEXT PRIMARY KEY,
+	tz            TEXT,
+	weekly_digest BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS guild_id TEXT;
+
+CREATE TABLE IF NOT EXISTS guild_prefs (
+	guild_id TEXT PRIMARY KEY,
+	paused   BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS poll JSONB;
+ALTER TABLE guild_prefs ADD COLUMN IF NOT EXISTS tz TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS snoozes (
+	id          SERIAL PRIMARY KEY,
+	reminder_id INT NOT NULL REFERENCES reminders(id) ON DELETE CASCADE,
+	fire_at     TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS mode         TEXT NOT NULL DEFAULT 'daily';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS days         TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS month_day    INT  NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS interval_min INT  NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS cron_spec    TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_prefs ADD COLUMN IF NOT EXISTS greet_nickname BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- one webhook per channel, token encrypted with WEBHOOK_KEY
+CREATE TABLE IF NOT EXISTS webhooks (
+	channel_id TEXT PRIMARY KEY,
+	webhook_id TEXT NOT NULL,
+	token      BYTEA NOT NULL
+);
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS webhook_name   TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS webhook_avatar TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS escalate_min INT NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS pending_acks (
+	message_id  TEXT PRIMARY KEY,
+	reminder_id INT NOT NULL REFERENCES reminders(id) ON DELETE CASCADE,
+	channel_id  TEXT NOT NULL,
+	due_at      TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS reply_chain     BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS last_message_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS min_gap_min     INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS silent          BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE guild_prefs ADD COLUMN IF NOT EXISTS reminder_channels TEXT[];
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'normal';
+ALTER TABLE guild_prefs ADD COLUMN IF NOT EXISTS fallback_channel TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS name TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_name ON reminders (user_id, lower(name)) WHERE active;
+ALTER TABLE user_prefs ADD COLUMN IF NOT EXISTS calendar_token TEXT UNIQUE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS rrule TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_prefs ADD COLUMN IF NOT EXISTS clock_12h BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_prefs ADD COLUMN IF NOT EXISTS leaderboard_optout BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_prefs ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en';
+
+-- schedules an admin shared with the server, for /subscribe
+CREATE TABLE IF NOT EXISTS reminder_templates (
+	id           SERIAL PRIMARY KEY,
+	guild_id     TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	created_by   TEXT NOT NULL,
+	published    BOOLEAN NOT NULL DEFAULT TRUE,
+	message      TEXT NOT NULL,
+	hour         INT NOT NULL,
+	minute       INT NOT NULL,
+	tz           TEXT NOT NULL,
+	mode         TEXT NOT NULL,
+	days         TEXT NOT NULL DEFAULT '',
+	month_day    INT NOT NULL DEFAULT 0,
+	interval_min INT NOT NULL DEFAULT 0,
+	cron_spec    TEXT NOT NULL DEFAULT '',
+	rrule        TEXT NOT NULL DEFAULT '',
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS reminder_templates_name ON reminder_templates (guild_id, lower(name));
+ALTER TABLE reminder_templates ADD COLUMN IF NOT EXISTS window_start INT NOT NULL DEFAULT 0;
+ALTER TABLE reminder_templates ADD COLUMN IF NOT EXISTS window_end   INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS current_streak INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS best_streak    INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS last_ack_date  DATE;
+-- ✅ reactions are matched to the fire they're on
+CREATE INDEX IF NOT EXISTS reminders_last_message ON reminders (last_message_id);
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS boost_min   INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS boost_until TIMESTAMPTZ;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS mirror_channels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS raw_markdown    BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS lead_min        INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS delete_after_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS fetch_url       TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS fetch_field     TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS show_count      BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS week_interval   INT NOT NULL DEFAULT 1;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS anchor_date     DATE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS skip_next       BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS link_label      TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS link_url        TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS first_failure_at TIMESTAMPTZ;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS voice_only      BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS window_start    INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS window_end      INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS delivery        TEXT NOT NULL DEFAULT 'channel';
+
+-- one row per fire, for /last
+CREATE TABLE IF NOT EXISTS reminder_log (
+	id          BIGSERIAL PRIMARY KEY,
+	reminder_id INT NOT NULL REFERENCES reminders(id) ON DELETE CASCADE,
+	user_id     TEXT NOT NULL,
+	channel_id  TEXT NOT NULL,
+	message     TEXT NOT NULL,
+	success     BOOLEAN NOT NULL,
+	fired_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS reminder_log_user ON reminder_log (user_id, fired_at);
+CREATE INDEX IF NOT EXISTS reminder_log_reminder ON reminder_log (reminder_id);
+
+-- reminders offered with /gift, until the recipient answers
+CREATE TABLE IF NOT EXISTS gifts (
+	id         SERIAL PRIMARY KEY,
+	from_user  TEXT NOT NULL,
+	to_user    TEXT NOT NULL,
+	channel_id TEXT NOT NULL,
+	guild_id   TEXT NOT NULL,
+	message    TEXT NOT NULL,
+	hour       INT NOT NULL,
+	minute     INT NOT NULL,
+	tz         TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );`
+
+// reminderColumns is the SELECT list scanReminder expects.
+const reminderColumns = `id,user_id,channel_id,message,hour,minute,tz,active,
+	extra_users,max_fires,fire_count,until_date,last_fired,created_at,updated_at,
+	COALESCE(guild_id,''),poll,consecutive_failures,
+	mode,days,month_day,interval_min,cron_spec,webhook_name,webhook_avatar,
+	escalate_min,reply_chain,last_message_id,min_gap_min,silent,
+	priority,COALESCE(name,''),rrule,boost_min,boost_until,
+	mirror_channels,raw_markdown,lead_min,delete_after_seconds,fetch_url,fetch_field,
+	show_count,week_interval,anchor_date,link_label,link_url,voice_only,
+	window_start,window_end,delivery`
+
+func scanReminder(row pgx.Row, r *Reminder) error {
+	return row.Scan(reminderDest(r)...)
+}
+
+// reminderDest lists r's fields in reminderColumns order.
+func reminderDest(r *Reminder) []any {
+	return []any{&r.ID, &r.UserID, &r.ChannelID, &r.Message, &r.Hour, &r.Min,
+		&r.TZ, &r.Active, &r.Extra, &r.MaxFires, &r.FireCount, &r.Until, &r.LastFired,
+		&r.CreatedAt, &r.UpdatedAt, &r.GuildID, &r.Poll, &r.Failures,
+		&r.Mode, &r.Days, &r.MonthDay, &r.IntervalMin, &r.CronSpec,
+		&r.WebhookName, &r.WebhookAvatar, &r.EscalateMin,
+		&r.ReplyChain, &r.LastMessageID, &r.MinGapMin, &r.Silent,
+		&r.Priority, &r.Name, &r.RRule, &r.BoostMin, &r.BoostUntil,
+		&r.Mirrors, &r.RawMarkdown, &r.LeadMin, &r.DeleteAfter,
+		&r.FetchURL, &r.FetchField, &r.ShowCount, &r.EveryWeeks, &r.Anchor,
+		&r.LinkLabel, &r.LinkURL, &r.VoiceOnly, &r.WindowStart, &r.WindowEnd,
+		&r.Delivery}
+}
@@ -0,0 +1,1130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+)
+
+// testDB connects to TEST_DATABASE_URL with the schema applied, or skips
+// the test when it isn't set. Tests clean up the rows they add.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	if _, err := db.Exec(context.Background(), schema); err != nil {
+		t.Fatalf("apply schema: %v", err)
+	}
+	return db
+}
+
+func TestSchemaApplies(t *testing.T) {
+	db := testDB(t)
+	// twice, as every restart does
+	if _, err := db.Exec(context.Background(), schema); err != nil {
+		t.Fatalf("reapply schema: %v", err)
+	}
+}
+
+func TestScheduleOneRejectsInvalidSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Reminder
+	}{
+		{"cron", Reminder{ID: 9001, Mode: modeCron, CronSpec: "not a spec"}},
+		{"weekly without days", Reminder{ID: 9002, Mode: modeWeekly}},
+		{"monthly day 32", Reminder{ID: 9003, Mode: modeMonthly, MonthDay: 32}},
+		{"interval 0", Reminder{ID: 9004, Mode: modeInterval}},
+		{"rrule", Reminder{ID: 9005, Mode: modeRRule, RRule: "FREQ=SOMETIMES"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.r.TZ, tt.r.Active = "UTC", true
+			if err := scheduleOne(nil, realClock{}, tt.r, &discordgo.Session{}, time.UTC); err == nil {
+				t.Fatal("scheduleOne accepted an invalid schedule")
+			}
+			cronsMu.Lock()
+			_, ok := crons[tt.r.ID]
+			cronsMu.Unlock()
+			if ok {
+				unschedule(tt.r.ID)
+				t.Error("a runner was left behind for the invalid schedule")
+			}
+		})
+	}
+}
+
+func TestScheduleOneRefusesInactive(t *testing.T) {
+	r := Reminder{ID: 9010, TZ: "UTC", Hour: 9}
+	if err := scheduleOne(nil, realClock{}, r, &discordgo.Session{}, time.UTC); err != errInactive {
+		t.Fatalf("err = %v, want errInactive", err)
+	}
+}
+
+func TestInactiveLeavesRunnerAlone(t *testing.T) {
+	s, _ := newFakeDiscord(nil)
+	const id = 2147483004
+	t.Cleanup(func() { unschedule(id) })
+	r := Reminder{ID: id, Hour: 9, TZ: "UTC", Active: true}
+	if err := reschedule(nil, realClock{}, s, r); err != nil {
+		t.Fatal(err)
+	}
+	cronsMu.Lock()
+	before, entry := crons[id], cronEntries[id]
+	cronsMu.Unlock()
+	if entry == 0 {
+		t.Fatal("scheduling didn't record a cron entry ID")
+	}
+
+	r.Active, r.Hour = false, 10
+	if err := reschedule(nil, realClock{}, s, r); err != errInactive {
+		t.Fatalf("err = %v, want errInactive", err)
+	}
+	cronsMu.Lock()
+	after, entryAfter := crons[id], cronEntries[id]
+	cronsMu.Unlock()
+	if after != before || entryAfter != entry {
+		t.Error("an inactive reminder replaced the running schedule")
+	}
+}
+
+func TestScheduledEntryIsTheFire(t *testing.T) {
+	s, _ := newFakeDiscord(nil)
+	const id = 2147483003
+	t.Cleanup(func() { unschedule(id) })
+	if err := reschedule(nil, realClock{}, s, Reminder{ID: id, Hour: 9, TZ: "UTC", Active: true, LeadMin: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	cronsMu.Lock()
+	c, entry := crons[id], cronEntries[id]
+	cronsMu.Unlock()
+	e := c.Entry(entry)
+	if !e.Valid() {
+		t.Fatalf("stored entry %d isn't in the runner", entry)
+	}
+	from := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	if got := e.Schedule.Next(from).Format("15:04"); got != "09:00" {
+		t.Errorf("stored entry fires at %s, want the 09:00 fire rather than the heads-up", got)
+	}
+	next, ok := scheduledNext(id)
+	if !ok || !next.Equal(e.Next) {
+		t.Errorf("scheduledNext = %v, %v; want %v", next, ok, e.Next)
+	}
+
+	unschedule(id)
+	if _, ok := scheduledNext(id); ok {
+		t.Error("scheduledNext still answers after unschedule")
+	}
+	cronsMu.Lock()
+	_, left := cronEntries[id]
+	cronsMu.Unlock()
+	if left {
+		t.Error("unschedule left the entry ID behind")
+	}
+}
+
+func TestGuildOnlyCoversEveryCommand(t *testing.T) {
+	guildOnly(commands)
+	for _, cmd := range commands {
+		if cmd.DMPermission == nil || *cmd.DMPermission {
+			t.Errorf("/%s can be used in DMs", cmd.Name)
+		}
+		if cmd.Contexts == nil || len(*cmd.Contexts) != 1 || (*cmd.Contexts)[0] != discordgo.InteractionContextGuild {
+			t.Errorf("/%s contexts = %v, want guild only", cmd.Name, cmd.Contexts)
+		}
+	}
+}
+
+func TestAdminCommandsHidden(t *testing.T) {
+	admin := map[string]bool{
+		"publish": true, "unpublish": true, "setguildtz": true, "upcoming": true,
+		"setreminderchannels": true, "setfallback": true, "greeting": true, "transfer": true,
+		"reload": true, "capacity": true, "globalpause": true, "globalresume": true,
+	}
+	for _, cmd := range commands {
+		p := cmd.DefaultMemberPermissions
+		switch {
+		case admin[cmd.Name] && (p == nil || *p != discordgo.PermissionManageServer):
+			t.Errorf("/%s isn't limited to Manage Server", cmd.Name)
+		case !admin[cmd.Name] && p != nil:
+			t.Errorf("/%s is hidden from members behind permissions %d", cmd.Name, *p)
+		}
+		if got := strings.HasSuffix(cmd.Description, "(admin)"); got != admin[cmd.Name] {
+			t.Errorf("/%s: description %q doesn't match its permissions", cmd.Name, cmd.Description)
+		}
+		delete(admin, cmd.Name)
+	}
+	for name := range admin {
+		t.Errorf("no /%s command", name)
+	}
+}
+
+func TestLimitReached(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Paris")
+	until := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)
+	onUntil := time.Date(2026, 6, 10, 23, 30, 0, 0, loc)
+	dayAfter := time.Date(2026, 6, 11, 0, 30, 0, 0, loc)
+	before := time.Date(2026, 6, 1, 9, 0, 0, 0, loc)
+	tests := []struct {
+		name string
+		r    Reminder
+		now  time.Time
+		want bool
+	}{
+		{"neither set", Reminder{FireCount: 500}, dayAfter, false},
+		{"neither reached", Reminder{MaxFires: 5, FireCount: 4, Until: &until}, before, false},
+		{"max fires first", Reminder{MaxFires: 5, FireCount: 5, Until: &until}, before, true},
+		{"until first", Reminder{MaxFires: 50, FireCount: 3, Until: &until}, dayAfter, true},
+		{"until day still fires", Reminder{Until: &until}, onUntil, false},
+		{"max fires only", Reminder{MaxFires: 1, FireCount: 1}, before, true},
+		{"until only", Reminder{Until: &until}, dayAfter, true},
+	}
+	for _, tt := range tests {
+		if got := limitReached(tt.r, tt.now); got != tt.want {
+			t.Errorf("%s: limitReached = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransferReminder(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id LIKE 'test-transfer-%'`)
+	})
+
+	var id int
+	var before time.Time
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, message, hour, minute, tz, active, extra_users)
+		 VALUES ('test-transfer-old', 'c1', 'backups', 3, 0, 'UTC', true, '{test-transfer-new,test-transfer-x}')
+		 RETURNING id, updated_at`).Scan(&id, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := transferReminder(ctx, db, id, "test-transfer-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.UserID != "test-transfer-new" {
+		t.Errorf("owner = %q, want test-transfer-new", r.UserID)
+	}
+	if !slices.Equal(r.Extra, []string{"test-transfer-x"}) {
+		t.Errorf("extras = %v, want the new owner dropped", r.Extra)
+	}
+	if !r.UpdatedAt.After(before) {
+		t.Error("updated_at didn't move, so reconcile wouldn't see the change")
+	}
+
+	// rescheduling picks up the transferred row's version
+	if err := reschedule(nil, realClock{}, &discordgo.Session{}, r); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unschedule(id) })
+	cronsMu.Lock()
+	v := cronVersions[id]
+	cronsMu.Unlock()
+	if !v.Equal(r.UpdatedAt) {
+		t.Errorf("scheduled version %s, want %s", v, r.UpdatedAt)
+	}
+}
+
+func TestMissedFireWindow(t *testing.T) {
+	last := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	missed := time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+	r := Reminder{ID: 1, TZ: "UTC", Hour: 9, LastFired: &last}
+	window := time.Hour
+	tests := []struct {
+		name string
+		now  time.Time
+		ok   bool
+	}{
+		{"not due yet", missed.Add(-time.Minute), false},
+		{"just missed", missed.Add(time.Minute), true},
+		{"at the window's edge", missed.Add(window), true},
+		{"past the window", missed.Add(window + time.Second), false},
+	}
+	for _, tt := range tests {
+		got, ok := missedFire(r, tt.now, window)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %t, want %t", tt.name, ok, tt.ok)
+		}
+		if ok && !got.Equal(missed) {
+			t.Errorf("%s: missed fire = %s, want %s", tt.name, got, missed)
+		}
+	}
+
+	created := missed.Add(-12 * time.Hour)
+	fresh := Reminder{ID: 2, TZ: "UTC", Hour: 9, CreatedAt: created, UpdatedAt: created}
+	if got, ok := missedFire(fresh, missed.Add(time.Minute), window); !ok || !got.Equal(missed) {
+		t.Errorf("never-fired reminder: missed = %s, %t, want %s", got, ok, missed)
+	}
+	once := Reminder{ID: 3, Mode: modeOnce, TZ: "UTC", Hour: 9, Until: &missed, CreatedAt: created, UpdatedAt: created}
+	if _, ok := missedFire(once, missed.Add(time.Minute), window); !ok {
+		t.Error("a one-shot due during downtime wasn't caught up")
+	}
+	edited := r
+	edited.UpdatedAt = missed.Add(30 * time.Second)
+	if _, ok := missedFire(edited, missed.Add(time.Minute), window); ok {
+		t.Error("caught up a fire from before the reminder was last changed")
+	}
+	if _, ok := missedFire(r, missed.Add(time.Minute), 0); ok {
+		t.Error("catch-up ran with the window off")
+	}
+}
+
+func TestChannelReminderCount(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE channel_id LIKE 'test-count-%'`)
+	})
+	for _, row := range []struct {
+		user, msg, channel string
+		active             bool
+	}{
+		{"u1", "a", "test-count-1", true},
+		{"u2", "b", "test-count-1", true},
+		{"u3", "c", "test-count-1", false}, // stopped ones don't count
+		{"u1", "d", "test-count-2", true},  // nor other channels
+	} {
+		if _, err := db.Exec(ctx,
+			`INSERT INTO reminders (user_id, channel_id, message, hour, minute, tz, active)
+			 VALUES ($1, $2, $3, 9, 0, 'UTC', $4)`, row.user, row.channel, row.msg, row.active); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := channelReminderCount(ctx, db, "test-count-1", "u9", 9, 0, "UTC", "new")
+	if err != nil || n != 2 {
+		t.Errorf("count for a new reminder = %d, %v; want 2", n, err)
+	}
+	// re-creating an existing one only reactivates it, so it isn't counted
+	n, err = channelReminderCount(ctx, db, "test-count-1", "u1", 9, 0, "UTC", "a")
+	if err != nil || n != 1 {
+		t.Errorf("count for an upsert = %d, %v; want 1", n, err)
+	}
+}
+
+func TestScheduleOneConcurrentlyLeavesOneRunner(t *testing.T) {
+	r := Reminder{ID: 9020, TZ: "UTC", Hour: 9, Active: true}
+	t.Cleanup(func() { unschedule(r.ID) })
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := scheduleOne(nil, realClock{}, r, &discordgo.Session{}, time.UTC); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+	if got := len(crons[r.ID].Entries()); got != 1 {
+		t.Errorf("the runner has %d entries, want 1", got)
+	}
+}
+
+func TestRestoreJobsTwice(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var ids []int
+	t.Cleanup(func() {
+		for _, id := range ids {
+			unschedule(id)
+		}
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-restore'`)
+	})
+	for _, msg := range []string{"water", "stretch"} {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+			 VALUES ('test-restore', 'c1', 'g1', $1, 9, 0, 'UTC', true) RETURNING id`, msg).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	s, _ := newFakeDiscord(nil)
+	first := restoreJobs(ctx, db, realClock{}, s)
+	cronsMu.Lock()
+	runners := make(map[int]*cron.Cron)
+	for _, id := range ids {
+		runners[id] = crons[id]
+	}
+	cronsMu.Unlock()
+
+	if second := restoreJobs(ctx, db, realClock{}, s); second != first {
+		t.Errorf("restored %d reminders the second time, %d the first", second, first)
+	}
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+	for _, id := range ids {
+		c := crons[id]
+		if c == nil || len(c.Entries()) != 1 {
+			t.Errorf("reminder %d doesn't have exactly one scheduled fire", id)
+			continue
+		}
+		if c == runners[id] {
+			t.Errorf("reminder %d kept its first runner instead of replacing it", id)
+		}
+	}
+}
+
+// unreachableDB is a pool that never connects: pgxpool dials lazily, so
+// only a query that gets past its context would notice.
+func unreachableDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	db, err := pgxpool.New(context.Background(), "postgres://nobody@127.0.0.1:1/none?connect_timeout=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestCancelledContextStopsDBWork(t *testing.T) {
+	db := unreachableDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if _, err := loadReminder(ctx, db, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("loadReminder = %v, want context.Canceled", err)
+	}
+	if _, err := transferReminder(ctx, db, 1, "u2"); err == nil {
+		t.Error("transferReminder succeeded with a cancelled context")
+	}
+	if n := restoreJobs(ctx, db, realClock{}, &discordgo.Session{}); n != 0 {
+		t.Errorf("restoreJobs restored %d reminders with a cancelled context", n)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("cancelled calls took %s", d)
+	}
+}
+
+func TestDBCtxHasDeadline(t *testing.T) {
+	old := dbTimeout
+	t.Cleanup(func() { dbTimeout = old })
+	dbTimeout = time.Minute
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("deadline %s, %t; want within dbTimeout", deadline, ok)
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	for _, tt := range []struct {
+		v    string
+		want time.Duration
+	}{
+		{"", 5 * time.Second},
+		{"30s", 30 * time.Second},
+		{"soon", 5 * time.Second},
+		{"-1s", 5 * time.Second},
+	} {
+		t.Setenv("TEST_DURATION", tt.v)
+		if got := envDuration("TEST_DURATION", 5*time.Second); got != tt.want {
+			t.Errorf("envDuration(%q) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+// slash is /name run by userID in channel c1 of guild g1, as onSlash
+// hands it to a handler. Options are given as name, value pairs.
+func slash(name, userID string, opts ...any) *discordgo.InteractionCreate {
+	data := discordgo.ApplicationCommandInteractionData{Name: name}
+	for i := 0; i+1 < len(opts); i += 2 {
+		o := &discordgo.ApplicationCommandInteractionDataOption{Name: opts[i].(string), Value: opts[i+1]}
+		switch v := opts[i+1].(type) {
+		case string:
+			o.Type = discordgo.ApplicationCommandOptionString
+		case bool:
+			o.Type = discordgo.ApplicationCommandOptionBoolean
+		case int:
+			o.Type, o.Value = discordgo.ApplicationCommandOptionInteger, float64(v)
+		case *discordgo.Channel:
+			o.Type, o.Value = discordgo.ApplicationCommandOptionChannel, v.ID
+		}
+		data.Options = append(data.Options, o)
+	}
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		AppID:     "app",
+		Token:     "tok",
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:      data,
+	}}
+}
+
+// press is userID pressing the button customID on a message in channel
+// c1 of guild g1.
+func press(customID, userID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "press-" + customID,
+		Type:      discordgo.InteractionMessageComponent,
+		AppID:     "app",
+		Token:     "tok",
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Message:   &discordgo.Message{ID: "m1", ChannelID: "c1"},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: customID},
+	}}
+}
+
+// callback is an interaction answered directly rather than by editing a
+// deferred reply.
+type callback struct {
+	Type discordgo.InteractionResponseType `json:"type"`
+	Data struct {
+		Content    string                 `json:"content"`
+		Flags      discordgo.MessageFlags `json:"flags"`
+		Components []json.RawMessage      `json:"components"`
+	} `json:"data"`
+}
+
+// callbacks are the bot's direct answers to interactions, in order.
+func (f *fakeDiscord) callbacks(t *testing.T) []callback {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []callback
+	for _, c := range f.calls {
+		if !strings.HasPrefix(c.Path, "/interactions/") {
+			continue
+		}
+		var cb callback
+		if err := json.Unmarshal(c.Body, &cb); err != nil {
+			t.Fatalf("decode callback: %v", err)
+		}
+		out = append(out, cb)
+	}
+	return out
+}
+
+// replies are the contents the bot put in its deferred replies.
+func (f *fakeDiscord) replies(t *testing.T) []string {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, c := range f.calls {
+		if !strings.HasPrefix(c.Path, "/webhooks/") {
+			continue
+		}
+		var m struct{ Content string }
+		if err := json.Unmarshal(c.Body, &m); err != nil {
+			t.Fatalf("decode reply: %v", err)
+		}
+		out = append(out, m.Content)
+	}
+	return out
+}
+
+func TestRemindMeNeedsTimeAndMessage(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleRemindMe(context.Background(), nil, realClock{}, s, slash("remindme", "u1", "message", "water"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "Both time and message are required." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestRemindMeDefaults(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		var ids []int
+		rows, _ := db.Query(ctx, `DELETE FROM reminders WHERE user_id LIKE 'test-remindme-%' RETURNING id`)
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			ids = append(ids, id)
+		}
+		rows.Close()
+		for _, id := range ids {
+			unschedule(id)
+		}
+		db.Exec(ctx, `DELETE FROM user_prefs WHERE user_id LIKE 'test-remindme-%'`)
+		db.Exec(ctx, `DELETE FROM guild_prefs WHERE guild_id = 'g1'`)
+	})
+	if _, err := db.Exec(ctx, `INSERT INTO guild_prefs (guild_id, tz) VALUES ('g1', 'Asia/Tokyo')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `INSERT INTO user_prefs (user_id, tz) VALUES ('test-remindme-b', 'America/Toronto')`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user string
+		opts []any
+		tz   string
+	}{
+		{"test-remindme-a", nil, "Asia/Tokyo"},                                 // the server's default
+		{"test-remindme-b", nil, "America/Toronto"},                            // the user's beats it
+		{"test-remindme-c", []any{"timezone", "Europe/Paris"}, "Europe/Paris"}, // given explicitly
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(nil)
+		opts := append([]any{"time", "08:15", "message", "water"}, tt.opts...)
+		handleRemindMe(ctx, db, realClock{}, s, slash("remindme", tt.user, opts...))
+
+		var tz, channel string
+		var hour, min int
+		if err := db.QueryRow(ctx,
+			`SELECT tz, channel_id, hour, minute FROM reminders WHERE user_id=$1`, tt.user).Scan(&tz, &channel, &hour, &min); err != nil {
+			t.Fatalf("%s: no reminder saved (replies %q): %v", tt.user, f.replies(t), err)
+		}
+		if tz != tt.tz || channel != "c1" || hour != 8 || min != 15 {
+			t.Errorf("%s: saved %s in %s at %02d:%02d; want %s in c1 at 08:15", tt.user, tz, channel, hour, min, tt.tz)
+		}
+	}
+}
+
+func TestFailedTooLong(t *testing.T) {
+	oldMax, oldGrace := maxSendFailures, failureGrace
+	t.Cleanup(func() { maxSendFailures, failureGrace = oldMax, oldGrace })
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+
+	maxSendFailures, failureGrace = 3, 0
+	for failures, want := range []bool{false, false, false, true, true} {
+		if got := failedTooLong(failures, ago(time.Hour), now); got != want {
+			t.Errorf("by count: %d failures = %t, want %t", failures, got, want)
+		}
+	}
+
+	failureGrace = 24 * time.Hour
+	tests := []struct {
+		failures int
+		since    *time.Time
+		want     bool
+	}{
+		{10, ago(23 * time.Hour), false}, // many fires, but a short outage
+		{1, ago(24 * time.Hour), true},
+		{0, ago(48 * time.Hour), false},
+		{2, nil, false},
+	}
+	for _, tt := range tests {
+		if got := failedTooLong(tt.failures, tt.since, now); got != tt.want {
+			t.Errorf("by grace: %d failures since %v = %t, want %t", tt.failures, tt.since, got, tt.want)
+		}
+	}
+}
+
+func TestRepeatedFailuresDisable(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	oldMax, oldGrace := maxSendFailures, failureGrace
+	t.Cleanup(func() {
+		maxSendFailures, failureGrace = oldMax, oldGrace
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-failing'`)
+	})
+	maxSendFailures, failureGrace = 3, 0
+	fc := newFakeClock(time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC))
+
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-failing', 'c-locked', 'g-failing', 'standup', 9, 0, 'UTC', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Path == "/channels/c-locked/messages" {
+			return http.StatusForbidden, discordError(discordgo.ErrCodeMissingAccess, "Missing Access")
+		}
+		return 0, nil
+	})
+
+	active := func() bool {
+		var a bool
+		if err := db.QueryRow(ctx, `SELECT active FROM reminders WHERE id=$1`, id).Scan(&a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	for fire := 1; fire <= 3; fire++ {
+		r, err := loadReminder(ctx, db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fireReminder(db, fc, s, r, time.UTC)
+		if want := fire < 3; active() != want {
+			t.Fatalf("after %d failed fires active = %t, want %t", fire, !want, want)
+		}
+		fc.Advance(24 * time.Hour)
+	}
+
+	var warned bool
+	for _, p := range f.posts(t) {
+		if p.ChannelID == "dm-test-failing" && strings.Contains(p.Content, "I turned off your reminder") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("the owner wasn't told by DM")
+	}
+}
+
+func TestFailureGrace(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	oldMax, oldGrace := maxSendFailures, failureGrace
+	t.Cleanup(func() {
+		maxSendFailures, failureGrace = oldMax, oldGrace
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-grace'`)
+	})
+	maxSendFailures, failureGrace = 2, 48*time.Hour
+	fc := newFakeClock(time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC))
+
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-grace', 'c-flaky', 'g-grace', 'standup', 9, 0, 'UTC', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	failing := true
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		if failing && c.Path == "/channels/c-flaky/messages" {
+			return http.StatusForbidden, discordError(discordgo.ErrCodeMissingAccess, "Missing Access")
+		}
+		return 0, nil
+	})
+	fire := func(fail bool) (active bool, since *time.Time) {
+		failing = fail
+		r, err := loadReminder(ctx, db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fireReminder(db, fc, s, r, time.UTC)
+		if err := db.QueryRow(ctx, `SELECT active, first_failure_at FROM reminders WHERE id=$1`, id).Scan(&active, &since); err != nil {
+			t.Fatal(err)
+		}
+		fc.Advance(24 * time.Hour)
+		return active, since
+	}
+
+	// past maxSendFailures, but only a day into the outage
+	fire(true)
+	if active, since := fire(true); !active || since == nil || !since.Equal(time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("after two failed fires: active %t, failing since %v", active, since)
+	}
+	if active, since := fire(false); !active || since != nil {
+		t.Fatalf("a good send left active %t, failing since %v", active, since)
+	}
+
+	// the streak starts over from the failure after the good send
+	fire(true)
+	if active, _ := fire(true); !active {
+		t.Fatal("switched off a day into the new streak")
+	}
+	if active, _ := fire(true); active {
+		t.Error("still on after failing for the whole grace period")
+	}
+}
+
+// postingState is a session whose state has server g1, where everyone may
+// post in c1 and c2 but only admins in c-announce, and channel c-other in
+// another server.
+func postingState(t *testing.T) (*discordgo.Session, *fakeDiscord) {
+	t.Helper()
+	s, f := newFakeDiscord(nil)
+	s.State.User = &discordgo.User{ID: "bot"}
+	const post = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(s.State.GuildAdd(&discordgo.Guild{ID: "g1", OwnerID: "owner",
+		Roles: []*discordgo.Role{{ID: "g1", Permissions: post}}}))
+	must(s.State.GuildAdd(&discordgo.Guild{ID: "g2"}))
+	for _, id := range []string{"u1", "bot"} {
+		must(s.State.MemberAdd(&discordgo.Member{GuildID: "g1", User: &discordgo.User{ID: id}}))
+	}
+	for _, ch := range []*discordgo.Channel{
+		{ID: "c1", GuildID: "g1"},
+		{ID: "c2", GuildID: "g1"},
+		{ID: "c-announce", GuildID: "g1", PermissionOverwrites: []*discordgo.PermissionOverwrite{
+			{ID: "g1", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionSendMessages}}},
+		{ID: "c-other", GuildID: "g2"},
+	} {
+		must(s.State.ChannelAdd(ch))
+	}
+	return s, f
+}
+
+func TestReadRemindInputChannel(t *testing.T) {
+	in := readRemindInput(slash("remind", "u1", "time", "09:00", "channel", &discordgo.Channel{ID: "c2"}))
+	if in.ChannelID != "c2" {
+		t.Errorf("channel = %q, want c2", in.ChannelID)
+	}
+	if in := readRemindInput(slash("remind", "u1", "time", "09:00")); in.ChannelID != "" {
+		t.Errorf("channel = %q with the option left out", in.ChannelID)
+	}
+}
+
+func TestCheckPostChannel(t *testing.T) {
+	s, _ := postingState(t)
+	tests := []struct {
+		channel, want string
+	}{
+		{"c2", ""},
+		{"c-other", "That channel isn't in this server."},
+		{"c-announce", "You can't post in <#c-announce>."},
+	}
+	for _, tt := range tests {
+		if got := checkPostChannel(s, slash("remind", "u1"), tt.channel); got != tt.want {
+			t.Errorf("checkPostChannel(%s) = %q, want %q", tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestRemindRefusesForeignChannel(t *testing.T) {
+	s, f := postingState(t)
+	ic := slash("remind", "u1", "time", "09:00", "timezone", "UTC", "message", "hi", "channel", &discordgo.Channel{ID: "c-other"})
+	handleRemind(context.Background(), nil, realClock{}, s, ic)
+	if got := f.replies(t); len(got) != 1 || got[0] != "That channel isn't in this server." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestRemindKeepsOtherSchedule(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-upsert'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, fire_count)
+		 VALUES ('test-upsert', 'c1', 'g1', 'water', 9, 0, 'UTC', true, 7) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, in := range []remindInput{
+		{Time: "09:00", TZ: "UTC", Message: "water", MonthDay: "15"},
+		{Time: "09:00", TZ: "UTC", Message: "water", MaxFires: 1},
+	} {
+		s, f := newFakeDiscord(nil)
+		createReminder(ctx, db, realClock{}, s, slash("remind", "test-upsert"), in)
+		want := fmt.Sprintf("You already have reminder %d with this message at this time, on a different schedule. ", id)
+		if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], want) {
+			t.Errorf("%+v: replies = %q", in, got)
+		}
+	}
+	if r, err := loadReminder(ctx, db, id); err != nil || r.Mode != modeDaily || r.MaxFires != 0 || r.FireCount != 7 {
+		t.Fatalf("daily reminder after the refusals: %+v, %v", r, err)
+	}
+
+	// once it's off, the same message and time can be set up afresh
+	if _, err := db.Exec(ctx, `UPDATE reminders SET active = false WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+	s, f := newFakeDiscord(nil)
+	createReminder(ctx, db, realClock{}, s, slash("remind", "test-upsert"), remindInput{Time: "09:00", TZ: "UTC", Message: "water", MonthDay: "15"})
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], "Got it!") {
+		t.Errorf("replies = %q", got)
+	}
+	if r, err := loadReminder(ctx, db, id); err != nil || r.Mode != modeMonthly || !r.Active {
+		t.Errorf("reminder after re-creating it: %+v, %v", r, err)
+	}
+}
+
+func TestRemindScheduleOptionsRejected(t *testing.T) {
+	tests := []struct {
+		opts []any
+		want string
+	}{
+		{[]any{"every", 90, "cron", "0 9 * * *"}, "Use only one of monthday, rrule, every and cron."},
+		{[]any{"monthday", "15", "rrule", "FREQ=DAILY"}, "Use only one of monthday, rrule, every and cron."},
+		{[]any{"every", 2}, "Every must be between 5 and 1440 minutes."},
+		{[]any{"cron", "every tuesday"}, "That cron spec doesn't work: "},
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(nil)
+		opts := append([]any{"time", "09:00", "timezone", "UTC", "message", "hi"}, tt.opts...)
+		handleRemind(context.Background(), nil, realClock{}, s, slash("remind", "u1", opts...))
+		if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], tt.want) {
+			t.Errorf("/remind %v: replies = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestOnSlashUnknownCommand(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s, f := newFakeDiscord(nil)
+	onSlash(nil, realClock{})(s, slash("frobnicate", "u1"))
+	want := "Unknown command. It may have been removed; try again in a little while."
+	if got := f.replies(t); len(got) != 1 || got[0] != want {
+		t.Errorf("replies = %q, want %q", got, want)
+	}
+	if !strings.Contains(logs.String(), "unhandled command /frobnicate") {
+		t.Errorf("log = %q, want the unhandled command named", logs.String())
+	}
+}
+
+func TestLogUnknownOptions(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	logUnknownOptions(slash("remind", "u1", "time", "09:00", "colour", "green").ApplicationCommandData())
+	if got := logs.String(); !strings.Contains(got, `/remind: unexpected option "colour"`) || strings.Contains(got, `"time"`) {
+		t.Errorf("log = %q, want only the colour option reported", got)
+	}
+}
+
+func TestOnSlashOutsideServer(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	ic := slash("list", "u1")
+	ic.Member, ic.GuildID, ic.User = nil, "", &discordgo.User{ID: "u1"}
+	onSlash(nil, realClock{})(s, ic)
+	var told bool
+	for _, c := range f.calls {
+		told = told || strings.Contains(string(c.Body), "My commands only work in a server.")
+	}
+	if !told || len(f.replies(t)) != 0 {
+		t.Error("a DM'd command wasn't turned away up front")
+	}
+}
+
+func TestOnSlashMissingOption(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	onSlash(nil, realClock{})(s, slash("remind", "u1", "time", "09:00", "timezone", "UTC"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "The message option is missing. Please run the command again." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestMissingOption(t *testing.T) {
+	data := func(name string, opts ...any) discordgo.ApplicationCommandInteractionData {
+		return slash(name, "u1", opts...).ApplicationCommandData()
+	}
+	for _, c := range []struct {
+		name string
+		data discordgo.ApplicationCommandInteractionData
+		want string
+	}{
+		{"all there", data("remind", "time", "09:00", "timezone", "UTC", "message", "hi", "silent", true), ""},
+		{"optional left out", data("remind", "time", "09:00", "timezone", "UTC", "message", "hi"), ""},
+		{"first missing wins", data("remind", "message", "hi"), "time"},
+		{"middle one", data("remind", "time", "09:00", "message", "hi"), "timezone"},
+		{"present without a value", data("timeformat", "format", nil), "format"},
+		{"no required options", data("list"), ""},
+		{"unknown command", data("nosuch"), ""},
+	} {
+		if got := missingOption(c.data); got != c.want {
+			t.Errorf("%s: missingOption = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	const (
+		badFormat = "Time must be HH:MM (24‑hour)."
+		badHour   = "Hour must be 00–23."
+		badMinute = "Minute must be 00–59."
+	)
+	tests := []struct {
+		in        string
+		hour, min int
+		err       string
+	}{
+		{"00:00", 0, 0, ""},
+		{"23:59", 23, 59, ""},
+		{"9:05", 9, 5, ""},
+		{" 07:30 ", 7, 30, ""},
+		{"24:00", 0, 0, badHour},
+		{"25:00", 0, 0, badHour},
+		{"23:60", 0, 0, badMinute},
+		{"12:99", 0, 0, badMinute},
+		{"99:99", 0, 0, badHour}, // the hour is named first
+		{"9:5", 0, 0, badFormat},
+		{"123:00", 0, 0, badFormat},
+		{"-1:00", 0, 0, badFormat},
+		{"9am", 0, 0, badFormat},
+		{"", 0, 0, badFormat},
+		{"١٢:٣٠", 0, 0, badFormat}, // non-ASCII digits
+	}
+	for _, tt := range tests {
+		hour, min, err := parseClock(tt.in)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if hour != tt.hour || min != tt.min || got != tt.err {
+			t.Errorf("parseClock(%q) = %d, %d, %q; want %d, %d, %q", tt.in, hour, min, got, tt.hour, tt.min, tt.err)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	status := func(code int, retryAfter string) error {
+		resp := &http.Response{StatusCode: code, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return &discordgo.RESTError{Response: resp}
+	}
+	tests := []struct {
+		name    string
+		err     error
+		attempt int
+		want    time.Duration
+		retry   bool
+	}{
+		{"429 with Retry-After", status(http.StatusTooManyRequests, "2.5"), 0, 2500 * time.Millisecond, true},
+		{"429 without", status(http.StatusTooManyRequests, ""), 2, 4 * time.Second, true},
+		{"server error backs off", status(http.StatusBadGateway, ""), 3, 8 * time.Second, true},
+		{"bad request isn't retried", status(http.StatusBadRequest, ""), 0, 0, false},
+		{"no response", errors.New("i/o timeout"), 1, 2 * time.Second, true},
+	}
+	for _, tt := range tests {
+		d, ok := retryDelay(tt.err, tt.attempt)
+		if d != tt.want || ok != tt.retry {
+			t.Errorf("%s: retryDelay = %s, %t; want %s, %t", tt.name, d, ok, tt.want, tt.retry)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var slept []time.Duration
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+	flaky := func(fails int) func() error {
+		return func() error {
+			if fails--; fails >= 0 {
+				return errors.New("connection reset")
+			}
+			return nil
+		}
+	}
+
+	if err := withRetry(5, sleep, flaky(2)); err != nil || !slices.Equal(slept, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("two failures: err %v, slept %v", err, slept)
+	}
+	slept = nil
+	if err := withRetry(3, sleep, flaky(5)); err == nil || len(slept) != 2 {
+		t.Errorf("out of attempts: err %v, slept %v; want an error after two waits", err, slept)
+	}
+}
+
+func TestEnsureCommandsRetriesRateLimits(t *testing.T) {
+	var puts int
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Method != http.MethodPut || c.Path != "/applications/app/commands" {
+			return 0, nil
+		}
+		if puts++; puts <= 2 {
+			return http.StatusTooManyRequests, map[string]any{"message": "You are being rate limited.", "retry_after": 0.01, "global": false}
+		}
+		return http.StatusOK, []map[string]any{}
+	})
+	s.State.User = &discordgo.User{ID: "app"}
+
+	if err := ensureCommands(s); err != nil {
+		t.Fatal(err)
+	}
+	if puts != 3 || len(f.calls) != 3 {
+		t.Fatalf("%d overwrites in %d calls, want one retried twice and nothing else", puts, len(f.calls))
+	}
+	var sent []struct{ Name string }
+	if err := json.Unmarshal(f.calls[2].Body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != len(commands) || sent[0].Name != commands[0].Name {
+		t.Errorf("overwrote with %d commands, want all %d", len(sent), len(commands))
+	}
+}
+
+func TestEnsureCommandsFailsLoudly(t *testing.T) {
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		return http.StatusBadRequest, discordError(50035, "Invalid Form Body")
+	})
+	s.State.User = &discordgo.User{ID: "app"}
+	err := ensureCommands(s)
+	if err == nil || !strings.Contains(err.Error(), "register commands") {
+		t.Errorf("err = %v, want the registration named", err)
+	}
+}
+
+func TestListOrder(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // a Wednesday
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	rs := []Reminder{
+		{ID: 1, Mode: modeDaily, Hour: 8, TZ: "UTC", CreatedAt: day(3)},              // tomorrow 08:00
+		{ID: 2, Mode: modeWeekly, Days: "3", Hour: 18, TZ: "UTC", CreatedAt: day(1)}, // today 18:00
+		{ID: 3, Mode: modeInterval, IntervalMin: 30, TZ: "UTC", CreatedAt: day(2)},   // 12:30
+		{ID: 4, Mode: modeCron, CronSpec: "not a spec", TZ: "UTC", CreatedAt: day(4)},
+		{ID: 5, Mode: modeDaily, Hour: 23, TZ: "Asia/Tokyo", CreatedAt: day(5)}, // 14:00 UTC
+	}
+	ids := func(fires []upcomingFire) []int {
+		var out []int
+		for _, f := range fires {
+			out = append(out, f.r.ID)
+		}
+		return out
+	}
+
+	for _, c := range []struct {
+		by   string
+		want []int
+	}{
+		{"", []int{3, 5, 2, 1}},
+		{"next", []int{3, 5, 2, 1}},
+		{"created", []int{2, 3, 1, 5}},
+		{"time", []int{1, 3, 2, 5}}, // Tokyo's 23:00 is read on its own clock
+	} {
+		if got := ids(listOrder(rs, now, c.by)); !slices.Equal(got, c.want) {
+			t.Errorf("by %q: %v, want %v", c.by, got, c.want)
+		}
+	}
+}
+
+func TestListOrderKeepsTiesStable(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	var rs []Reminder
+	for id := 1; id <= 4; id++ {
+		rs = append(rs, Reminder{ID: id, Mode: modeDaily, Hour: 9, TZ: "UTC"})
+	}
+	for i, f := range listOrder(rs, now, "") {
+		if f.r.ID != i+1 {
+			t.Fatalf("reminders firing together were reordered: position %d is %d", i, f.r.ID)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// remindAboutName is the message context-menu command's name, which is
+// also what Discord shows in the menu.
+const remindAboutName = "Remind me about this"
+
+// remindAboutQuote caps how much of the message is copied into the
+// reminder text.
+const remindAboutQuote = 1000
+
+// messageLink is the jump URL for a message.
+func messageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// quoteMessage is the default reminder text for m: its content as a block
+// quote, trimmed to remindAboutQuote, followed by a link back to it.
+func quoteMessage(guildID string, m *discordgo.Message) string {
+	content := strings.TrimSpace(m.Content)
+	if r := []rune(content); len(r) > remindAboutQuote {
+		content = string(r[:remindAboutQuote]) + "…"
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if content != "" {
+			b.WriteString("> " + line + "\n")
+		}
+	}
+	b.WriteString(messageLink(guildID, m.ChannelID, m.ID))
+	return b.String()
+}
+
+// remindAboutModal asks for the time of a reminder about the message the
+// context-menu command was run on, with the text prefilled.
+func remindAboutModal(ic *discordgo.InteractionCreate) *discordgo.InteractionResponse {
+	data := ic.ApplicationCommandData()
+	text := ""
+	if m := data.Resolved.Messages[data.TargetID]; m != nil {
+		text = quoteMessage(ic.GuildID, m)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "remindmsg",
+			Title:    "Remind me about this",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "time", Label: "Time (HH:MM)", Style: discordgo.TextInputShort,
+					Placeholder: "09:00", Required: true, MaxLength: 5,
+				}}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "timezone", Label: "Timezone (blank for your default)", Style: discordgo.TextInputShort,
+					Placeholder: "America/Toronto", MaxLength: 64,
+				}}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "message", Label: "Message", Style: discordgo.TextInputParagraph,
+					Value: text, Required: true, MaxLength: 1800,
+				}}},
+			},
+		},
+	}
+}
+
+// modalValues collects a submitted modal's text inputs by custom ID.
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	vals := map[string]string{}
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rc := range row.Components {
+			if in, ok := rc.(*discordgo.TextInput); ok {
+				vals[in.CustomID] = strings.TrimSpace(in.Value)
+			}
+		}
+	}
+	return vals
+}
+
+// remindFormModal is /remindform: the /remindme options as a dialog, with
+// room for a multi-line message.
+func remindFormModal(ic *discordgo.InteractionCreate) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "remindform",
+			Title:    "New daily reminder",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "time", Label: "Time (HH:MM)", Style: discordgo.TextInputShort,
+					Placeholder: "09:00", Required: true, MaxLength: 5,
+				}}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "timezone", Label: "Timezone (blank for your default)", Style: discordgo.TextInputShort,
+					Placeholder: "America/Toronto", MaxLength: 64,
+				}}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.TextInput{
+					CustomID: "message", Label: "Message", Style: discordgo.TextInputParagraph,
+					Required: true, MaxLength: 1800,
+				}}},
+			},
+		},
+	}
+}
+
+// modalCommands are answered with a modal instead of being deferred, since
+// a modal can't follow a deferral.
+var modalCommands = map[string]func(*discordgo.InteractionCreate) *discordgo.InteractionResponse{
+	remindAboutName: remindAboutModal,
+	"remindform":    remindFormModal,
+}
+
+// onModalSubmit creates the reminder from a submitted modal: "remindmsg"
+// fires once, at the next occurrence of the chosen time, and "remindform"
+// every day like /remindme.
+func onModalSubmit(db *pgxpool.Pool, clk Clock) func(*discordgo.Session, *discordgo.InteractionCreate) {
+	return func(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+		if ic.Type != discordgo.InteractionModalSubmit {
+			return
+		}
+		id := ic.ModalSubmitData().CustomID
+		if id != "remindmsg" && id != "remindform" {
+			return
+		}
+
+		var flags discordgo.MessageFlags
+		if ephemeralCommands[id] {
+			flags = discordgo.MessageFlagsEphemeral
+		}
+		if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: flags},
+		}); err != nil {
+			log.Printf("defer %s: %v", id, err)
+			return
+		}
+
+		ctx, cancel := dbCtx()
+		defer cancel()
+
+		vals := modalValues(ic.ModalSubmitData())
+		in := remindInput{Time: vals["time"], TZ: vals["timezone"], Message: vals["message"]}
+		if id == "remindmsg" {
+			in.MaxFires = 1
+		}
+		if in.TZ == "" {
+			tz, err := defaultTZ(ctx, db, ic.Member.User.ID, ic.GuildID)
+			if err != nil {
+				respondErr(s, ic, "looking up your timezone", err)
+				return
+			}
+			in.TZ = tz
+		}
+		createReminder(ctx, db, clk, s, ic, in)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// decodeInteraction parses an interaction the way discordgo does off the
+// gateway.
+func decodeInteraction(t *testing.T, raw string) *discordgo.InteractionCreate {
+	t.Helper()
+	var i discordgo.Interaction
+	if err := json.Unmarshal([]byte(raw), &i); err != nil {
+		t.Fatal(err)
+	}
+	return &discordgo.InteractionCreate{Interaction: &i}
+}
+
+func TestQuoteMessage(t *testing.T) {
+	m := &discordgo.Message{ID: "m1", ChannelID: "c1", Content: "  ship it\nby friday  "}
+	want := "> ship it\n> by friday\nhttps://discord.com/channels/g1/c1/m1"
+	if got := quoteMessage("g1", m); got != want {
+		t.Errorf("quoteMessage = %q, want %q", got, want)
+	}
+
+	// an image-only message is just the link
+	if got := quoteMessage("g1", &discordgo.Message{ID: "m2", ChannelID: "c1"}); got != "https://discord.com/channels/g1/c1/m2" {
+		t.Errorf("quoteMessage of an empty message = %q", got)
+	}
+
+	long := &discordgo.Message{ID: "m3", ChannelID: "c1", Content: strings.Repeat("é", remindAboutQuote+10)}
+	if got := quoteMessage("g1", long); !strings.HasPrefix(got, "> "+strings.Repeat("é", remindAboutQuote)+"…\n") {
+		t.Errorf("long message wasn't cut at %d runes", remindAboutQuote)
+	}
+}
+
+func TestRemindAboutModalPrefills(t *testing.T) {
+	ic := decodeInteraction(t, `{
+		"id": "i1", "type": 2, "guild_id": "g1", "channel_id": "c1",
+		"member": {"user": {"id": "u1"}},
+		"data": {
+			"id": "cmd", "name": "Remind me about this", "type": 3, "target_id": "m1",
+			"resolved": {"messages": {"m1": {"id": "m1", "channel_id": "c1", "content": "review the PR"}}}
+		}
+	}`)
+	resp := remindAboutModal(ic)
+	if resp.Type != discordgo.InteractionResponseModal || resp.Data.CustomID != "remindmsg" {
+		t.Fatalf("response = %+v, want the remindmsg modal", resp)
+	}
+	var message discordgo.TextInput
+	for _, row := range resp.Data.Components {
+		for _, c := range row.(discordgo.ActionsRow).Components {
+			if in := c.(discordgo.TextInput); in.CustomID == "message" {
+				message = in
+			}
+		}
+	}
+	if want := "> review the PR\nhttps://discord.com/channels/g1/c1/m1"; message.Value != want {
+		t.Errorf("message prefilled with %q, want %q", message.Value, want)
+	}
+}
+
+func TestModalValuesFromSubmit(t *testing.T) {
+	ic := decodeInteraction(t, `{
+		"id": "i2", "type": 5, "guild_id": "g1", "channel_id": "c1",
+		"member": {"user": {"id": "u1"}},
+		"data": {"custom_id": "remindmsg", "components": [
+			{"type": 1, "components": [{"type": 4, "custom_id": "time", "value": " 17:30 "}]},
+			{"type": 1, "components": [{"type": 4, "custom_id": "timezone", "value": ""}]},
+			{"type": 1, "components": [{"type": 4, "custom_id": "message", "value": "> review the PR\nhttps://discord.com/channels/g1/c1/m1"}]}
+		]}
+	}`)
+	vals := modalValues(ic.ModalSubmitData())
+	if vals["time"] != "17:30" || vals["timezone"] != "" || !strings.HasPrefix(vals["message"], "> review the PR\n") {
+		t.Errorf("values = %q", vals)
+	}
+}
+
+func TestRemindFormModal(t *testing.T) {
+	resp := remindFormModal(slash("remindform", "u1"))
+	if resp.Type != discordgo.InteractionResponseModal || resp.Data.CustomID != "remindform" {
+		t.Fatalf("response = %+v, want the remindform modal", resp)
+	}
+	var ids []string
+	for _, row := range resp.Data.Components {
+		for _, c := range row.(discordgo.ActionsRow).Components {
+			in := c.(discordgo.TextInput)
+			ids = append(ids, in.CustomID)
+			if in.CustomID == "message" && in.Style != discordgo.TextInputParagraph {
+				t.Error("the message input is single-line")
+			}
+		}
+	}
+	if strings.Join(ids, ",") != "time,timezone,message" {
+		t.Errorf("inputs = %v", ids)
+	}
+	if modalCommands["remindform"] == nil {
+		t.Error("/remindform isn't answered with its modal")
+	}
+}
+
+func TestRemindFormSubmitValidates(t *testing.T) {
+	ic := decodeInteraction(t, `{
+		"id": "i3", "type": 5, "guild_id": "g1", "channel_id": "c1",
+		"application_id": "app", "token": "tok",
+		"member": {"user": {"id": "u1"}},
+		"data": {"custom_id": "remindform", "components": [
+			{"type": 1, "components": [{"type": 4, "custom_id": "time", "value": "25:00"}]},
+			{"type": 1, "components": [{"type": 4, "custom_id": "timezone", "value": "UTC"}]},
+			{"type": 1, "components": [{"type": 4, "custom_id": "message", "value": "line one\nline two"}]}
+		]}
+	}`)
+	s, f := newFakeDiscord(nil)
+	onModalSubmit(nil, realClock{})(s, ic)
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], "Hour must be") {
+		t.Errorf("replies = %q, want the hour rejected", got)
+	}
+
+	// other modals are left to their own handlers
+	other := decodeInteraction(t, `{"id": "i4", "type": 5, "data": {"custom_id": "something-else", "components": []}}`)
+	s, f = newFakeDiscord(nil)
+	onModalSubmit(nil, realClock{})(s, other)
+	if len(f.calls) != 0 {
+		t.Errorf("answered another handler's modal: %+v", f.calls)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxNameLen caps reminder names so they stay easy to type.
+const maxNameLen = 32
+
+// checkName validates a reminder name. Names that are only digits are
+// refused, since they'd be read as IDs. The error is meant for the user.
+func checkName(name string) error {
+	if utf8.RuneCountInString(name) > maxNameLen {
+		return fmt.Errorf("Names can be at most %d characters.", maxNameLen)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return errors.New("A name can't be just a number, it would look like an ID.")
+	}
+	return nil
+}
+
+// resolveRef turns a reminder option, either an ID or the name of one of
+// the caller's active reminders, into an ID. Names match case-insensitively.
+// On failure it has already replied to the user.
+func resolveRef(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate, ref string) (int, bool) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, true
+	}
+
+	var id int
+	err := db.QueryRow(ctx,
+		`SELECT id FROM reminders WHERE user_id=$1 AND lower(name)=lower($2) AND active`,
+		ic.Member.User.ID, ref).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder called %q.", ref))
+		return 0, false
+	}
+	if err != nil {
+		respondErr(s, ic, "looking up that reminder", err)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"standup", true},
+		{"Stand-up 2", true},
+		{strings.Repeat("é", maxNameLen), true}, // counted in characters
+		{strings.Repeat("a", maxNameLen+1), false},
+		{"42", false},
+		{"-7", false},
+	}
+	for _, tt := range tests {
+		if err := checkName(tt.name); (err == nil) != tt.ok {
+			t.Errorf("checkName(%q) = %v, want ok %t", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestResolveRefByID(t *testing.T) {
+	// IDs never touch the database
+	id, ok := resolveRef(context.Background(), nil, nil, slash("stop", "u1"), " 42 ")
+	if !ok || id != 42 {
+		t.Errorf("resolveRef(42) = %d, %t", id, ok)
+	}
+}
+
+func TestResolveRefByName(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id LIKE 'test-names-%'`)
+	})
+	insert := func(user, msg, name string, active bool) int {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, name)
+			 VALUES ($1, 'c1', 'g1', $2, 9, 0, 'UTC', $3, $4) RETURNING id`, user, msg, active, name).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	mine := insert("test-names-me", "a", "Standup", true)
+	insert("test-names-me", "b", "old", false)
+	insert("test-names-other", "c", "standup", true)
+
+	tests := []struct {
+		ref   string
+		id    int
+		reply string
+	}{
+		{"standup", mine, ""},
+		{"STANDUP", mine, ""},
+		{"old", 0, `You don't have a reminder called "old".`},     // stopped
+		{"lunch", 0, `You don't have a reminder called "lunch".`}, // nobody's
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(nil)
+		id, ok := resolveRef(ctx, db, s, slash("stop", "test-names-me"), tt.ref)
+		if id != tt.id || ok != (tt.reply == "") {
+			t.Errorf("resolveRef(%q) = %d, %t; want %d", tt.ref, id, ok, tt.id)
+		}
+		if got := f.replies(t); tt.reply != "" && (len(got) != 1 || got[0] != tt.reply) {
+			t.Errorf("resolveRef(%q) replied %q, want %q", tt.ref, got, tt.reply)
+		}
+	}
+
+	// names are unique per user among active reminders, whatever the case
+	s, f := newFakeDiscord(nil)
+	handleRemind(ctx, db, realClock{}, s, slash("remind", "test-names-me", "time", "10:00", "timezone", "UTC", "message", "new", "name", "STANDUP"))
+	if got := f.replies(t); len(got) != 1 || got[0] != `You already have a reminder called "STANDUP".` {
+		t.Errorf("duplicate name: replies %q", got)
+	}
+	// but a stopped reminder's name is free again
+	s, f = newFakeDiscord(nil)
+	handleRemind(ctx, db, realClock{}, s, slash("remind", "test-names-me", "time", "10:00", "timezone", "UTC", "message", "newer", "name", "old"))
+	var id int
+	if err := db.QueryRow(ctx,
+		`SELECT id FROM reminders WHERE user_id = 'test-names-me' AND name = 'old' AND active`).Scan(&id); err != nil {
+		t.Errorf("reusing a stopped reminder's name failed: %v (replies %q)", err, f.replies(t))
+	}
+	unschedule(id)
+}
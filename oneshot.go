@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// minLead is how far ahead a one-shot must be. Anything closer can fire
+// before the interaction is even acknowledged, or race the scheduler.
+var minLead = 30 * time.Second
+
+// parseISOTime reads an RFC 3339 timestamp like 2025-06-01T18:30:00-04:00
+// and returns it in a location matching its offset: an Etc/GMT zone for
+// whole hours, otherwise UTC. The error is meant for the user.
+func parseISOTime(raw string) (time.Time, string, error) {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, "", errors.New("When must be an ISO 8601 time like 2025-06-01T18:30:00-04:00.")
+	}
+	tz := offsetZone(t)
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return t.In(loc), tz, nil
+}
+
+// offsetZone names a zone with t's UTC offset. Etc/GMT signs are
+// inverted, so -04:00 is Etc/GMT+4.
+func offsetZone(t time.Time) string {
+	_, off := t.Zone()
+	if off == 0 || off%3600 != 0 || off < -12*3600 || off > 14*3600 {
+		return "UTC"
+	}
+	return fmt.Sprintf("Etc/GMT%+d", -off/3600)
+}
+
+// checkOneShotTime says why a one-shot can't fire at at as of now, or ""
+// if it can. The reason is meant for the user.
+func checkOneShotTime(at, now time.Time) string {
+	switch {
+	case !at.After(now):
+		return "That time has already passed."
+	case at.Sub(now) < minLead:
+		return fmt.Sprintf("That's too soon. Pick a time at least %s from now.", minLead)
+	case at.Second() != 0:
+		return "Reminders fire on the minute, so leave the seconds at 00."
+	}
+	return ""
+}
+
+// handleRemindAt creates a reminder that fires once, at an exact ISO 8601
+// time.
+func handleRemindAt(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var when, message string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "when":
+			when = opt.StringValue() // "2025-06-01T18:30:00-04:00"
+		case "message":
+			message = opt.StringValue()
+		}
+	}
+
+	at, tz, err := parseISOTime(when)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	if msg := checkOneShotTime(at, clk.Now()); msg != "" {
+		respond(s, ic, msg)
+		return
+	}
+
+	day := localDate(at)
+	row := Reminder{
+		UserID:    ic.Member.User.ID,
+		ChannelID: ic.ChannelID,
+		GuildID:   ic.GuildID,
+		Message:   message,
+		Hour:      at.Hour(),
+		Min:       at.Minute(),
+		TZ:        tz,
+		Active:    true,
+		MaxFires:  1,
+		Until:     &day,
+		Mode:      modeOnce,
+	}
+	if !saveNewReminder(ctx, db, clk, s, ic, &row, at.Location()) {
+		return
+	}
+
+	respond(s, ic, fmt.Sprintf("Got it! I’ll remind you <t:%d:F> (ID %d)", at.Unix(), row.ID))
+}
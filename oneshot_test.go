@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseISOTime(t *testing.T) {
+	tests := []struct {
+		raw     string
+		tz      string
+		wall    string // the time in tz
+		wantErr bool
+	}{
+		{"2025-06-01T18:30:00-04:00", "Etc/GMT+4", "2025-06-01 18:30", false},
+		{"2025-06-01T18:30:00+09:00", "Etc/GMT-9", "2025-06-01 18:30", false},
+		{"2025-06-01T18:30:00Z", "UTC", "2025-06-01 18:30", false},
+		{" 2025-06-01T18:30:00+14:00 ", "Etc/GMT-14", "2025-06-01 18:30", false},
+		{"2025-06-01T18:30:00+05:30", "UTC", "2025-06-01 13:00", false}, // no whole-hour zone
+		{"2025-06-01T18:30:00", "", "", true},                           // no offset
+		{"2025-06-01 18:30:00-04:00", "", "", true},
+		{"2025-13-01T18:30:00Z", "", "", true},
+		{"tomorrow at 6", "", "", true},
+	}
+	for _, tt := range tests {
+		got, tz, err := parseISOTime(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseISOTime(%q) error = %v, want error %t", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if tz != tt.tz || got.Format("2006-01-02 15:04") != tt.wall {
+			t.Errorf("parseISOTime(%q) = %s in %s, want %s in %s", tt.raw, got.Format("2006-01-02 15:04"), tz, tt.wall, tt.tz)
+		}
+		want, _ := time.Parse(time.RFC3339, strings.TrimSpace(tt.raw))
+		if !got.Equal(want) {
+			t.Errorf("parseISOTime(%q) = %s, not the same instant", tt.raw, got)
+		}
+	}
+}
+
+func TestRemindAtRejects(t *testing.T) {
+	clk := newFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		when, want string
+	}{
+		{"2025-06-01T18:30", "When must be an ISO 8601 time like 2025-06-01T18:30:00-04:00."},
+		{"2025-06-01T07:59:00-04:00", "That time has already passed."},
+		{"2025-06-01T18:30:15-04:00", "Reminders fire on the minute, so leave the seconds at 00."},
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(nil)
+		handleRemindAt(context.Background(), nil, clk, s, slash("remindat", "u1", "when", tt.when, "message", "hi"))
+		if got := f.replies(t); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("/remindat %s: replies %q, want %q", tt.when, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOneShotTimeMinLead(t *testing.T) {
+	old := minLead
+	t.Cleanup(func() { minLead = old })
+	minLead = 30 * time.Second
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tooSoon := "That's too soon. Pick a time at least 30s from now."
+	tests := []struct {
+		lead time.Duration
+		want string
+	}{
+		{time.Hour, ""},
+		{30 * time.Second, ""}, // exactly the minimum
+		{30*time.Second - time.Nanosecond, tooSoon},
+		{time.Second, tooSoon},
+		{0, "That time has already passed."},
+		{-time.Minute, "That time has already passed."},
+	}
+	for _, tt := range tests {
+		if got := checkOneShotTime(at, at.Add(-tt.lead)); got != tt.want {
+			t.Errorf("%s ahead: %q, want %q", tt.lead, got, tt.want)
+		}
+	}
+
+	minLead = 0
+	if got := checkOneShotTime(at, at.Add(-time.Nanosecond)); got != "" {
+		t.Errorf("with no minimum lead: %q", got)
+	}
+}
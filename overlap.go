@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// overlapping finds an active reminder of row's owner firing at the same
+// time of day in the same timezone but with a different message, which
+// the uniqueness constraint lets through and would ping at the same
+// second. The identical message is a plain update, not an overlap.
+func overlapping(ctx context.Context, db *pgxpool.Pool, row Reminder) (id int, message string, err error) {
+	err = db.QueryRow(ctx,
+		`SELECT id, message FROM reminders
+		  WHERE user_id=$1 AND hour=$2 AND minute=$3 AND tz=$4 AND active
+		    AND message <> $5 AND mode NOT IN ('interval', 'cron')
+		  ORDER BY id LIMIT 1`,
+		row.UserID, row.Hour, row.Min, row.TZ, row.Message).Scan(&id, &message)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", nil
+	}
+	return id, message, err
+}
+
+// overlaps holds reminders waiting on the user to confirm an overlap,
+// keyed by the ID of the interaction that created them.
+var (
+	overlaps   = map[string]pendingReminder{}
+	overlapsMu sync.Mutex
+)
+
+// overlapPrompt warns that in would fire alongside reminder id, with
+// buttons to create it anyway or drop it. They last as long as tzFixTTL.
+func overlapPrompt(ic *discordgo.InteractionCreate, in remindInput, id int, message string) *discordgo.InteractionResponseData {
+	overlapsMu.Lock()
+	overlaps[ic.ID] = pendingReminder{userID: ic.Member.User.ID, in: in}
+	overlapsMu.Unlock()
+	time.AfterFunc(tzFixTTL, func() {
+		overlapsMu.Lock()
+		delete(overlaps, ic.ID)
+		overlapsMu.Unlock()
+	})
+
+	return &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("⚠️ Your reminder %d (%q) already fires at %s. Create this one as well?",
+			id, message, in.Time),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Create anyway", Style: discordgo.PrimaryButton, CustomID: "overlap:" + ic.ID + ":yes"},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "overlap:" + ic.ID + ":no"},
+			}},
+		},
+	}
+}
+
+// overlapButton handles the overlap warning's buttons.
+func overlapButton(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	key, answer, _ := strings.Cut(strings.TrimPrefix(ic.MessageComponentData().CustomID, "overlap:"), ":")
+
+	overlapsMu.Lock()
+	pending, ok := overlaps[key]
+	if ok && pending.userID == ic.Member.User.ID {
+		delete(overlaps, key)
+	}
+	overlapsMu.Unlock()
+
+	if !ok || pending.userID != ic.Member.User.ID {
+		msg := "That question has expired. Run the command again."
+		if ok {
+			msg = "That isn't your reminder."
+		}
+		s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	msg := "Nothing was created."
+	if answer == "yes" {
+		msg = "Creating it…" // createReminder's reply edits this
+	}
+	empty := []discordgo.MessageComponent{}
+	if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: msg, Components: empty},
+	}); err != nil {
+		log.Printf("ack overlap %s: %v", key, err)
+		return
+	}
+	if answer != "yes" {
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	pending.in.AllowOverlap = true
+	createReminder(ctx, db, clk, s, ic, pending.in)
+}
+
+// existingChannel is the channel of row's owner's reminder with the same
+// time, timezone and message, active or not, which saving row will
+// reactivate and move to row's channel. It's "" if there is none.
+func existingChannel(ctx context.Context, db *pgxpool.Pool, row Reminder) (string, error) {
+	var ch string
+	err := db.QueryRow(ctx,
+		`SELECT channel_id FROM reminders
+		  WHERE user_id=$1 AND hour=$2 AND minute=$3 AND tz=$4 AND message=$5`,
+		row.UserID, row.Hour, row.Min, row.TZ, row.Message).Scan(&ch)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return ch, err
+}
+
+// movedNote tells the user reminder id was an existing one that has just
+// been moved out of channel from, with a button to move it back.
+func movedNote(msg string, id int, from, to string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: msg + fmt.Sprintf("\nYou already had this reminder in <#%s>, so I reactivated it and moved it to <#%s>.", from, to),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Keep it in the old channel", Style: discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("keepch:%d:%s", id, from)},
+			}},
+		},
+	}
+}
+
+// keepChannelButton handles movedNote's button: it moves the reminder
+// back to where it was.
+func keepChannelButton(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	rawID, channelID, _ := strings.Cut(strings.TrimPrefix(ic.MessageComponentData().CustomID, "keepch:"), ":")
+	id, _ := strconv.Atoi(rawID)
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	reply := func(msg string) {
+		s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	if ok, err := channelAllowed(ctx, db, s, ic.GuildID, channelID); err != nil {
+		log.Printf("keep channel of reminder %d: %v", id, err)
+		reply("Something went wrong on my end, try again in a moment.")
+		return
+	} else if !ok {
+		reply(fmt.Sprintf("Reminders aren't allowed in <#%s> any more.", channelID))
+		return
+	}
+
+	var r Reminder
+	err := db.QueryRow(ctx,
+		`UPDATE reminders SET channel_id = $3, updated_at = now()
+		  WHERE id = $1 AND user_id = $2
+		RETURNING `+reminderColumns, id, ic.Member.User.ID, channelID).Scan(reminderDest(&r)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		reply("That isn't your reminder.")
+		return
+	}
+	if err != nil {
+		log.Printf("keep channel of reminder %d: %v", id, err)
+		reply("Something went wrong on my end, try again in a moment.")
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	msg := ic.Message.Content + fmt.Sprintf("\nMoved back to <#%s>.", channelID)
+	empty := []discordgo.MessageComponent{}
+	if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: msg, Components: empty},
+	}); err != nil {
+		log.Printf("ack keep channel %d: %v", id, err)
+	}
+}
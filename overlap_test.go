@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestOverlapping(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() { db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id LIKE 'test-overlap%'`) })
+
+	ids := map[string]int{}
+	for _, row := range []struct {
+		key, user, message, tz, mode string
+		hour, min                    int
+		active                       bool
+	}{
+		{"standup", "test-overlap", "standup", "UTC", "daily", 9, 0, true},
+		{"stopped", "test-overlap", "old one", "UTC", "daily", 9, 0, false},
+		{"paris", "test-overlap", "bonjour", "Europe/Paris", "daily", 9, 0, true},
+		{"later", "test-overlap", "coffee", "UTC", "daily", 9, 30, true},
+		{"interval", "test-overlap", "stretch", "UTC", "interval", 9, 0, true},
+		{"other user", "test-overlap-other", "theirs", "UTC", "daily", 9, 0, true},
+	} {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, mode, interval_min, active)
+			 VALUES ($1, 'c1', 'g1', $2, $3, $4, $5, $6, 60, $7) RETURNING id`,
+			row.user, row.message, row.hour, row.min, row.tz, row.mode, row.active).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids[row.key] = id
+	}
+
+	for _, c := range []struct {
+		name   string
+		row    Reminder
+		wantID int
+	}{
+		{"new message at 09:00", Reminder{UserID: "test-overlap", Hour: 9, TZ: "UTC", Message: "lunch"}, ids["standup"]},
+		{"same message updates", Reminder{UserID: "test-overlap", Hour: 9, TZ: "UTC", Message: "standup"}, 0},
+		{"other timezone", Reminder{UserID: "test-overlap", Hour: 9, TZ: "America/Toronto", Message: "lunch"}, 0},
+		{"other minute", Reminder{UserID: "test-overlap", Hour: 9, Min: 15, TZ: "UTC", Message: "lunch"}, 0},
+		{"someone new", Reminder{UserID: "test-overlap-nobody", Hour: 9, TZ: "UTC", Message: "lunch"}, 0},
+	} {
+		id, msg, err := overlapping(ctx, db, c.row)
+		if err != nil || id != c.wantID {
+			t.Errorf("%s: overlapping = %d %q, %v; want %d", c.name, id, msg, err, c.wantID)
+		}
+		if id == ids["standup"] && msg != "standup" {
+			t.Errorf("%s: message %q, want standup", c.name, msg)
+		}
+	}
+}
+
+func TestOverlapPrompt(t *testing.T) {
+	ic := slash("remind", "u1")
+	ic.ID = "i-prompt"
+	t.Cleanup(func() {
+		overlapsMu.Lock()
+		delete(overlaps, ic.ID)
+		overlapsMu.Unlock()
+	})
+
+	data := overlapPrompt(ic, remindInput{Time: "09:00", Message: "lunch"}, 12, "standup")
+	if want := `⚠️ Your reminder 12 ("standup") already fires at 09:00. Create this one as well?`; data.Content != want {
+		t.Errorf("content = %q, want %q", data.Content, want)
+	}
+	var got []string
+	for _, c := range data.Components[0].(discordgo.ActionsRow).Components {
+		got = append(got, c.(discordgo.Button).CustomID)
+	}
+	if len(got) != 2 || got[0] != "overlap:i-prompt:yes" || got[1] != "overlap:i-prompt:no" {
+		t.Errorf("buttons = %v", got)
+	}
+	overlapsMu.Lock()
+	pending, ok := overlaps[ic.ID]
+	overlapsMu.Unlock()
+	if !ok || pending.userID != "u1" || pending.in.Message != "lunch" {
+		t.Errorf("pending = %+v, %t", pending, ok)
+	}
+}
+
+func TestOverlapButton(t *testing.T) {
+	overlapsMu.Lock()
+	overlaps["i-btn"] = pendingReminder{userID: "u1", in: remindInput{Message: "lunch"}}
+	overlapsMu.Unlock()
+	t.Cleanup(func() {
+		overlapsMu.Lock()
+		delete(overlaps, "i-btn")
+		overlapsMu.Unlock()
+	})
+	pending := func() bool {
+		overlapsMu.Lock()
+		defer overlapsMu.Unlock()
+		_, ok := overlaps["i-btn"]
+		return ok
+	}
+
+	s, f := newFakeDiscord(nil)
+	overlapButton(nil, realClock{}, s, press("overlap:i-btn:yes", "u2"))
+	if cb := f.callbacks(t); len(cb) != 1 || cb[0].Data.Content != "That isn't your reminder." {
+		t.Errorf("someone else pressing: %+v", cb)
+	}
+	if !pending() {
+		t.Fatal("someone else's press dropped the question")
+	}
+
+	s, f = newFakeDiscord(nil)
+	overlapButton(nil, realClock{}, s, press("overlap:i-btn:no", "u1"))
+	cb := f.callbacks(t)
+	if len(cb) != 1 || cb[0].Type != discordgo.InteractionResponseUpdateMessage || cb[0].Data.Content != "Nothing was created." {
+		t.Errorf("cancel: %+v", cb)
+	}
+	if pending() {
+		t.Error("cancelling left the question pending")
+	}
+
+	s, f = newFakeDiscord(nil)
+	overlapButton(nil, realClock{}, s, press("overlap:i-btn:yes", "u1"))
+	if cb := f.callbacks(t); len(cb) != 1 || cb[0].Data.Content != "That question has expired. Run the command again." {
+		t.Errorf("answered twice: %+v", cb)
+	}
+}
+
+func TestMovedNote(t *testing.T) {
+	data := movedNote("Got it! (ID 5)", 5, "c2", "c1")
+	if want := "Got it! (ID 5)\nYou already had this reminder in <#c2>, so I reactivated it and moved it to <#c1>."; data.Content != want {
+		t.Errorf("content = %q", data.Content)
+	}
+	b := data.Components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button)
+	if b.CustomID != "keepch:5:c2" {
+		t.Errorf("button = %q, want keepch:5:c2", b.CustomID)
+	}
+}
+
+func TestRemindInAnotherChannelMovesIt(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-moved'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-moved', 'c2', 'g1', 'standup', 9, 0, 'UTC', false) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	in := remindInput{Time: "09:00", TZ: "UTC", Message: "standup"}
+
+	// re-running /remind in c1 takes over the stopped reminder from c2
+	s, f := newFakeDiscord(nil)
+	createReminder(ctx, db, realClock{}, s, slash("remind", "test-moved"), in)
+	got := f.replies(t)
+	if len(got) != 1 || !strings.HasSuffix(got[0], "\nYou already had this reminder in <#c2>, so I reactivated it and moved it to <#c1>.") {
+		t.Fatalf("replies = %q", got)
+	}
+	if r, err := loadReminder(ctx, db, id); err != nil || r.ChannelID != "c1" || !r.Active {
+		t.Fatalf("reminder %d after /remind: %+v, %v", id, r, err)
+	}
+
+	// in the same channel there's nothing to point out
+	s, f = newFakeDiscord(nil)
+	createReminder(ctx, db, realClock{}, s, slash("remind", "test-moved"), in)
+	if got := f.replies(t); len(got) != 1 || strings.Contains(got[0], "You already had") {
+		t.Errorf("same channel: replies = %q", got)
+	}
+
+	s, f = newFakeDiscord(nil)
+	keepChannelButton(db, realClock{}, s, press(fmt.Sprintf("keepch:%d:c2", id), "test-moved-intruder"))
+	if cb := f.callbacks(t); len(cb) != 1 || cb[0].Data.Content != "That isn't your reminder." {
+		t.Errorf("intruder: %+v", cb)
+	}
+
+	s, f = newFakeDiscord(nil)
+	keepChannelButton(db, realClock{}, s, press(fmt.Sprintf("keepch:%d:c2", id), "test-moved"))
+	if cb := f.callbacks(t); len(cb) != 1 || cb[0].Data.Content != "\nMoved back to <#c2>." {
+		t.Errorf("keep: %+v", cb)
+	}
+	if r, err := loadReminder(ctx, db, id); err != nil || r.ChannelID != "c2" {
+		t.Errorf("after keeping the old channel: %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Languages a reminder's phrase tokens can be filled in with. English is
+// the default for owners who haven't picked one with /language.
+const (
+	langEnglish = "en"
+	langFrench  = "fr"
+)
+
+// greetingToken in a message becomes a greeting in the owner's language
+// fitting the time of day it's sent.
+const greetingToken = "{greeting}"
+
+// greeting says hello in lang at the given hour.
+func greeting(lang string, hour int) string {
+	switch lang {
+	case langFrench:
+		if hour >= 18 {
+			return "Bonsoir"
+		}
+		return "Bonjour"
+	default:
+		switch {
+		case hour < 12:
+			return "Good morning"
+		case hour < 18:
+			return "Good afternoon"
+		default:
+			return "Good evening"
+		}
+	}
+}
+
+// localizeMessage fills the phrase tokens in msg for an owner speaking
+// lang, at t in the reminder's timezone.
+func localizeMessage(msg, lang string, t time.Time) string {
+	if !strings.Contains(msg, greetingToken) {
+		return msg
+	}
+	return strings.ReplaceAll(msg, greetingToken, greeting(lang, t.Hour()))
+}
+
+// userLanguage is the language the user picked with /language.
+func userLanguage(ctx context.Context, db *pgxpool.Pool, userID string) string {
+	lang := langEnglish
+	_ = db.QueryRow(ctx,
+		`SELECT language FROM user_prefs WHERE user_id=$1`, userID).Scan(&lang)
+	return lang
+}
+
+// languageName is how /prefs and /language show lang.
+func languageName(lang string) string {
+	if lang == langFrench {
+		return "Français"
+	}
+	return "English"
+}
+
+// handleLanguage sets the language the phrase tokens in the caller's
+// reminders, like {greeting}, are filled in with.
+func handleLanguage(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	lang := ic.ApplicationCommandData().Options[0].StringValue() // "fr"
+	if _, err := db.Exec(ctx,
+		`INSERT INTO user_prefs (user_id, language) VALUES ($1,$2)
+		 ON CONFLICT (user_id) DO UPDATE SET language = EXCLUDED.language`,
+		ic.Member.User.ID, lang); err != nil {
+		respondErr(s, ic, "saving your preference", err)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Your reminders will say %s in %s, e.g. “%s”.",
+		greetingToken, languageName(lang), greeting(lang, 9)))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGreeting(t *testing.T) {
+	tests := []struct {
+		lang string
+		hour int
+		want string
+	}{
+		{langEnglish, 0, "Good morning"},
+		{langEnglish, 11, "Good morning"},
+		{langEnglish, 12, "Good afternoon"},
+		{langEnglish, 17, "Good afternoon"},
+		{langEnglish, 18, "Good evening"},
+		{langEnglish, 23, "Good evening"},
+		{langFrench, 11, "Bonjour"},
+		{langFrench, 12, "Bonjour"},
+		{langFrench, 17, "Bonjour"},
+		{langFrench, 18, "Bonsoir"},
+		{"", 9, "Good morning"},
+		{"de", 20, "Good evening"},
+	}
+	for _, tt := range tests {
+		if got := greeting(tt.lang, tt.hour); got != tt.want {
+			t.Errorf("greeting(%q, %d) = %q, want %q", tt.lang, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizeMessage(t *testing.T) {
+	morning := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC)
+	tests := []struct {
+		msg, lang string
+		at        time.Time
+		want      string
+	}{
+		{"{greeting}, stand-up!", langEnglish, morning, "Good morning, stand-up!"},
+		{"{greeting}, stand-up!", langFrench, morning, "Bonjour, stand-up!"},
+		{"{greeting} ! {greeting} !", langFrench, evening, "Bonsoir ! Bonsoir !"},
+		{"no tokens here", langFrench, evening, "no tokens here"},
+		{"{Greeting} stays", langEnglish, morning, "{Greeting} stays"},
+	}
+	for _, tt := range tests {
+		if got := localizeMessage(tt.msg, tt.lang, tt.at); got != tt.want {
+			t.Errorf("localizeMessage(%q, %q) = %q, want %q", tt.msg, tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Discord's limits on polls.
+const (
+	maxPollQuestion = 300
+	maxPollAnswer   = 55
+	maxPollAnswers  = 10
+	maxPollHours    = 768
+)
+
+// pollDef is what a poll reminder stores in its poll column.
+type pollDef struct {
+	Question string   `json:"question"`
+	Answers  []string `json:"answers"`
+	Multi    bool     `json:"multiselect,omitempty"`
+	Hours    int      `json:"hours,omitempty"`
+}
+
+// parseAnswers splits "Yes | No | Maybe" into trimmed, non-empty answers.
+// The error is meant for the user.
+func parseAnswers(raw string) ([]string, error) {
+	var answers []string
+	for _, a := range strings.Split(raw, "|") {
+		if a = strings.TrimSpace(a); a == "" {
+			continue
+		}
+		if len(a) > maxPollAnswer {
+			return nil, fmt.Errorf("Answer %q is longer than %d characters.", a, maxPollAnswer)
+		}
+		answers = append(answers, a)
+	}
+	if len(answers) < 2 || len(answers) > maxPollAnswers {
+		return nil, fmt.Errorf("A poll needs between 2 and %d answers, separated by |.", maxPollAnswers)
+	}
+	return answers, nil
+}
+
+// buildPoll turns a stored definition into the poll Discord expects.
+func buildPoll(p pollDef) *discordgo.Poll {
+	hours := p.Hours
+	if hours <= 0 {
+		hours = 24
+	}
+	poll := &discordgo.Poll{
+		Question:         discordgo.PollMedia{Text: p.Question},
+		AllowMultiselect: p.Multi,
+		LayoutType:       discordgo.PollLayoutTypeDefault,
+		Duration:         hours,
+	}
+	for _, a := range p.Answers {
+		poll.Answers = append(poll.Answers, discordgo.PollAnswer{Media: &discordgo.PollMedia{Text: a}})
+	}
+	return poll
+}
+
+func handleRemindPoll(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var timeStr, tzStr, question, answersStr string
+	var multi bool
+	var hours int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "time":
+			timeStr = opt.StringValue()
+		case "timezone":
+			tzStr = opt.StringValue()
+		case "question":
+			question = opt.StringValue()
+		case "answers":
+			answersStr = opt.StringValue()
+		case "multiselect":
+			multi = opt.BoolValue()
+		case "hours":
+			hours = int(opt.IntValue())
+		}
+	}
+
+	hour, min, err := parseClock(timeStr)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	loc, err := time.LoadLocation(tzStr)
+	if err != nil {
+		respond(s, ic, invalidTZ(tzStr))
+		return
+	}
+	answers, err := parseAnswers(answersStr)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+
+	// the question doubles as the message, which keeps the uniqueness
+	// constraint meaningful for polls
+	row := Reminder{
+		UserID:    ic.Member.User.ID,
+		ChannelID: ic.ChannelID,
+		GuildID:   ic.GuildID,
+		Message:   question,
+		Hour:      hour,
+		Min:       min,
+		TZ:        tzStr,
+		Active:    true,
+		Poll:      &pollDef{Question: question, Answers: answers, Multi: multi, Hours: hours},
+	}
+	if !saveNewReminder(ctx, db, clk, s, ic, &row, loc) {
+		return
+	}
+
+	respond(s, ic, fmt.Sprintf("Got it! I’ll post this poll every day at %02d:%02d %s (ID %d)",
+		hour, min, tzStr, row.ID))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBuildPollFromStoredJSON(t *testing.T) {
+	var p pollDef
+	stored := `{"question":"Lunch?","answers":["Pizza","Sushi","Tacos"],"multiselect":true,"hours":6}`
+	if err := json.Unmarshal([]byte(stored), &p); err != nil {
+		t.Fatal(err)
+	}
+	poll := buildPoll(p)
+	if poll.Question.Text != "Lunch?" || !poll.AllowMultiselect || poll.Duration != 6 {
+		t.Errorf("poll = %+v", poll)
+	}
+	if poll.LayoutType != discordgo.PollLayoutTypeDefault {
+		t.Errorf("layout = %d, want the default", poll.LayoutType)
+	}
+	var answers []string
+	for _, a := range poll.Answers {
+		answers = append(answers, a.Media.Text)
+	}
+	if strings.Join(answers, ",") != "Pizza,Sushi,Tacos" {
+		t.Errorf("answers = %v", answers)
+	}
+}
+
+func TestBuildPollDefaultsToADay(t *testing.T) {
+	var p pollDef
+	if err := json.Unmarshal([]byte(`{"question":"Gym?","answers":["Yes","No"]}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if poll := buildPoll(p); poll.Duration != 24 || poll.AllowMultiselect {
+		t.Errorf("duration %d, multiselect %t; want 24 and false", poll.Duration, poll.AllowMultiselect)
+	}
+}
+
+func TestParseAnswers(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{"Yes | No", 2, false},
+		{" Yes || No | Maybe |", 3, false},
+		{"Yes", 0, true},
+		{strings.Repeat("a|", maxPollAnswers+1), 0, true},
+		{"Yes | " + strings.Repeat("x", maxPollAnswer+1), 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAnswers(tt.raw)
+		if (err != nil) != tt.wantErr || len(got) != tt.want {
+			t.Errorf("parseAnswers(%q) = %q, %v; want %d answers, error %t", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSendPostsThePoll(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", TZ: "UTC", Message: "Lunch?",
+		Poll: &pollDef{Question: "Lunch?", Answers: []string{"Pizza", "Sushi"}}}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	posts := f.posts(t)
+	if len(posts) != 1 || posts[0].Poll == nil {
+		t.Fatalf("posts = %+v, want one with a poll", posts)
+	}
+	if posts[0].Content != "<@1>" || posts[0].Poll.Question.Text != "Lunch?" || len(posts[0].Poll.Answers) != 2 {
+		t.Errorf("posted %q with poll %+v", posts[0].Content, posts[0].Poll)
+	}
+}
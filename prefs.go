@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultTZ is the timezone commands fall back to when none is given: the
+// user's own preference, then the server's, then UTC.
+func defaultTZ(ctx context.Context, db *pgxpool.Pool, userID, guildID string) (string, error) {
+	var tz string
+	err := db.QueryRow(ctx,
+		`SELECT COALESCE(
+		        (SELECT tz FROM user_prefs WHERE user_id=$1),
+		        (SELECT tz FROM guild_prefs WHERE guild_id=$2),
+		        'UTC')`, userID, guildID).Scan(&tz)
+	return tz, err
+}
+
+func handleSetTZ(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	tz := ic.ApplicationCommandData().Options[0].StringValue()
+	if _, err := time.LoadLocation(tz); err != nil {
+		respond(s, ic, invalidTZ(tz))
+		return
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO user_prefs (user_id, tz) VALUES ($1,$2)
+		 ON CONFLICT (user_id) DO UPDATE SET tz = EXCLUDED.tz`,
+		ic.Member.User.ID, tz); err != nil {
+		respondErr(s, ic, "saving your preference", err)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Your default timezone is now %s.", tz))
+}
+
+// uses12h reports whether the user wants times shown on a 12-hour clock.
+// Anything going wrong means the 24-hour default.
+func uses12h(ctx context.Context, db *pgxpool.Pool, userID string) bool {
+	var h12 bool
+	_ = db.QueryRow(ctx,
+		`SELECT clock_12h FROM user_prefs WHERE user_id=$1`, userID).Scan(&h12)
+	return h12
+}
+
+// handleTimeFormat sets whether the user's confirmations and lists show
+// times as 24-hour (the default) or 12-hour with AM/PM. Input is
+// unaffected.
+func handleTimeFormat(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	h12 := ic.ApplicationCommandData().Options[0].StringValue() == "12h"
+	if _, err := db.Exec(ctx,
+		`INSERT INTO user_prefs (user_id, clock_12h) VALUES ($1,$2)
+		 ON CONFLICT (user_id) DO UPDATE SET clock_12h = EXCLUDED.clock_12h`,
+		ic.Member.User.ID, h12); err != nil {
+		respondErr(s, ic, "saving your preference", err)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("I’ll show times like %s from now on.", formatClock(18, 30, h12)))
+}
+
+// handleRetz moves all of the caller's active reminders to a new timezone,
+// keeping their wall-clock times, and reschedules them.
+func handleRetz(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	tz := ic.ApplicationCommandData().Options[0].StringValue()
+	if _, err := time.LoadLocation(tz); err != nil {
+		respond(s, ic, invalidTZ(tz))
+		return
+	}
+
+	rows, err := db.Query(ctx,
+		`UPDATE reminders SET tz = $2, updated_at = now()
+		  WHERE user_id = $1 AND active AND tz <> $2
+		RETURNING `+reminderColumns, ic.Member.User.ID, tz)
+	if err != nil {
+		respondErr(s, ic, "moving your reminders", err)
+		return
+	}
+	var moved []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := scanReminder(rows, &r); err != nil {
+			rows.Close()
+			respondErr(s, ic, "moving your reminders", err)
+			return
+		}
+		moved = append(moved, r)
+	}
+	rows.Close()
+	if err := rows.Err(); isUniqueViolation(err) {
+		respond(s, ic, fmt.Sprintf("Some of your reminders already exist in %s, so nothing was changed. Stop the duplicates first.", tz))
+		return
+	} else if err != nil {
+		respondErr(s, ic, "moving your reminders", err)
+		return
+	}
+
+	for _, r := range moved {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+	respond(s, ic, fmt.Sprintf("Moved %d reminders to %s ✅", len(moved), tz))
+}
+
+// handlePrefs shows the caller's saved preferences and what they fall
+// back to.
+func handlePrefs(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var tz *string
+	var digest, h12, hidden bool
+	lang := langEnglish
+	err := db.QueryRow(ctx,
+		`SELECT tz, weekly_digest, clock_12h, leaderboard_optout, language FROM user_prefs WHERE user_id=$1`,
+		ic.Member.User.ID).Scan(&tz, &digest, &h12, &hidden, &lang)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondErr(s, ic, "loading your preferences", err)
+		return
+	}
+	fallback, err := defaultTZ(ctx, db, "", ic.GuildID)
+	if err != nil {
+		respondErr(s, ic, "loading your preferences", err)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("**Your preferences**\n")
+	if tz != nil {
+		fmt.Fprintf(&b, "timezone: %s\n", *tz)
+	} else {
+		fmt.Fprintf(&b, "timezone: not set (using %s)\n", fallback)
+	}
+	fmt.Fprintf(&b, "weekly digest: %t\n", digest)
+	if h12 {
+		b.WriteString("time format: 12-hour\n")
+	} else {
+		b.WriteString("time format: 24-hour\n")
+	}
+	fmt.Fprintf(&b, "on the leaderboard: %t\n", !hidden)
+	fmt.Fprintf(&b, "language: %s\n", languageName(lang))
+	respond(s, ic, b.String())
+}
+
+// handleClearPrefs asks for confirmation before deleting the caller's
+// preferences; clearPrefsButton does the deleting.
+func handleClearPrefs(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	respondWith(s, ic, &discordgo.InteractionResponseData{
+		Content: "Clear all your preferences? Your timezone falls back to the server's, and the weekly digest stops.",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Clear", Style: discordgo.DangerButton, CustomID: "clearprefs:yes"},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "clearprefs:no"},
+			}},
+		},
+	})
+}
+
+// clearPrefsButton handles the /clearprefs confirmation. The prompt is
+// ephemeral, so whoever presses it is the user who asked.
+func clearPrefsButton(db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	msg := "Nothing was changed."
+	if ic.MessageComponentData().CustomID == "clearprefs:yes" {
+		ctx, cancel := dbCtx()
+		defer cancel()
+		if _, err := db.Exec(ctx,
+			`DELETE FROM user_prefs WHERE user_id=$1`, ic.Member.User.ID); err != nil {
+			log.Printf("clear prefs for %s: %v", ic.Member.User.ID, err)
+			msg = "Sorry, I couldn't clear your preferences. Try again later."
+		} else {
+			msg = "Your preferences are cleared."
+		}
+	}
+	empty := []discordgo.MessageComponent{}
+	s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: msg, Components: empty},
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRetzRejectsInvalidZone(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleRetz(context.Background(), nil, realClock{}, s, slash("retz", "u1", "timezone", "torontoo"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "Invalid timezone name. Did you mean America/Toronto?" {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestRetzMovesAndReschedules(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var ids []int
+	t.Cleanup(func() {
+		for _, id := range ids {
+			unschedule(id)
+		}
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id IN ('test-retz', 'test-retz-other')`)
+	})
+	for _, row := range []struct {
+		user, msg, tz string
+		active        bool
+	}{
+		{"test-retz", "a", "UTC", true},
+		{"test-retz", "b", "Asia/Tokyo", true},
+		{"test-retz", "c", "Europe/Paris", true}, // already there
+		{"test-retz", "d", "UTC", false},         // stopped ones stay put
+		{"test-retz-other", "e", "UTC", true},    // someone else's
+	} {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+			 VALUES ($1, 'c1', 'g1', $2, 9, 0, $3, $4) RETURNING id`, row.user, row.msg, row.tz, row.active).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	s, f := newFakeDiscord(nil)
+	handleRetz(ctx, db, realClock{}, s, slash("retz", "test-retz", "timezone", "Europe/Paris"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "Moved 2 reminders to Europe/Paris ✅" {
+		t.Errorf("replies = %q", got)
+	}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	for i, want := range []string{"Europe/Paris", "Europe/Paris", "Europe/Paris", "UTC", "UTC"} {
+		r, err := loadReminder(ctx, db, ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.TZ != want {
+			t.Errorf("reminder %s is in %s, want %s", r.Message, r.TZ, want)
+		}
+		if i > 1 {
+			continue
+		}
+		cronsMu.Lock()
+		c, version := crons[r.ID], cronVersions[r.ID]
+		cronsMu.Unlock()
+		if c == nil || !version.Equal(r.UpdatedAt) {
+			t.Errorf("reminder %s wasn't rescheduled", r.Message)
+			continue
+		}
+		if next := c.Entries()[0].Schedule.Next(time.Now()).In(paris); next.Hour() != 9 || next.Minute() != 0 {
+			t.Errorf("reminder %s next fires at %s, want 09:00 Paris time", r.Message, next)
+		}
+	}
+}
+
+func TestClearPrefsAsksFirst(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleClearPrefs(context.Background(), nil, s, slash("clearprefs", "u1"))
+	got := f.replies(t)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "Clear all your preferences?") {
+		t.Fatalf("replies = %q", got)
+	}
+	body := string(f.calls[len(f.calls)-1].Body)
+	if !strings.Contains(body, `"custom_id":"clearprefs:yes"`) || !strings.Contains(body, `"custom_id":"clearprefs:no"`) {
+		t.Errorf("the prompt has no confirm and cancel buttons: %s", body)
+	}
+}
+
+func TestClearPrefsCancel(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	onComponent(nil, realClock{})(s, press("clearprefs:no", "u1"))
+	cbs := f.callbacks(t)
+	if len(cbs) != 1 || cbs[0].Type != discordgo.InteractionResponseUpdateMessage || cbs[0].Data.Content != "Nothing was changed." {
+		t.Fatalf("callbacks = %+v", cbs)
+	}
+	if len(cbs[0].Data.Components) != 0 {
+		t.Error("the buttons were left on the prompt")
+	}
+}
+
+func TestClearPrefsDeletes(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM user_prefs WHERE user_id LIKE 'test-clear-%'`)
+		db.Exec(context.Background(), `DELETE FROM guild_prefs WHERE guild_id = 'g1'`)
+	})
+	for _, q := range []string{
+		`INSERT INTO user_prefs (user_id, tz) VALUES ('test-clear-me', 'Asia/Tokyo'), ('test-clear-other', 'Asia/Tokyo')`,
+		`INSERT INTO guild_prefs (guild_id, tz) VALUES ('g1', 'Europe/Paris')`,
+	} {
+		if _, err := db.Exec(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, f := newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press("clearprefs:yes", "test-clear-me"))
+	if cbs := f.callbacks(t); len(cbs) != 1 || cbs[0].Data.Content != "Your preferences are cleared." {
+		t.Errorf("callbacks = %+v", cbs)
+	}
+	if tz, err := defaultTZ(ctx, db, "test-clear-me", "g1"); err != nil || tz != "Europe/Paris" {
+		t.Errorf("timezone after clearing = %q, %v; want the server's", tz, err)
+	}
+	if tz, err := defaultTZ(ctx, db, "test-clear-other", "g1"); err != nil || tz != "Asia/Tokyo" {
+		t.Errorf("someone else's timezone = %q, %v; want it kept", tz, err)
+	}
+}
+
+func TestTimeFormatPref(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() { db.Exec(context.Background(), `DELETE FROM user_prefs WHERE user_id = 'test-timeformat'`) })
+
+	if uses12h(ctx, db, "test-timeformat") {
+		t.Fatal("a user without prefs should get the 24-hour default")
+	}
+	for _, c := range []struct {
+		format string
+		h12    bool
+		reply  string
+	}{
+		{"12h", true, "I’ll show times like 6:30 PM from now on."},
+		{"24h", false, "I’ll show times like 18:30 from now on."},
+	} {
+		s, f := newFakeDiscord(nil)
+		handleTimeFormat(ctx, db, s, slash("timeformat", "test-timeformat", "format", c.format))
+		if got := f.replies(t); len(got) != 1 || got[0] != c.reply {
+			t.Errorf("%s: replies = %q", c.format, got)
+		}
+		if got := uses12h(ctx, db, "test-timeformat"); got != c.h12 {
+			t.Errorf("after %s uses12h = %t", c.format, got)
+		}
+	}
+}
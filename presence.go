@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// presenceConfig controls the rotating "Playing ..." status. Phrases may
+// contain {count}, replaced with the number of active reminders.
+type presenceConfig struct {
+	Phrases  []string
+	Interval time.Duration
+}
+
+var defaultPhrases = []string{"{count} active reminders", "/remind to start"}
+
+// presenceConfigFromEnv reads STATUS_PHRASES ("|"-separated) and
+// STATUS_INTERVAL (e.g. "5m"). An empty STATUS_PHRASES keeps the defaults;
+// "off" disables rotation.
+func presenceConfigFromEnv() presenceConfig {
+	cfg := presenceConfig{Phrases: defaultPhrases, Interval: 5 * time.Minute}
+
+	if v := os.Getenv("STATUS_PHRASES"); v == "off" {
+		cfg.Phrases = nil
+	} else if v != "" {
+		cfg.Phrases = strings.Split(v, "|")
+	}
+	if v := os.Getenv("STATUS_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 30*time.Second {
+			log.Printf("STATUS_INTERVAL %q ignored, want a duration of at least 30s", v)
+		} else {
+			cfg.Interval = d
+		}
+	}
+	return cfg
+}
+
+// rotatePresence cycles through the configured phrases until the process
+// exits. Run it in its own goroutine.
+func rotatePresence(db *pgxpool.Pool, s *discordgo.Session, cfg presenceConfig) {
+	if len(cfg.Phrases) == 0 {
+		return
+	}
+
+	t := time.NewTicker(cfg.Interval)
+	defer t.Stop()
+
+	for i := 0; ; i++ {
+		phrase := cfg.Phrases[i%len(cfg.Phrases)]
+		if strings.Contains(phrase, "{count}") {
+			var n int
+			ctx, cancel := dbCtx()
+			if err := db.QueryRow(ctx,
+				`SELECT COUNT(*) FROM reminders WHERE active`).Scan(&n); err != nil {
+				log.Printf("presence count: %v", err)
+			}
+			cancel()
+			phrase = strings.ReplaceAll(phrase, "{count}", strconv.Itoa(n))
+		}
+		if err := s.UpdateGameStatus(0, phrase); err != nil {
+			log.Printf("update presence: %v", err)
+		}
+		<-t.C
+	}
+}
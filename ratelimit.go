@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// sendLimit spaces out reminder sends so a burst of fires at the top of
+// the hour stays under Discord's global rate limit. discordgo already
+// waits out per-route limits; this is the safety valve above them. nil
+// means unlimited.
+var sendLimit *tokenBucket
+
+// tokenBucket lets through up to rate sends a second on average, and up
+// to a second's worth at once.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond int) *tokenBucket {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:   float64(perSecond),
+		tokens: float64(perSecond),
+		last:   time.Now(),
+	}
+}
+
+// take blocks until n tokens are free and uses them. Callers queue in the
+// order they arrive: the bucket goes into debt and each waits its turn.
+func (b *tokenBucket) take(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	time.Sleep(wait)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstThenRate(t *testing.T) {
+	b := newTokenBucket(100)
+
+	start := time.Now()
+	for range 100 {
+		b.take(1)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("a second's worth of sends took %v, want them at once", d)
+	}
+
+	start = time.Now()
+	for range 50 {
+		b.take(1)
+	}
+	if d := time.Since(start); d < 400*time.Millisecond || d > 800*time.Millisecond {
+		t.Errorf("50 sends past the burst took %v, want about 500ms at 100/s", d)
+	}
+}
+
+func TestTokenBucketQueuesConcurrentSends(t *testing.T) {
+	b := newTokenBucket(20)
+	b.take(20) // drain the burst
+
+	var mu sync.Mutex
+	var done []time.Duration
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.take(1)
+			mu.Lock()
+			done = append(done, time.Since(start))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// 10 sends at 20/s finish over half a second, not all at once
+	var first, last time.Duration = done[0], done[0]
+	for _, d := range done {
+		first, last = min(first, d), max(last, d)
+	}
+	if last < 400*time.Millisecond || last > 800*time.Millisecond {
+		t.Errorf("last queued send after %v, want about 500ms", last)
+	}
+	if first > 150*time.Millisecond {
+		t.Errorf("first queued send after %v, want about 50ms", first)
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if b := newTokenBucket(n); b != nil {
+			t.Errorf("newTokenBucket(%d) = %+v, want nil for unlimited", n, b)
+		}
+	}
+	var b *tokenBucket
+	start := time.Now()
+	for range 1000 {
+		b.take(1)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("nil bucket took %v", d)
+	}
+}
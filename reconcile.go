@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reconcileInterval is how often the crons map is checked against the
+// database, 0 = never. Edits made by another instance, or a schedule
+// that failed to build, otherwise stay wrong until the next restart.
+var reconcileInterval = 10 * time.Minute
+
+// startReconcile runs reconcile every reconcileInterval.
+func startReconcile(db *pgxpool.Pool, clk Clock, s *discordgo.Session) {
+	if reconcileInterval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(reconcileInterval) {
+			reconcile(db, clk, s)
+		}
+	}()
+}
+
+// reconcile makes the crons map match the active reminders in the
+// database. The map is read before the query, so a reminder created in
+// between is only ever (harmlessly) scheduled again, never dropped.
+func reconcile(db *pgxpool.Pool, clk Clock, s *discordgo.Session) {
+	scheduled := scheduledVersions()
+
+	ctx, cancel := dbCtx()
+	rows, _ := db.Query(ctx, `SELECT `+reminderColumns+` FROM reminders WHERE active`)
+	var active []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := scanReminder(rows, &r); err != nil {
+			continue
+		}
+		active = append(active, r)
+	}
+	rows.Close()
+	err := rows.Err()
+	cancel()
+	if err != nil {
+		log.Printf("reconcile: %v", err)
+		reportError(s, "reconcile", fmt.Sprintf("Checking scheduled reminders against the database failed: %v", err))
+		return
+	}
+
+	add, remove := reconcileDiff(active, scheduled)
+	for _, id := range remove {
+		log.Printf("reconcile: unscheduling reminder %d", id)
+		unschedule(id)
+	}
+	for _, r := range add {
+		log.Printf("reconcile: scheduling reminder %d", r.ID)
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reconcile reminder %d: %v", r.ID, err)
+		}
+	}
+}
+
+// reconcileDiff compares the active reminders with the scheduled ones,
+// given as ID -> updated_at of the row they were built from. Reminders
+// that are missing or out of date are returned in add, runners with no
+// active reminder behind them in remove.
+func reconcileDiff(active []Reminder, scheduled map[int]time.Time) (add []Reminder, remove []int) {
+	seen := make(map[int]bool, len(active))
+	for _, r := range active {
+		seen[r.ID] = true
+		if v, ok := scheduled[r.ID]; !ok || !v.Equal(r.UpdatedAt) {
+			add = append(add, r)
+		}
+	}
+	for id := range scheduled {
+		if !seen[id] {
+			remove = append(remove, id)
+		}
+	}
+	return add, remove
+}
+
+// scheduledVersions is a copy of cronVersions.
+func scheduledVersions() map[int]time.Time {
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+
+	m := make(map[int]time.Time, len(cronVersions))
+	for id, v := range cronVersions {
+		m[id] = v
+	}
+	return m
+}
+
+// stopAll stops and forgets every cron runner.
+func stopAll() {
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+
+	for id, c := range crons {
+		c.Stop()
+		delete(crons, id)
+		delete(cronVersions, id)
+		delete(cronEntries, id)
+	}
+}
+
+// handleReload rebuilds every job from the database, as after a restart,
+// e.g. once rows have been edited by hand. Fires due while it runs are
+// caught up like after a restart, so running it twice does no harm. It
+// touches every server's reminders, so it's for operators only.
+func handleReload(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+	if !isOperator(ic) {
+		respond(s, ic, "Only the bot's operators can reload every reminder.")
+		return
+	}
+
+	log.Printf("reload requested by %s in %s", ic.Member.User.ID, ic.GuildID)
+	stopAll()
+	n := restoreJobs(ctx, db, clk, s)
+	respond(s, ic, fmt.Sprintf("🔄 Reloaded %d reminders from the database.", n))
+}
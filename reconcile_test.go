@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestReconcileDiff(t *testing.T) {
+	v1 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	v2 := v1.Add(time.Minute)
+	active := []Reminder{
+		{ID: 1, UpdatedAt: v1}, // scheduled and current
+		{ID: 2, UpdatedAt: v2}, // scheduled from an older row
+		{ID: 3, UpdatedAt: v1}, // never scheduled
+	}
+	scheduled := map[int]time.Time{1: v1, 2: v1, 4: v1} // 4 was stopped elsewhere
+
+	add, remove := reconcileDiff(active, scheduled)
+	var added []int
+	for _, r := range add {
+		added = append(added, r.ID)
+	}
+	if !slices.Equal(added, []int{2, 3}) {
+		t.Errorf("add = %v, want 2 and 3", added)
+	}
+	if !slices.Equal(remove, []int{4}) {
+		t.Errorf("remove = %v, want 4", remove)
+	}
+
+	// the same version in another zone is still the same version
+	add, remove = reconcileDiff([]Reminder{{ID: 1, UpdatedAt: v1.In(time.FixedZone("x", 3600))}}, map[int]time.Time{1: v1})
+	if len(add) != 0 || len(remove) != 0 {
+		t.Errorf("in sync: add %v remove %v", add, remove)
+	}
+	if add, remove = reconcileDiff(nil, nil); len(add) != 0 || len(remove) != 0 {
+		t.Errorf("empty: add %v remove %v", add, remove)
+	}
+}
+
+func TestReconcileConverges(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		stopAll()
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-reconcile'`)
+	})
+	stopAll()
+
+	var missing, stale int
+	for i, id := range []*int{&missing, &stale} {
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+			 VALUES ('test-reconcile', 'c1', 'g1', 'drift', 9, $1, 'UTC', true) RETURNING id`, i).Scan(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, _ := newFakeDiscord(nil)
+	r, err := loadReminder(ctx, db, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stale runs the row as it was before someone else edited it
+	r.UpdatedAt = r.UpdatedAt.Add(-time.Hour)
+	if err := reschedule(db, realClock{}, s, r); err != nil {
+		t.Fatal(err)
+	}
+	// orphan is a runner whose reminder no longer exists
+	const orphan = 2147483000
+	if err := reschedule(db, realClock{}, s, Reminder{ID: orphan, Hour: 9, TZ: "UTC", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile(db, realClock{}, s)
+
+	versions := scheduledVersions()
+	for _, id := range []int{missing, stale} {
+		r, _ := loadReminder(ctx, db, id)
+		if v, ok := versions[id]; !ok || !v.Equal(r.UpdatedAt) {
+			t.Errorf("reminder %d scheduled at version %v (%t), want %v", id, v, ok, r.UpdatedAt)
+		}
+	}
+	if _, ok := versions[orphan]; ok {
+		t.Error("the orphaned runner is still scheduled")
+	}
+
+	// a second pass finds nothing to do
+	before := scheduledVersions()
+	reconcile(db, realClock{}, s)
+	if after := scheduledVersions(); len(after) != len(before) {
+		t.Errorf("second pass changed the schedule: %d runners, then %d", len(before), len(after))
+	}
+}
+
+func TestReloadNeedsAdmin(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handleReload(context.Background(), nil, realClock{}, s, slash("reload", "u1"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "You need the Manage Server permission to do that." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+// A server's admin only has a say over their own server, and a reload
+// reaches all of them.
+func TestReloadNeedsOperator(t *testing.T) {
+	useOperators(t, "someone-else")
+	admin := slash("reload", "u1")
+	admin.Member.Permissions = discordgo.PermissionManageServer
+	s, f := newFakeDiscord(nil)
+	handleReload(context.Background(), nil, realClock{}, s, admin)
+	if got := f.replies(t); len(got) != 1 || got[0] != "Only the bot's operators can reload every reminder." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+// useOperators makes ids the bot's operators for the rest of the test.
+func useOperators(t *testing.T, ids ...string) {
+	t.Helper()
+	prev := operators
+	operators = map[string]bool{}
+	for _, id := range ids {
+		operators[id] = true
+	}
+	t.Cleanup(func() { operators = prev })
+}
+
+func TestReloadConverges(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		stopAll()
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-reload'`)
+	})
+	var id int
+	hour := (time.Now().UTC().Hour() + 12) % 24 // nowhere near a catch-up
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-reload', 'c1', 'g1', 'edited by hand', $1, 0, 'UTC', true) RETURNING id`, hour).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := newFakeDiscord(nil)
+	const orphan = 2147483002
+	if err := reschedule(db, realClock{}, s, Reminder{ID: orphan, Hour: 9, TZ: "UTC", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	useOperators(t, "test-reload")
+	admin := slash("reload", "test-reload")
+	admin.Member.Permissions = discordgo.PermissionManageServer
+	var counts []string
+	for range 2 {
+		s, f := newFakeDiscord(nil)
+		handleReload(ctx, db, realClock{}, s, admin)
+		got := f.replies(t)
+		if len(got) != 1 {
+			t.Fatalf("replies = %q", got)
+		}
+		counts = append(counts, got[0])
+
+		versions := scheduledVersions()
+		if _, ok := versions[orphan]; ok {
+			t.Error("a runner with no reminder behind it survived the reload")
+		}
+		r, _ := loadReminder(ctx, db, id)
+		if v, ok := versions[id]; !ok || !v.Equal(r.UpdatedAt) {
+			t.Errorf("reminder %d not scheduled from its current row", id)
+		}
+		var active int
+		db.QueryRow(ctx, `SELECT count(*) FROM reminders WHERE active`).Scan(&active)
+		if want := fmt.Sprintf("🔄 Reloaded %d reminders from the database.", len(versions)); got[0] != want || len(versions) > active {
+			t.Errorf("reply %q with %d runners for %d active reminders", got[0], len(versions), active)
+		}
+	}
+	if counts[0] != counts[1] {
+		t.Errorf("reloading twice gave %q then %q", counts[0], counts[1])
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replyReference points a fire at the previous one in channelID. If that
+// message has since been deleted, Discord posts without the reply instead
+// of rejecting the send.
+func replyReference(channelID, messageID string) *discordgo.MessageReference {
+	failIfNotExists := false
+	return &discordgo.MessageReference{
+		MessageID:       messageID,
+		ChannelID:       channelID,
+		FailIfNotExists: &failIfNotExists,
+	}
+}
+
+// handleReplyChain turns on or off posting each fire of a reminder as a
+// reply to the previous one. Owner only.
+func handleReplyChain(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var on bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "enabled":
+			on = opt.BoolValue()
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET reply_chain = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, on).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the reply setting", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if on {
+		respond(s, ic, fmt.Sprintf("🧵 Each fire of reminder %d will reply to the one before.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d will post on its own again.", id))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplyReference(t *testing.T) {
+	ref := replyReference("10", "99")
+	if ref.ChannelID != "10" || ref.MessageID != "99" {
+		t.Errorf("reference = %+v, want message 99 in 10", ref)
+	}
+	if ref.FailIfNotExists == nil || *ref.FailIfNotExists {
+		t.Error("a deleted previous fire would make the send fail")
+	}
+}
+
+func TestSendRepliesToPreviousFire(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: strings.Repeat("long ", maxMessageLen/4), TZ: "UTC"}
+	if _, err := sendReminder(s, r, delivery{replyTo: "99"}); err != nil {
+		t.Fatal(err)
+	}
+	posts := f.posts(t)
+	if len(posts) < 2 {
+		t.Fatalf("posted %d messages, want the reminder split", len(posts))
+	}
+	if ref := posts[0].Reference; ref == nil || ref.MessageID != "99" || ref.ChannelID != "10" {
+		t.Errorf("first post references %+v, want message 99", ref)
+	}
+	if posts[1].Reference != nil {
+		t.Error("the continuation replies too")
+	}
+
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	if again := f.posts(t); again[len(posts)].Reference != nil {
+		t.Error("a reminder without a reply chain replied")
+	}
+}
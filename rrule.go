@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rrule is the subset of an iCalendar RRULE that reminders support:
+// FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL, BYDAY and BYMONTHDAY. The
+// time of day comes from the reminder, and COUNT and UNTIL are left to
+// max_fires and until.
+type rrule struct {
+	freq     string
+	interval int
+	byDay    []rruleDay
+	byMonth  []int // BYMONTHDAY, negative counts from the month's end
+}
+
+// rruleDay is one BYDAY entry, e.g. TU, or 2TU for the second Tuesday.
+type rruleDay struct {
+	nth int // 0 = every such weekday
+	day time.Weekday
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule reads a rule like FREQ=WEEKLY;INTERVAL=2;BYDAY=TU, with or
+// without a leading "RRULE:". The error is meant for the user.
+func parseRRule(raw string) (rrule, error) {
+	rule := rrule{interval: 1}
+	raw = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(raw)), "RRULE:")
+	for _, part := range strings.Split(raw, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rrule{}, fmt.Errorf("%q isn't a KEY=VALUE part.", part)
+		}
+		switch key {
+		case "FREQ":
+			switch val {
+			case "DAILY", "WEEKLY", "MONTHLY":
+				rule.freq = val
+			default:
+				return rrule{}, errors.New("FREQ must be DAILY, WEEKLY or MONTHLY.")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 366 {
+				return rrule{}, errors.New("INTERVAL must be a number from 1 to 366.")
+			}
+			rule.interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				if len(d) < 2 {
+					return rrule{}, fmt.Errorf("%q isn't a day like MO or 2TU.", d)
+				}
+				wd, ok := rruleWeekdays[d[len(d)-2:]]
+				if !ok {
+					return rrule{}, fmt.Errorf("%q isn't a day like MO or 2TU.", d)
+				}
+				nth := 0
+				if prefix := d[:len(d)-2]; prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return rrule{}, fmt.Errorf("%q isn't a day like MO or 2TU.", d)
+					}
+					nth = n
+				}
+				rule.byDay = append(rule.byDay, rruleDay{nth, wd})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return rrule{}, errors.New("BYMONTHDAY days must be 1 to 31, or -1 to -31 from the end.")
+				}
+				rule.byMonth = append(rule.byMonth, n)
+			}
+		case "COUNT", "UNTIL":
+			return rrule{}, fmt.Errorf("Use the max_fires and until options instead of %s.", key)
+		default:
+			return rrule{}, fmt.Errorf("%s isn't supported.", key)
+		}
+	}
+	if rule.freq == "" {
+		return rrule{}, errors.New("The rule needs a FREQ.")
+	}
+	for _, d := range rule.byDay {
+		if d.nth != 0 && rule.freq != "MONTHLY" {
+			return rrule{}, errors.New("Numbered days like 2TU only work with FREQ=MONTHLY.")
+		}
+	}
+	return rule, nil
+}
+
+// rruleSchedule is a cron.Schedule firing at hour:min on the days rule
+// picks. INTERVAL counts from the anchor date, the reminder's creation.
+// cron asks for Next again after every fire, so the reminder reschedules
+// itself as it goes.
+type rruleSchedule struct {
+	rule      rrule
+	hour, min int
+	anchor    time.Time // midnight, in loc
+	loc       *time.Location
+}
+
+// rruleHorizon bounds how far ahead Next looks before giving up.
+const rruleHorizon = 5 * 366
+
+// Next returns the first fire strictly after t, or the zero time if there
+// is none within rruleHorizon days, which cron takes as never.
+func (s rruleSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+	if day.Before(s.anchor) {
+		day = s.anchor
+	}
+	for range rruleHorizon {
+		if s.matches(day) {
+			at := time.Date(day.Year(), day.Month(), day.Day(), s.hour, s.min, 0, 0, s.loc)
+			if at.After(t) {
+				return at
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, s.loc)
+	}
+	return time.Time{}
+}
+
+// matches reports whether the rule fires on day.
+func (s rruleSchedule) matches(day time.Time) bool {
+	r := s.rule
+	switch r.freq {
+	case "DAILY":
+		if daysBetween(s.anchor, day)%r.interval != 0 {
+			return false
+		}
+		return (len(r.byDay) == 0 || matchesDay(r.byDay, day)) &&
+			(len(r.byMonth) == 0 || matchesMonthDay(r.byMonth, day))
+	case "WEEKLY":
+		if (daysBetween(weekStart(s.anchor), weekStart(day))/7)%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Weekday() == s.anchor.Weekday()
+		}
+		return matchesDay(r.byDay, day)
+	case "MONTHLY":
+		months := (day.Year()-s.anchor.Year())*12 + int(day.Month()) - int(s.anchor.Month())
+		if months%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 && len(r.byMonth) == 0 {
+			return day.Day() == s.anchor.Day()
+		}
+		return (len(r.byDay) == 0 || matchesDay(r.byDay, day)) &&
+			(len(r.byMonth) == 0 || matchesMonthDay(r.byMonth, day))
+	}
+	return false
+}
+
+func matchesDay(days []rruleDay, day time.Time) bool {
+	for _, d := range days {
+		if d.day != day.Weekday() {
+			continue
+		}
+		switch {
+		case d.nth == 0:
+			return true
+		case d.nth > 0 && (day.Day()-1)/7+1 == d.nth:
+			return true
+		case d.nth < 0 && (daysInMonth(day)-day.Day())/7+1 == -d.nth:
+			return true
+		}
+	}
+	return false
+}
+
+func matchesMonthDay(days []int, day time.Time) bool {
+	for _, d := range days {
+		if d == day.Day() || (d < 0 && daysInMonth(day)+d+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// daysBetween counts calendar days from a to b, ignoring DST.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+// weekStart is the Monday on or before t, RRULE's default week start.
+func weekStart(t time.Time) time.Time {
+	back := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	got, err := parseRRule(" rrule:freq=monthly;interval=2;byday=2TU,-1FR;bymonthday=1,-1 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rrule{
+		freq:     "MONTHLY",
+		interval: 2,
+		byDay:    []rruleDay{{2, time.Tuesday}, {-1, time.Friday}},
+		byMonth:  []int{1, -1},
+	}
+	if got.freq != want.freq || got.interval != want.interval ||
+		!slices.Equal(got.byDay, want.byDay) || !slices.Equal(got.byMonth, want.byMonth) {
+		t.Errorf("parseRRule = %+v, want %+v", got, want)
+	}
+
+	if r, _ := parseRRule("FREQ=WEEKLY"); r.interval != 1 {
+		t.Errorf("INTERVAL defaults to %d, want 1", r.interval)
+	}
+}
+
+func TestParseRRuleRejects(t *testing.T) {
+	for raw, want := range map[string]string{
+		"":                           `"" isn't a KEY=VALUE part.`,
+		"BYDAY=MO":                   "The rule needs a FREQ.",
+		"FREQ=YEARLY":                "FREQ must be DAILY, WEEKLY or MONTHLY.",
+		"FREQ=DAILY;INTERVAL=0":      "INTERVAL must be a number from 1 to 366.",
+		"FREQ=WEEKLY;BYDAY=XX":       `"XX" isn't a day like MO or 2TU.`,
+		"FREQ=MONTHLY;BYDAY=6TU":     `"6TU" isn't a day like MO or 2TU.`,
+		"FREQ=WEEKLY;BYDAY=2TU":      "Numbered days like 2TU only work with FREQ=MONTHLY.",
+		"FREQ=MONTHLY;BYMONTHDAY=32": "BYMONTHDAY days must be 1 to 31, or -1 to -31 from the end.",
+		"FREQ=DAILY;COUNT=5":         "Use the max_fires and until options instead of COUNT.",
+		"FREQ=DAILY;WKST=SU":         "WKST isn't supported.",
+	} {
+		if _, err := parseRRule(raw); err == nil || err.Error() != want {
+			t.Errorf("parseRRule(%q) = %v, want %q", raw, err, want)
+		}
+	}
+}
+
+func TestRRuleNextFires(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	created := time.Date(2026, 10, 12, 15, 0, 0, 0, paris) // a Monday afternoon
+	for _, c := range []struct {
+		rule string
+		want []string
+	}{
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", []string{"2026-10-13", "2026-10-27", "2026-11-10"}},
+		{"FREQ=WEEKLY", []string{"2026-10-19", "2026-10-26", "2026-11-02"}}, // the anchor's weekday
+		{"FREQ=DAILY;INTERVAL=3", []string{"2026-10-15", "2026-10-18", "2026-10-21"}},
+		{"FREQ=MONTHLY;BYDAY=2TU", []string{"2026-10-13", "2026-11-10", "2026-12-08"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", []string{"2026-10-30", "2026-11-27", "2026-12-25"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", []string{"2026-10-31", "2026-11-30", "2026-12-31"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", []string{"2026-10-31", "2026-12-31", "2027-01-31"}},
+	} {
+		r := Reminder{Mode: modeRRule, RRule: c.rule, Hour: 9, Min: 15, TZ: "Europe/Paris", CreatedAt: created}
+		times, err := nextFires(r, created, 3)
+		if err != nil {
+			t.Errorf("%s: %v", c.rule, err)
+			continue
+		}
+		var got []string
+		for _, at := range times {
+			if at.Hour() != 9 || at.Minute() != 15 || at.Location().String() != paris.String() {
+				t.Errorf("%s: fire at %v, want 09:15 Paris", c.rule, at)
+			}
+			got = append(got, at.Format(time.DateOnly))
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: fires on %v, want %v", c.rule, got, c.want)
+		}
+	}
+}
+
+func TestRRuleNeverFires(t *testing.T) {
+	// the 30th of every February
+	created := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	r := Reminder{Mode: modeRRule, RRule: "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30", Hour: 9, TZ: "UTC", CreatedAt: created}
+	if _, err := nextFire(r, created); err == nil || !strings.Contains(err.Error(), "never fires") {
+		t.Errorf("nextFire = %v, want a never-fires error", err)
+	}
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+)
+
+// Scheduling modes. The zero value behaves as modeDaily so rows created
+// before modes existed keep working.
+const (
+	modeDaily    = "daily"    // every day at Hour:Min
+	modeWeekly   = "weekly"   // on Days at Hour:Min
+	modeMonthly  = "monthly"  // on MonthDay at Hour:Min
+	modeLastDay  = "lastday"  // on the last day of each month at Hour:Min
+	modeOnce     = "once"     // on the Until date at Hour:Min, one fire
+	modeInterval = "interval" // every IntervalMin minutes
+	modeCron     = "cron"     // raw 5-field CronSpec
+	modeRRule    = "rrule"    // on the days RRule picks, at Hour:Min
+	modeWindow   = "window"   // daily at a random minute between WindowStart and WindowEnd
+)
+
+// modeOrDaily maps the zero mode to modeDaily for storage.
+func modeOrDaily(mode string) string {
+	if mode == "" {
+		return modeDaily
+	}
+	return mode
+}
+
+// buildSpec produces the cron spec and runner options for r, whatever its
+// mode, as of now. Everything that schedules a reminder or predicts its
+// fires goes through here so they can't disagree.
+func buildSpec(r Reminder, now time.Time) (spec string, opts []cron.Option, err error) {
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		return "", nil, err
+	}
+	if boosted(r, now) {
+		return fmt.Sprintf("@every %dm", r.BoostMin), []cron.Option{cron.WithLocation(loc)}, nil
+	}
+
+	switch r.Mode {
+	case "", modeDaily:
+		spec = fmt.Sprintf("%d %d * * *", r.Min, r.Hour)
+	case modeWeekly:
+		if r.Days == "" {
+			return "", nil, fmt.Errorf("weekly reminder %d has no days", r.ID)
+		}
+		spec = fmt.Sprintf("%d %d * * %s", r.Min, r.Hour, r.Days)
+	case modeMonthly:
+		if r.MonthDay < 1 || r.MonthDay > 31 {
+			return "", nil, fmt.Errorf("monthly reminder %d has day %d", r.ID, r.MonthDay)
+		}
+		spec = fmt.Sprintf("%d %d %d * *", r.Min, r.Hour, r.MonthDay)
+	case modeLastDay:
+		// cron can't say "last day", so run on every day that might be
+		// and let onFireDay pick the right one
+		spec = fmt.Sprintf("%d %d 28-31 * *", r.Min, r.Hour)
+	case modeOnce:
+		if r.Until == nil {
+			return "", nil, fmt.Errorf("one-shot reminder %d has no date", r.ID)
+		}
+		spec = fmt.Sprintf("%d %d %d %d *", r.Min, r.Hour, r.Until.Day(), int(r.Until.Month()))
+	case modeInterval:
+		if r.IntervalMin <= 0 {
+			return "", nil, fmt.Errorf("interval reminder %d has interval %d", r.ID, r.IntervalMin)
+		}
+		spec = fmt.Sprintf("@every %dm", r.IntervalMin)
+	case modeCron:
+		spec = r.CronSpec
+	case modeRRule:
+		if _, err := parseRRule(r.RRule); err != nil {
+			return "", nil, fmt.Errorf("rrule %q: %w", r.RRule, err)
+		}
+		// not a cron spec: buildSchedule turns it into the schedule
+		return "RRULE:" + r.RRule, []cron.Option{cron.WithLocation(loc)}, nil
+	case modeWindow:
+		if r.WindowEnd <= r.WindowStart {
+			return "", nil, fmt.Errorf("window reminder %d has window %d-%d", r.ID, r.WindowStart, r.WindowEnd)
+		}
+		// not a cron spec either: buildSchedule picks each day's time
+		return fmt.Sprintf("WINDOW:%02d:%02d-%02d:%02d", r.WindowStart/60, r.WindowStart%60, r.WindowEnd/60, r.WindowEnd%60),
+			[]cron.Option{cron.WithLocation(loc)}, nil
+	default:
+		return "", nil, fmt.Errorf("reminder %d has unknown mode %q", r.ID, r.Mode)
+	}
+
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return "", nil, fmt.Errorf("cron spec %q: %w", spec, err)
+	}
+	return spec, []cron.Option{cron.WithLocation(loc)}, nil
+}
+
+// buildSchedule is buildSpec parsed into the cron.Schedule that decides
+// when r fires.
+func buildSchedule(r Reminder, now time.Time) (cron.Schedule, []cron.Option, error) {
+	spec, opts, err := buildSpec(r, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if boosted(r, now) || (r.Mode != modeRRule && r.Mode != modeWindow) {
+		sched, err := cron.ParseStandard(spec)
+		return sched, opts, err
+	}
+	if r.Mode == modeWindow {
+		loc, _ := time.LoadLocation(r.TZ) // buildSpec checked it
+		return windowSchedule{seed: r.ID, from: r.WindowStart, to: r.WindowEnd, loc: loc}, opts, nil
+	}
+
+	// buildSpec checked both of these
+	rule, _ := parseRRule(r.RRule)
+	loc, _ := time.LoadLocation(r.TZ)
+	anchor := r.CreatedAt
+	if anchor.IsZero() {
+		anchor = now
+	}
+	anchor = anchor.In(loc)
+	return rruleSchedule{
+		rule: rule, hour: r.Hour, min: r.Min, loc: loc,
+		anchor: time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, loc),
+	}, opts, nil
+}
+
+// nextFire is the first time after t that r is due, in r's timezone.
+func nextFire(r Reminder, t time.Time) (time.Time, error) {
+	times, err := nextFires(r, t, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return times[0], nil
+}
+
+// nextFires lists the next n times after t that r is due, in r's timezone.
+// Interval reminders count from t, as cron does from when they're scheduled.
+// Fewer come back when r runs out.
+func nextFires(r Reminder, t time.Time, n int) ([]time.Time, error) {
+	sched, _, err := buildSchedule(r, t)
+	if err != nil {
+		return nil, err
+	}
+	loc, _ := time.LoadLocation(r.TZ) // buildSpec checked it
+	times := make([]time.Time, 0, n)
+	for t = t.In(loc); len(times) < n; {
+		if t = sched.Next(t); t.IsZero() {
+			// an rrule with no day left inside its horizon
+			if len(times) == 0 {
+				return nil, errors.New("it never fires again")
+			}
+			break
+		}
+		if onFireDay(r, t) {
+			times = append(times, t)
+		}
+	}
+	return times, nil
+}
+
+// onFireDay reports whether r really fires on t's date, for modes whose
+// cron spec is broader than the schedule.
+func onFireDay(r Reminder, t time.Time) bool {
+	if boosted(r, t) {
+		return true
+	}
+	switch {
+	case r.Mode == modeLastDay:
+		return lastDayOfMonth(t)
+	case r.Mode == modeWeekly && r.EveryWeeks > 1 && r.Anchor != nil:
+		return onWeek(t, *r.Anchor, r.EveryWeeks)
+	}
+	return true
+}
+
+// maxEveryWeeks caps /convert's every_weeks at a year.
+const maxEveryWeeks = 52
+
+// onWeek reports whether t's week is one of every n weeks counted from
+// anchor's week. Weeks start on Monday; only the dates matter.
+func onWeek(t, anchor time.Time, n int) bool {
+	monday := func(x time.Time) time.Time {
+		d := time.Date(x.Year(), x.Month(), x.Day(), 0, 0, 0, 0, time.UTC)
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+	}
+	weeks := int(monday(t).Sub(monday(anchor)).Hours()/24) / 7
+	return (weeks%n+n)%n == 0
+}
+
+// lastDayOfMonth reports whether t is the last day of its month, in t's
+// location.
+func lastDayOfMonth(t time.Time) bool {
+	return t.AddDate(0, 0, 1).Month() != t.Month()
+}
+
+// parseMonthDay reads /remind's monthday option: a day number for a
+// monthly reminder or "last" for the end of every month. The error is
+// meant for the user.
+func parseMonthDay(raw string) (mode string, day int, err error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "last" {
+		return modeLastDay, 0, nil
+	}
+	day, err = strconv.Atoi(raw)
+	if err != nil || day < 1 || day > 31 {
+		return "", 0, errors.New("Day of the month must be 1 to 31, or \"last\".")
+	}
+	return modeMonthly, day, nil
+}
+
+// parseWeekdays reads a list of days like "mon, wed, fri" into the cron
+// day-of-week list weekly reminders store, e.g. "1,3,5". The error is
+// meant for the user.
+func parseWeekdays(raw string) (string, error) {
+	var seen [7]bool
+	for _, part := range strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool { return r == ',' || r == ' ' }) {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if name := strings.ToLower(d.String()); part == name || part == name[:3] {
+				seen[d], found = true, true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%q isn't a day of the week.", part)
+		}
+	}
+	var days []string
+	for d, ok := range seen {
+		if ok {
+			days = append(days, strconv.Itoa(d))
+		}
+	}
+	if len(days) == 0 {
+		return "", errors.New("Give at least one day, e.g. mon, wed, fri.")
+	}
+	return strings.Join(days, ","), nil
+}
+
+// describeSchedule is the human phrasing of when r fires, e.g. "every
+// Mon, Fri at 09:00 America/Toronto".
+func describeSchedule(r Reminder) string {
+	return describeScheduleClock(r, false)
+}
+
+// describeScheduleClock is describeSchedule with the time of day on a
+// 12-hour clock when h12 is set.
+func describeScheduleClock(r Reminder, h12 bool) string {
+	at := formatClock(r.Hour, r.Min, h12) + " " + r.TZ
+	switch r.Mode {
+	case modeWeekly:
+		var names []string
+		for _, d := range strings.Split(r.Days, ",") {
+			if i, err := strconv.Atoi(d); err == nil && i >= 0 && i < 7 {
+				names = append(names, time.Weekday(i).String()[:3])
+			}
+		}
+		if r.EveryWeeks > 1 {
+			return fmt.Sprintf("every %d weeks on %s at %s", r.EveryWeeks, strings.Join(names, ", "), at)
+		}
+		return "every " + strings.Join(names, ", ") + " at " + at
+	case modeMonthly:
+		return fmt.Sprintf("on day %d of every month at %s", r.MonthDay, at)
+	case modeLastDay:
+		return "on the last day of every month at " + at
+	case modeOnce:
+		if r.Until == nil {
+			return "once at " + at
+		}
+		return "once on " + r.Until.Format("2006-01-02") + " at " + at
+	case modeInterval:
+		return "every " + (time.Duration(r.IntervalMin) * time.Minute).String()
+	case modeCron:
+		return fmt.Sprintf("on cron `%s` (%s)", r.CronSpec, r.TZ)
+	case modeRRule:
+		return fmt.Sprintf("on `%s` at %s", r.RRule, at)
+	case modeWindow:
+		return fmt.Sprintf("every day at a random time between %s and %s %s",
+			formatClock(r.WindowStart/60, r.WindowStart%60, h12), formatClock(r.WindowEnd/60, r.WindowEnd%60, h12), r.TZ)
+	default:
+		return "every day at " + at
+	}
+}
+
+// formatClock writes a time of day as 09:05, or as 9:05 AM when h12 is
+// set.
+func formatClock(hour, min int, h12 bool) string {
+	if !h12 {
+		return fmt.Sprintf("%02d:%02d", hour, min)
+	}
+	return time.Date(2000, 1, 1, hour, min, 0, 0, time.UTC).Format("3:04 PM")
+}
+
+// minFireGap is the least time allowed between two fires of any reminder,
+// whatever its schedule says, 0 = no floor. A reminder's own cooldown can
+// only raise it.
+var minFireGap time.Duration
+
+// maxCooldownMin caps /cooldown at a week.
+const maxCooldownMin = 7 * 24 * 60
+
+// fireGap is the minimum gap enforced between r's fires.
+func fireGap(r Reminder) time.Duration {
+	return max(minFireGap, time.Duration(r.MinGapMin)*time.Minute)
+}
+
+// tooSoon reports whether a fire at now would come less than gap after
+// lastFired.
+func tooSoon(lastFired *time.Time, now time.Time, gap time.Duration) bool {
+	return gap > 0 && lastFired != nil && now.Sub(*lastFired) < gap
+}
+
+// handleCooldown sets a reminder's minimum gap between fires. Owner only.
+func handleCooldown(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var minutes int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "minutes":
+			minutes = int(opt.IntValue())
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET min_gap_min = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, minutes).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the cooldown", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if minutes == 0 {
+		respond(s, ic, fmt.Sprintf("Reminder %d has no cooldown now.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d won't fire again within %s of its last fire.",
+		id, time.Duration(minutes)*time.Minute))
+}
+
+// shiftClock moves hour:min by offset minutes, wrapping around midnight.
+func shiftClock(hour, min, offset int) (int, int) {
+	t := ((hour*60+min+offset)%(24*60) + 24*60) % (24 * 60)
+	return t / 60, t % 60
+}
+
+// handleShift moves a reminder's time of day by a signed number of
+// minutes, for good. Owner only.
+func handleShift(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var offset int
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "minutes":
+			offset = int(opt.IntValue())
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	if r.Mode == modeInterval || r.Mode == modeCron || r.Mode == modeWindow {
+		respond(s, ic, "That reminder doesn't fire at a set time of day, so it can't be shifted.")
+		return
+	}
+
+	hour, min := shiftClock(r.Hour, r.Min, offset)
+	err = db.QueryRow(ctx,
+		`UPDATE reminders SET hour = $2, minute = $3, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, hour, min).Scan(reminderDest(&r)...)
+	if isUniqueViolation(err) {
+		respond(s, ic, fmt.Sprintf("You already have the same reminder at %02d:%02d.", hour, min))
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "shifting your reminder", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	respond(s, ic, fmt.Sprintf("Reminder %d now fires %s ✅", id, describeSchedule(r)))
+}
+
+// handleConvert switches a reminder between the daily, weekly and monthly
+// modes, keeping its time of day. Weekly needs days and monthly needs
+// monthday. Owner only.
+func handleConvert(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref, mode, rawDays, rawMonthDay string
+	everyWeeks := 1
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "mode":
+			mode = opt.StringValue() // "weekly"
+		case "every_weeks":
+			everyWeeks = int(opt.IntValue()) // 2
+		case "days":
+			rawDays = opt.StringValue() // "mon, wed, fri"
+		case "monthday":
+			rawMonthDay = opt.StringValue() // "15", "last"
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	switch modeOrDaily(r.Mode) {
+	case modeDaily, modeWeekly, modeMonthly, modeLastDay, modeWindow:
+	default:
+		respond(s, ic, "Only daily, weekly and monthly reminders can be converted.")
+		return
+	}
+
+	mode, days, monthDay, err := convertTarget(mode, rawDays, rawMonthDay, everyWeeks)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+
+	// the week of the conversion is the first one it fires in
+	var anchor *time.Time
+	if everyWeeks > 1 {
+		loc, _ := time.LoadLocation(r.TZ) // it's scheduled, so it loads
+		today := localDate(clk.Now().In(loc))
+		anchor = &today
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET mode = $2, days = $3, month_day = $4, week_interval = $5, anchor_date = $6,
+		       updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, mode, days, monthDay, everyWeeks, anchor).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "converting your reminder", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	respond(s, ic, fmt.Sprintf("Reminder %d now fires %s ✅", id, describeSchedule(r)))
+}
+
+// convertTarget works out the mode, days and month day /convert stores
+// for a reminder becoming mode. The error is meant for the user.
+func convertTarget(mode, rawDays, rawMonthDay string, everyWeeks int) (string, string, int, error) {
+	days, monthDay := "", 0
+	var err error
+	switch mode {
+	case modeDaily:
+	case modeWeekly:
+		if rawDays == "" {
+			return "", "", 0, errors.New("Weekly reminders need days, e.g. mon, wed, fri.")
+		}
+		if days, err = parseWeekdays(rawDays); err != nil {
+			return "", "", 0, err
+		}
+	case modeMonthly:
+		if rawMonthDay == "" {
+			return "", "", 0, errors.New("Monthly reminders need a monthday, 1 to 31 or \"last\".")
+		}
+		if mode, monthDay, err = parseMonthDay(rawMonthDay); err != nil {
+			return "", "", 0, err
+		}
+	default:
+		return "", "", 0, errors.New("Mode must be daily, weekly or monthly.")
+	}
+	if everyWeeks > 1 && mode != modeWeekly {
+		return "", "", 0, errors.New("every_weeks only works with weekly reminders.")
+	}
+	return mode, days, monthDay, nil
+}
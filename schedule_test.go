@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSpec(t *testing.T) {
+	until := time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		r    Reminder
+		want string
+	}{
+		{"zero mode is daily", Reminder{Hour: 9, Min: 5}, "5 9 * * *"},
+		{"daily", Reminder{Mode: modeDaily, Hour: 18, Min: 30}, "30 18 * * *"},
+		{"weekly", Reminder{Mode: modeWeekly, Days: "1,3,5", Hour: 8}, "0 8 * * 1,3,5"},
+		{"every 2 weeks", Reminder{Mode: modeWeekly, Days: "2", Hour: 8, EveryWeeks: 2}, "0 8 * * 2"},
+		{"monthly", Reminder{Mode: modeMonthly, MonthDay: 15, Hour: 12}, "0 12 15 * *"},
+		{"last day", Reminder{Mode: modeLastDay, Hour: 17, Min: 45}, "45 17 28-31 * *"},
+		{"once", Reminder{Mode: modeOnce, Until: &until, Hour: 6}, "0 6 14 7 *"},
+		{"interval", Reminder{Mode: modeInterval, IntervalMin: 90}, "@every 90m"},
+		{"cron", Reminder{Mode: modeCron, CronSpec: "*/10 9-17 * * 1-5"}, "*/10 9-17 * * 1-5"},
+		{"rrule", Reminder{Mode: modeRRule, RRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", Hour: 9}, "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"},
+		{"window", Reminder{Mode: modeWindow, WindowStart: 14 * 60, WindowEnd: 16*60 + 30}, "WINDOW:14:00-16:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.r.ID, tt.r.TZ = 1, "Europe/Paris"
+			got, opts, err := buildSpec(tt.r, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("spec = %q, want %q", got, tt.want)
+			}
+			if len(opts) != 1 {
+				t.Errorf("got %d options, want the location", len(opts))
+			}
+			if _, _, err := buildSchedule(tt.r, time.Now()); err != nil {
+				t.Errorf("buildSchedule: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildSpecInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Reminder
+		want string
+	}{
+		{"bad timezone", Reminder{TZ: "Mars/Olympus"}, "unknown time zone"},
+		{"weekly without days", Reminder{Mode: modeWeekly}, "no days"},
+		{"monthly day 0", Reminder{Mode: modeMonthly}, "has day 0"},
+		{"monthly day 32", Reminder{Mode: modeMonthly, MonthDay: 32}, "has day 32"},
+		{"once without date", Reminder{Mode: modeOnce}, "no date"},
+		{"interval 0", Reminder{Mode: modeInterval}, "interval 0"},
+		{"cron garbage", Reminder{Mode: modeCron, CronSpec: "every tuesday"}, "cron spec"},
+		{"cron six fields", Reminder{Mode: modeCron, CronSpec: "0 0 9 * * *"}, "cron spec"},
+		{"rrule", Reminder{Mode: modeRRule, RRule: "FREQ=HOURLY"}, "rrule"},
+		{"window backwards", Reminder{Mode: modeWindow, WindowStart: 16 * 60, WindowEnd: 14 * 60}, "window"},
+		{"unknown mode", Reminder{Mode: "fortnightly"}, "unknown mode"},
+		{"hour out of range", Reminder{Hour: 24}, "cron spec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.r.TZ == "" {
+				tt.r.TZ = "UTC"
+			}
+			_, _, err := buildSpec(tt.r, time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want one mentioning %q", err, tt.want)
+			}
+			if _, _, err := buildSchedule(tt.r, time.Now()); err == nil {
+				t.Error("buildSchedule accepted it")
+			}
+		})
+	}
+}
+
+func TestBuildSpecTakesTimezone(t *testing.T) {
+	r := Reminder{ID: 1, TZ: "Asia/Tokyo", Hour: 9}
+	sched, opts, err := buildSchedule(r, time.Now())
+	if err != nil || len(opts) != 1 {
+		t.Fatalf("buildSchedule = %v, %v", opts, err)
+	}
+	after := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC) // 09:00 in Tokyo
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	next := sched.Next(after.In(tokyo))
+	if want := time.Date(2026, 5, 5, 9, 0, 0, 0, tokyo); !next.Equal(want) {
+		t.Errorf("next = %s, want %s", next, want)
+	}
+}
+
+func TestNextFiresPerMode(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	from := time.Date(2026, 3, 2, 10, 0, 0, 0, loc) // a Monday
+	day := func(d, h, m int) time.Time { return time.Date(2026, 3, d, h, m, 0, 0, loc) }
+	tests := []struct {
+		name string
+		r    Reminder
+		want []time.Time
+	}{
+		{"daily", Reminder{Hour: 9}, []time.Time{day(3, 9, 0), day(4, 9, 0), day(5, 9, 0)}},
+		{"weekly", Reminder{Mode: modeWeekly, Days: "2,4", Hour: 9}, []time.Time{day(3, 9, 0), day(5, 9, 0), day(10, 9, 0)}},
+		{"interval", Reminder{Mode: modeInterval, IntervalMin: 45}, []time.Time{day(2, 10, 45), day(2, 11, 30), day(2, 12, 15)}},
+		{"rrule", Reminder{Mode: modeRRule, RRule: "FREQ=DAILY;INTERVAL=2", Hour: 7, CreatedAt: day(1, 12, 0)},
+			[]time.Time{day(3, 7, 0), day(5, 7, 0), day(7, 7, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.r.ID, tt.r.TZ = 1, "America/New_York"
+			got, err := nextFires(tt.r, from, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("fire %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFireGap(t *testing.T) {
+	old := minFireGap
+	t.Cleanup(func() { minFireGap = old })
+
+	minFireGap = 0
+	if got := fireGap(Reminder{}); got != 0 {
+		t.Errorf("no cooldown anywhere gives a gap of %s", got)
+	}
+	if got := fireGap(Reminder{MinGapMin: 45}); got != 45*time.Minute {
+		t.Errorf("the reminder's own cooldown gives %s, want 45m", got)
+	}
+	minFireGap = time.Hour
+	if got := fireGap(Reminder{MinGapMin: 45}); got != time.Hour {
+		t.Errorf("the bot-wide floor gives %s, want 1h", got)
+	}
+	if got := fireGap(Reminder{MinGapMin: 90}); got != 90*time.Minute {
+		t.Errorf("a longer cooldown than the floor gives %s, want 1h30m", got)
+	}
+}
+
+func TestTooSoon(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+	tests := []struct {
+		name string
+		last *time.Time
+		gap  time.Duration
+		want bool
+	}{
+		{"never fired", nil, time.Hour, false},
+		{"no gap", ago(time.Second), 0, false},
+		{"inside the gap", ago(59 * time.Minute), time.Hour, true},
+		{"exactly the gap", ago(time.Hour), time.Hour, false},
+		{"clock went back", ago(-time.Minute), time.Hour, true},
+	}
+	for _, tt := range tests {
+		if got := tooSoon(tt.last, now, tt.gap); got != tt.want {
+			t.Errorf("%s: tooSoon = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFireSkippedInsideCooldown(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-cooldown'`)
+	})
+	fc := newFakeClock(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, mode, cron_spec, min_gap_min, last_fired)
+		 VALUES ('test-cooldown', 'c1', 'g1', 'drink', 0, 0, 'UTC', true, 'cron', '* * * * *', 30, $1) RETURNING id`,
+		fc.Now().Add(-10*time.Minute)).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	s, f := newFakeDiscord(nil)
+	fire := func() {
+		r, err := loadReminder(ctx, db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fireReminder(db, fc, s, r, time.UTC)
+	}
+
+	fire()
+	if n := len(f.posts(t)); n != 0 {
+		t.Fatalf("posted %d times 10 minutes after the last fire", n)
+	}
+	fc.Advance(20 * time.Minute)
+	fire()
+	if n := len(f.posts(t)); n != 1 {
+		t.Errorf("posted %d times once the cooldown was over, want 1", n)
+	}
+}
+
+func TestLastDayOfMonth(t *testing.T) {
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"2026-02-28", true}, // 28 days
+		{"2026-02-27", false},
+		{"2028-02-28", false}, // leap year: 29 days
+		{"2028-02-29", true},
+		{"2026-04-30", true}, // 30 days
+		{"2026-04-29", false},
+		{"2026-05-30", false}, // 31 days
+		{"2026-05-31", true},
+		{"2026-12-31", true},
+	}
+	for _, tt := range tests {
+		d, _ := time.Parse("2006-01-02", tt.date)
+		if got := lastDayOfMonth(d.Add(9 * time.Hour)); got != tt.want {
+			t.Errorf("lastDayOfMonth(%s) = %t, want %t", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestLastDayFiresInOwnTimezone(t *testing.T) {
+	// 23:30 UTC on 30 April is already 1 May in Tokyo
+	r := Reminder{TZ: "Asia/Tokyo", Hour: 8, Mode: modeLastDay}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if onFireDay(r, time.Date(2026, 4, 30, 23, 30, 0, 0, time.UTC).In(tokyo)) {
+		t.Error("fired on 1 May Tokyo time")
+	}
+
+	times, err := nextFires(r, time.Date(2028, 2, 1, 0, 0, 0, 0, tokyo), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, at := range times {
+		got = append(got, at.Format("2006-01-02 15:04 MST"))
+	}
+	want := []string{"2028-02-29 08:00 JST", "2028-03-31 08:00 JST", "2028-04-30 08:00 JST"}
+	if !slices.Equal(got, want) {
+		t.Errorf("next fires = %v, want %v", got, want)
+	}
+}
+
+func TestOnWeek(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d.Add(9 * time.Hour)
+	}
+	tests := []struct {
+		anchor, date string
+		n            int
+		want         bool
+	}{
+		{"2026-10-14", "2026-10-12", 2, true}, // the anchor's own Monday
+		{"2026-10-14", "2026-10-18", 2, true}, // and its Sunday
+		{"2026-10-14", "2026-10-19", 2, false},
+		{"2026-10-14", "2026-10-26", 2, true}, // across the Paris DST change
+		{"2026-10-14", "2026-10-05", 2, false},
+		{"2026-10-14", "2026-09-28", 2, true}, // weeks before the anchor count too
+		{"2026-10-18", "2026-10-26", 3, false},
+		{"2026-10-18", "2026-11-01", 3, false},
+		{"2026-10-18", "2026-11-02", 3, true},
+		{"2026-12-28", "2027-01-03", 2, true}, // same week across New Year
+		{"2026-12-28", "2027-01-04", 2, false},
+		{"2026-12-28", "2027-01-11", 2, true},
+		{"2026-10-14", "2027-10-13", 4, true}, // 52 weeks on
+		{"2026-10-14", "2026-11-09", 4, true},
+		{"2026-10-14", "2026-11-02", 1, true},
+	}
+	for _, tt := range tests {
+		if got := onWeek(date(tt.date), date(tt.anchor), tt.n); got != tt.want {
+			t.Errorf("onWeek(%s, anchor %s, every %d) = %t, want %t", tt.date, tt.anchor, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEveryOtherWeekFires(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	anchor := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	r := Reminder{ID: 1, Mode: modeWeekly, Days: "5", Hour: 9, TZ: "Asia/Tokyo", EveryWeeks: 2, Anchor: &anchor}
+
+	// 00:30 on Monday 19th in Tokyo is still Sunday in UTC; the local date decides
+	if onFireDay(r, time.Date(2026, 10, 19, 0, 30, 0, 0, tokyo)) {
+		t.Error("the off week started late by the UTC date")
+	}
+
+	times, err := nextFires(r, time.Date(2026, 10, 14, 12, 0, 0, 0, tokyo), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, at := range times {
+		got = append(got, at.Format("2006-01-02 15:04"))
+	}
+	want := []string{"2026-10-16 09:00", "2026-10-30 09:00", "2026-11-13 09:00"}
+	if !slices.Equal(got, want) {
+		t.Errorf("next fires = %v, want %v", got, want)
+	}
+}
+
+func TestParseMonthDay(t *testing.T) {
+	tests := []struct {
+		raw     string
+		mode    string
+		day     int
+		wantErr bool
+	}{
+		{"last", modeLastDay, 0, false},
+		{" LAST ", modeLastDay, 0, false},
+		{"1", modeMonthly, 1, false},
+		{"31", modeMonthly, 31, false},
+		{"0", "", 0, true},
+		{"32", "", 0, true},
+		{"first", "", 0, true},
+	}
+	for _, tt := range tests {
+		mode, day, err := parseMonthDay(tt.raw)
+		if mode != tt.mode || day != tt.day || (err != nil) != tt.wantErr {
+			t.Errorf("parseMonthDay(%q) = %q, %d, %v; want %q, %d, error %t", tt.raw, mode, day, err, tt.mode, tt.day, tt.wantErr)
+		}
+	}
+}
+
+func TestShiftClock(t *testing.T) {
+	tests := []struct {
+		hour, min, offset int
+		wantH, wantM      int
+	}{
+		{9, 0, 30, 9, 30},
+		{23, 50, 30, 0, 20},  // past midnight
+		{0, 10, -30, 23, 40}, // back before midnight
+		{12, 0, 24*60 - 1, 11, 59},
+		{12, 0, -(24*60 - 1), 12, 1},
+		{8, 15, 0, 8, 15},
+	}
+	for _, tt := range tests {
+		h, m := shiftClock(tt.hour, tt.min, tt.offset)
+		if h != tt.wantH || m != tt.wantM {
+			t.Errorf("shiftClock(%02d:%02d, %+d) = %02d:%02d, want %02d:%02d", tt.hour, tt.min, tt.offset, h, m, tt.wantH, tt.wantM)
+		}
+	}
+}
+
+func TestShiftAcrossMidnight(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-shift'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-shift', 'c1', 'g1', 'lights out', 23, 50, 'UTC', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	s, f := newFakeDiscord(nil)
+	handleShift(ctx, db, realClock{}, s, slash("shift", "test-shift", "id", strconv.Itoa(id), "minutes", 30))
+	r, err := loadReminder(ctx, db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Hour != 0 || r.Min != 20 {
+		t.Errorf("shifted to %02d:%02d, want 00:20 (replies %q)", r.Hour, r.Min, f.replies(t))
+	}
+	cronsMu.Lock()
+	c := crons[id]
+	cronsMu.Unlock()
+	if c == nil {
+		t.Fatal("the shifted reminder wasn't rescheduled")
+	}
+	if next := c.Entries()[0].Schedule.Next(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)); !next.Equal(time.Date(2026, 5, 5, 0, 20, 0, 0, time.UTC)) {
+		t.Errorf("next fire %s, want 00:20 the next day", next)
+	}
+
+	// someone else can't shift it
+	s, f = newFakeDiscord(nil)
+	handleShift(ctx, db, realClock{}, s, slash("shift", "test-shift-intruder", "id", strconv.Itoa(id), "minutes", 60))
+	if got := f.replies(t); len(got) != 1 || got[0] != fmt.Sprintf("You don't have a reminder %d.", id) {
+		t.Errorf("intruder got %q", got)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	for _, c := range []struct {
+		hour, min int
+		h24, h12  string
+	}{
+		{0, 0, "00:00", "12:00 AM"},
+		{9, 5, "09:05", "9:05 AM"},
+		{12, 0, "12:00", "12:00 PM"},
+		{18, 30, "18:30", "6:30 PM"},
+		{23, 59, "23:59", "11:59 PM"},
+	} {
+		if got := formatClock(c.hour, c.min, false); got != c.h24 {
+			t.Errorf("formatClock(%d, %d, 24h) = %q, want %q", c.hour, c.min, got, c.h24)
+		}
+		if got := formatClock(c.hour, c.min, true); got != c.h12 {
+			t.Errorf("formatClock(%d, %d, 12h) = %q, want %q", c.hour, c.min, got, c.h12)
+		}
+	}
+}
+
+func TestDescribeScheduleClock(t *testing.T) {
+	weekly := Reminder{Mode: modeWeekly, Days: "1,5", Hour: 18, Min: 30, TZ: "America/Toronto"}
+	if got, want := describeScheduleClock(weekly, false), "every Mon, Fri at 18:30 America/Toronto"; got != want {
+		t.Errorf("24h: %q, want %q", got, want)
+	}
+	if got, want := describeScheduleClock(weekly, true), "every Mon, Fri at 6:30 PM America/Toronto"; got != want {
+		t.Errorf("12h: %q, want %q", got, want)
+	}
+	window := Reminder{Mode: modeWindow, WindowStart: 9 * 60, WindowEnd: 13*60 + 30, TZ: "UTC"}
+	if got, want := describeScheduleClock(window, true), "every day at a random time between 9:00 AM and 1:30 PM UTC"; got != want {
+		t.Errorf("12h window: %q, want %q", got, want)
+	}
+}
+
+func TestConvertTarget(t *testing.T) {
+	for _, c := range []struct {
+		mode, days, monthDay string
+		everyWeeks           int
+		wantMode, wantDays   string
+		wantMonthDay         int
+	}{
+		{modeDaily, "", "", 1, modeDaily, "", 0},
+		{modeDaily, "mon", "15", 1, modeDaily, "", 0}, // other modes' options are ignored
+		{modeWeekly, "fri, mon", "", 1, modeWeekly, "1,5", 0},
+		{modeWeekly, "tue", "", 2, modeWeekly, "2", 0},
+		{modeMonthly, "", "15", 1, modeMonthly, "", 15},
+		{modeMonthly, "", "last", 1, modeLastDay, "", 0},
+	} {
+		mode, days, monthDay, err := convertTarget(c.mode, c.days, c.monthDay, c.everyWeeks)
+		if err != nil || mode != c.wantMode || days != c.wantDays || monthDay != c.wantMonthDay {
+			t.Errorf("convertTarget(%q, %q, %q, %d) = %q, %q, %d, %v; want %q, %q, %d",
+				c.mode, c.days, c.monthDay, c.everyWeeks, mode, days, monthDay, err, c.wantMode, c.wantDays, c.wantMonthDay)
+		}
+	}
+}
+
+func TestConvertTargetNeedsParameters(t *testing.T) {
+	for _, c := range []struct {
+		mode, days, monthDay string
+		everyWeeks           int
+		want                 string
+	}{
+		{modeWeekly, "", "", 1, "Weekly reminders need days, e.g. mon, wed, fri."},
+		{modeWeekly, "funday", "", 1, `"funday" isn't a day of the week.`},
+		{modeMonthly, "", "", 1, `Monthly reminders need a monthday, 1 to 31 or "last".`},
+		{modeMonthly, "", "32", 1, `Day of the month must be 1 to 31, or "last".`},
+		{modeCron, "", "", 1, "Mode must be daily, weekly or monthly."},
+		{modeDaily, "", "", 2, "every_weeks only works with weekly reminders."},
+		{modeMonthly, "", "last", 3, "every_weeks only works with weekly reminders."},
+	} {
+		if _, _, _, err := convertTarget(c.mode, c.days, c.monthDay, c.everyWeeks); err == nil || err.Error() != c.want {
+			t.Errorf("convertTarget(%q, %q, %q, %d) = %v, want %q", c.mode, c.days, c.monthDay, c.everyWeeks, err, c.want)
+		}
+	}
+}
+
+func TestConvertBetweenModes(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() {
+		unschedule(id)
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-convert'`)
+	})
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-convert', 'c1', 'g1', 'water plants', 9, 0, 'UTC', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	wednesday := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		opts  []any
+		reply string
+		next  time.Time
+	}{
+		{[]any{"mode", "weekly", "days", "mon, fri"}, "now fires every Mon, Fri at 09:00 UTC ✅", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{[]any{"mode", "monthly", "monthday", "last"}, "now fires on the last day of every month at 09:00 UTC ✅", time.Date(2026, 10, 31, 9, 0, 0, 0, time.UTC)},
+		{[]any{"mode", "monthly", "monthday", "20"}, "now fires on day 20 of every month at 09:00 UTC ✅", time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		{[]any{"mode", "daily"}, "now fires every day at 09:00 UTC ✅", time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)},
+	} {
+		s, f := newFakeDiscord(nil)
+		handleConvert(ctx, db, realClock{}, s, slash("convert", "test-convert", append([]any{"id", strconv.Itoa(id)}, c.opts...)...))
+		if got := f.replies(t); len(got) != 1 || got[0] != fmt.Sprintf("Reminder %d %s", id, c.reply) {
+			t.Errorf("%v: replies = %q", c.opts, got)
+		}
+		r, err := loadReminder(ctx, db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, err := nextFire(r, wednesday)
+		if err != nil || !next.Equal(c.next) {
+			t.Errorf("%v: stored schedule next fires %v (%v), want %v", c.opts, next, err, c.next)
+		}
+		cronsMu.Lock()
+		runner := crons[id]
+		cronsMu.Unlock()
+		if runner == nil {
+			t.Fatalf("%v: not rescheduled", c.opts)
+		}
+	}
+
+	// a cron reminder has no daily time to keep
+	if _, err := db.Exec(ctx, `UPDATE reminders SET mode = 'cron', cron_spec = '*/5 * * * *' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+	s, f := newFakeDiscord(nil)
+	handleConvert(ctx, db, realClock{}, s, slash("convert", "test-convert", "id", strconv.Itoa(id), "mode", "daily"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "Only daily, weekly and monthly reminders can be converted." {
+		t.Errorf("cron reminder: replies = %q", got)
+	}
+}
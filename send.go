@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxExtraUsers caps how many users besides the owner one reminder pings.
+const maxExtraUsers = 10
+
+var mentionRe = regexp.MustCompile(`^<@!?(\d{17,20})>$`)
+var snowflakeRe = regexp.MustCompile(`^\d{17,20}$`)
+
+// parseUserList turns "<@1>, <@!2> 3" into user IDs, dropping duplicates
+// and the owner.
+func parseUserList(raw, owner string) ([]string, error) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	var ids []string
+	seen := map[string]bool{owner: true}
+	for _, f := range fields {
+		id := f
+		if m := mentionRe.FindStringSubmatch(f); m != nil {
+			id = m[1]
+		} else if !snowflakeRe.MatchString(f) {
+			return nil, fmt.Errorf("%q isn't a user mention.", f)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) > maxExtraUsers {
+		return nil, fmt.Errorf("A reminder can ping at most %d other users.", maxExtraUsers)
+	}
+	return ids, nil
+}
+
+// messageFlags are the flags every message of r is posted with.
+func (r Reminder) messageFlags() discordgo.MessageFlags {
+	if r.Silent {
+		return discordgo.MessageFlagsSuppressNotifications
+	}
+	return 0
+}
+
+// Reminder priorities. High stands out in the channel; low still names
+// its users but doesn't ping them.
+const (
+	priorityLow    = "low"
+	priorityNormal = "normal"
+	priorityHigh   = "high"
+)
+
+// priorityOrNormal maps the zero priority to priorityNormal for storage.
+func priorityOrNormal(p string) string {
+	if p == "" {
+		return priorityNormal
+	}
+	return p
+}
+
+// mentions lists every user r names, owner first.
+func (r Reminder) mentions() []string {
+	return append([]string{r.UserID}, r.Extra...)
+}
+
+// pinged is who actually gets notified when r is posted.
+func (r Reminder) pinged() []string {
+	if r.Priority == priorityLow {
+		return nil
+	}
+	return r.mentions()
+}
+
+// renderReminder builds the text posted when r fires, addressing the
+// owner as name if it isn't empty. For polls the question is in the poll
+// itself, so only the mentions are rendered.
+func renderReminder(r Reminder, name string) string {
+	var b strings.Builder
+	for _, id := range r.mentions() {
+		b.WriteString("<@" + id + "> ")
+	}
+	if r.Poll == nil {
+		if r.Priority == priorityHigh {
+			b.WriteString("🔴 ")
+		}
+		if name != "" {
+			// raw is about the message; a nickname is always escaped
+			b.WriteString("Hey " + escapeMessage(name) + ", ")
+		}
+		msg := r.Message
+		if !r.RawMarkdown {
+			msg = escapeMessage(msg)
+		}
+		if r.Priority == priorityHigh {
+			b.WriteString("**" + msg + "**")
+		} else {
+			b.WriteString(msg)
+		}
+		if r.ShowCount {
+			b.WriteString(" " + fireCounter(r))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// inlineEscaper defuses the markdown that lets a message break out of
+// its formatting or pass for something it isn't: code fences, masked
+// links and mentions written out by hand (which AllowedMentions keeps
+// silent but Discord still renders).
+var inlineEscaper = strings.NewReplacer(
+	"```", "\\`\\`\\`",
+	"](", "]\\(",
+	"<@", "\\<@",
+	"@everyone", "@\u200beveryone",
+	"@here", "@\u200bhere",
+)
+
+// escapeMessage makes a user's message safe to post in a shared channel
+// unless they asked for raw markdown: besides inlineEscaper, headings and
+// subtext at the start of a line are shown as plain text. Bold, italics
+// and the like are left alone.
+func escapeMessage(msg string) string {
+	lines := strings.Split(inlineEscaper.Replace(msg), "\n")
+	for i, l := range lines {
+		t := strings.TrimLeft(l, " ")
+		if strings.HasPrefix(t, "#") || strings.HasPrefix(t, "-#") {
+			lines[i] = l[:len(l)-len(t)] + "\\" + t
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxDeleteAfterMin caps /remind's delete_after at a day.
+const maxDeleteAfterMin = 24 * 60
+
+// deleteAfter deletes m, the first post of a fire of reminder id, once
+// ttl has passed. The timer lives in memory only, so a restart in between
+// leaves the post up. A post someone already deleted is fine.
+func deleteAfter(s *discordgo.Session, id int, m *discordgo.Message, ttl time.Duration) {
+	time.AfterFunc(ttl, func() {
+		err := s.ChannelMessageDelete(m.ChannelID, m.ID)
+		if err != nil && discordErrCode(err) != discordgo.ErrCodeUnknownMessage {
+			log.Printf("delete post of reminder %d: %v", id, err)
+		}
+	})
+}
+
+// displayName is how userID appears in guildID: their server nickname,
+// else their global or user name. It's empty if they've left the guild or
+// can't be looked up, so the greeting is just dropped.
+func displayName(s *discordgo.Session, guildID, userID string) string {
+	if guildID == "" {
+		return ""
+	}
+	m, err := s.State.Member(guildID, userID)
+	if err != nil {
+		if m, err = s.GuildMember(guildID, userID); err != nil {
+			if discordErrCode(err) != discordgo.ErrCodeUnknownMember {
+				log.Printf("look up member %s in %s: %v", userID, guildID, err)
+			}
+			return ""
+		}
+	}
+	return m.DisplayName()
+}
+
+// maxMessageLen is Discord's limit on a message's content.
+const maxMessageLen = 2000
+
+// splitMessage cuts content into chunks of at most limit bytes, preferring
+// to break after a newline, then after a space, and never inside a rune.
+func splitMessage(content string, limit int) []string {
+	var chunks []string
+	for len(content) > limit {
+		cut := strings.LastIndexByte(content[:limit], '\n') + 1
+		if cut == 0 {
+			cut = strings.LastIndexByte(content[:limit], ' ') + 1
+		}
+		if cut == 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(content[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, content[:cut])
+		content = content[cut:]
+	}
+	return append(chunks, content)
+}
+
+// truncate shortens s to at most limit bytes, ending in "…" when it had
+// to cut, and never inside a rune.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// delivery is how one send of a reminder goes out, beyond the reminder
+// itself.
+type delivery struct {
+	greet    bool      // address the owner by display name
+	hook     *webhook  // post through this webhook instead of as the bot
+	prefix   string    // prepended to the rendered text
+	replyTo  string    // message to reply to, in r's channel
+	fallback string    // where to post if r's channel is off limits: a channel ID, fallbackDM or ""
+	lang     string    // the owner's language, for localizeMessage
+	at       time.Time // when the fire is, for localizeMessage
+	text     string    // the post as rendered by renderFire, "" = render it when sending
+}
+
+// fallbackDM as a fallback sends the reminder to its owner's DMs.
+const fallbackDM = "dm"
+
+// loadDelivery looks up how a fire of r at at should be delivered. A
+// webhook that can't be loaded is logged and r goes out as the bot
+// instead.
+func loadDelivery(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, r Reminder, at time.Time) delivery {
+	d := delivery{at: at}
+	_ = db.QueryRow(ctx,
+		`SELECT greet_nickname, COALESCE(fallback_channel, '') FROM guild_prefs WHERE guild_id=$1`,
+		r.GuildID).Scan(&d.greet, &d.fallback)
+	d.lang = userLanguage(ctx, db, r.UserID)
+	if r.WebhookName != "" {
+		hook, err := channelWebhook(ctx, db, s, r.ChannelID, false)
+		if err != nil {
+			log.Printf("load webhook for reminder %d: %v", r.ID, err)
+		}
+		d.hook = hook
+	}
+	return d
+}
+
+// dryRun makes sendReminder log reminders instead of posting them, for
+// staging against real data. Everything else runs as usual.
+var dryRun bool
+
+// renderFire is the full text a fire of r posts with d: the greeting, the
+// message localized for the owner and the fire time, and d's prefix.
+func renderFire(s *discordgo.Session, r Reminder, d delivery) string {
+	name := ""
+	if d.greet {
+		name = displayName(s, r.GuildID, r.UserID)
+	}
+	if loc, err := time.LoadLocation(r.TZ); err == nil {
+		r.Message = localizeMessage(r.Message, d.lang, d.at.In(loc))
+	}
+	return d.prefix + renderReminder(r, name)
+}
+
+// sendReminder posts r to its channel, only allowing the reminder's own
+// users to be pinged. Content over Discord's length limit goes out as
+// several messages, and only the first one pings. If the channel is a
+// thread that has been archived, the thread is reopened first, or failing
+// that the reminder goes to the thread's parent channel. A webhook
+// delivery that fails outright falls back to posting as the bot. If the
+// bot has lost access to the channel, the reminder goes to the server's
+// fallback instead, when one is set. A reminder moved to DMs posts there,
+// never through a webhook. The first message posted is
+// returned; in a dry run nothing is posted and it's nil.
+func sendReminder(s *discordgo.Session, r Reminder, d delivery) (*discordgo.Message, error) {
+	if d.text == "" {
+		d.text = renderFire(s, r, d)
+	}
+	chunks := splitMessage(d.text, maxMessageLen)
+	if dryRun {
+		for _, c := range chunks {
+			log.Printf("DRY RUN: would send reminder %d to %s: %q", r.ID, r.ChannelID, c)
+		}
+		return nil, nil
+	}
+
+	channelID, err := postChannel(s, r)
+	if err != nil {
+		return nil, err
+	}
+	if channelID != r.ChannelID {
+		d.hook = nil // webhooks only post in server channels
+	}
+
+	if d.hook != nil {
+		first, err := sendWebhook(s, r, d.hook, chunks[:1])
+		if err == nil {
+			_, err = sendWebhook(s, r, d.hook, chunks[1:])
+			return first, err
+		}
+		logRepeated("webhook send reminder %d, posting as bot: %v", r.ID, err)
+	}
+
+	msgs := make([]*discordgo.MessageSend, len(chunks))
+	for i, c := range chunks {
+		msgs[i] = &discordgo.MessageSend{Content: c, AllowedMentions: &discordgo.MessageAllowedMentions{}, Flags: r.messageFlags()}
+	}
+	msgs[0].AllowedMentions.Users = r.pinged()
+	msgs[0].Components = firedButtons(r)
+	if r.Poll != nil {
+		msgs[0].Poll = buildPoll(*r.Poll)
+	}
+	if d.replyTo != "" {
+		msgs[0].Reference = replyReference(channelID, d.replyTo)
+	}
+
+	first, err := postMessage(s, channelID, msgs[0])
+	if discordErrCode(err) == discordgo.ErrCodePerformedOperationOnArchivedThread {
+		archived := false
+		if _, uerr := s.ChannelEditComplex(channelID, &discordgo.ChannelEdit{Archived: &archived}); uerr == nil {
+			first, err = postMessage(s, channelID, msgs[0])
+		} else if thread, cerr := s.Channel(channelID); cerr == nil && thread.ParentID != "" {
+			channelID = thread.ParentID
+			msgs[0].Reference = nil // the previous fire is in the thread
+			first, err = postMessage(s, channelID, msgs[0])
+		}
+	}
+	if isAccessError(err) && d.fallback != "" {
+		target := d.fallback
+		if target == fallbackDM {
+			ch, cerr := s.UserChannelCreate(r.UserID)
+			if cerr != nil {
+				return nil, err
+			}
+			target = ch.ID
+		}
+		logRepeated("reminder %d can't post in %s, using fallback %s: %v", r.ID, r.ChannelID, d.fallback, err)
+		if note := fmt.Sprintf("(I can't post in <#%s> any more) ", r.ChannelID); len(note)+len(msgs[0].Content) <= maxMessageLen {
+			msgs[0].Content = note + msgs[0].Content
+		}
+		msgs[0].Reference = nil
+		channelID = target
+		first, err = postMessage(s, channelID, msgs[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range msgs[1:] {
+		if _, err := postMessage(s, channelID, m); err != nil {
+			return first, err
+		}
+	}
+	return first, nil
+}
+
+// postMessage posts m in channelID once sendLimit lets it through. Every
+// post of a fire goes through here, retries and fallbacks included, so
+// each one counts against the limit.
+func postMessage(s *discordgo.Session, channelID string, m *discordgo.MessageSend) (*discordgo.Message, error) {
+	sendLimit.take(1)
+	return s.ChannelMessageSendComplex(channelID, m)
+}
+
+// maxMirrors caps how many extra channels one reminder posts in.
+const maxMirrors = 5
+
+// mirrorsOrEmpty stores a nil mirror list as an empty array, as the
+// column requires.
+func mirrorsOrEmpty(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+// mirrorReminder posts a fire of r in each of its mirror channels as well,
+// as the bot and without replying or falling back. A mirror failing is
+// only logged; the main channel's send is what counts for r.
+func mirrorReminder(s *discordgo.Session, r Reminder, d delivery) {
+	d.hook, d.replyTo, d.fallback = nil, "", ""
+	for _, ch := range r.Mirrors {
+		if _, err := sendReminder(s, mirrorCopy(r, ch), d); err != nil {
+			logRepeated("mirror reminder %d to %s: %v", r.ID, ch, err)
+		}
+	}
+}
+
+// mirrorCopy is r as posted in the mirror channel ch. Mirrors always post
+// in their channel, also when r itself was moved to DMs.
+func mirrorCopy(r Reminder, ch string) Reminder {
+	r.ChannelID = ch
+	r.Delivery = deliverChannel
+	return r
+}
+
+// isAccessError reports whether err is Discord refusing the bot access to
+// a channel that still exists.
+func isAccessError(err error) bool {
+	switch discordErrCode(err) {
+	case discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+		return true
+	}
+	var rerr *discordgo.RESTError
+	return errors.As(err, &rerr) && rerr.Response != nil && rerr.Response.StatusCode == http.StatusForbidden
+}
+
+// discordErrCode extracts Discord's JSON error code from a REST error, or
+// 0 if err isn't one.
+func discordErrCode(err error) int {
+	var rerr *discordgo.RESTError
+	if errors.As(err, &rerr) && rerr.Message != nil {
+		return rerr.Message.Code
+	}
+	return 0
+}
@@ -0,0 +1,717 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  string
+	}{
+		{"fits", "hello", 5, "hello"},
+		{"ascii", "hello world", 8, "hello…"},
+		{"accent at the cut", "café crème", 7, "caf…"},
+		{"emoji at the cut", "ab🐸🐸", 8, "ab…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.in, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+			}
+			if len(got) > tt.limit || !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q: %d bytes, valid UTF-8 %t", tt.in, tt.limit, got, len(got), utf8.ValidString(got))
+			}
+		})
+	}
+}
+
+func TestTruncateLongMessage(t *testing.T) {
+	msg := strings.Repeat("é", maxMessageLen)
+	got := truncate(msg, maxMessageLen)
+	if len(got) > maxMessageLen || !utf8.ValidString(got) || !strings.HasSuffix(got, "…") {
+		t.Errorf("got %d bytes, valid %t", len(got), utf8.ValidString(got))
+	}
+}
+
+// discordCall is one REST request the bot made to fakeDiscord.
+type discordCall struct {
+	Method, Path, Query string
+	Body                []byte
+}
+
+// fakeDiscord stands in for Discord's REST API. reply picks the status
+// and JSON body for each request; nil or a zero status gets a plausible
+// success.
+type fakeDiscord struct {
+	mu    sync.Mutex
+	calls []discordCall
+	reply func(c discordCall) (int, any)
+}
+
+func (f *fakeDiscord) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	c := discordCall{Method: req.Method, Path: strings.TrimPrefix(req.URL.Path, "/api/v"+discordgo.APIVersion), Query: req.URL.RawQuery, Body: body}
+	f.mu.Lock()
+	f.calls = append(f.calls, c)
+	n := len(f.calls)
+	f.mu.Unlock()
+
+	status, v := 0, any(nil)
+	if f.reply != nil {
+		status, v = f.reply(c)
+	}
+	if status == 0 {
+		status, v = http.StatusOK, defaultReply(c, n)
+	}
+	out, _ := json.Marshal(v)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(out)),
+		Request:    req,
+	}, nil
+}
+
+// defaultReply is what Discord would say to c going through: a message
+// for a post, a DM channel dm-<user> for opening one.
+func defaultReply(c discordCall, n int) any {
+	parts := strings.Split(strings.Trim(c.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "channels" && parts[2] == "messages":
+		return map[string]any{"id": fmt.Sprintf("m%d", n), "channel_id": parts[1]}
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "channels":
+		var body struct {
+			RecipientID string `json:"recipient_id"`
+		}
+		json.Unmarshal(c.Body, &body)
+		return map[string]any{"id": "dm-" + body.RecipientID, "type": 1}
+	}
+	return map[string]any{}
+}
+
+// posts are the messages posted, by channel ID in order.
+func (f *fakeDiscord) posts(t *testing.T) []postedMessage {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []postedMessage
+	for _, c := range f.calls {
+		parts := strings.Split(strings.Trim(c.Path, "/"), "/")
+		if c.Method != http.MethodPost || len(parts) != 3 || parts[0] != "channels" || parts[2] != "messages" {
+			continue
+		}
+		m := postedMessage{ChannelID: parts[1]}
+		if err := json.Unmarshal(c.Body, &m); err != nil {
+			t.Fatalf("decode post to %s: %v", parts[1], err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// postedMessage is the part of a posted message the tests look at.
+type postedMessage struct {
+	ChannelID       string                            `json:"-"`
+	Content         string                            `json:"content"`
+	Flags           discordgo.MessageFlags            `json:"flags"`
+	AllowedMentions *discordgo.MessageAllowedMentions `json:"allowed_mentions"`
+	Reference       *discordgo.MessageReference       `json:"message_reference"`
+	Components      []json.RawMessage                 `json:"components"`
+	Poll            *discordgo.Poll                   `json:"poll"`
+}
+
+// newFakeDiscord is a session whose REST calls all go to a fakeDiscord.
+func newFakeDiscord(reply func(c discordCall) (int, any)) (*discordgo.Session, *fakeDiscord) {
+	f := &fakeDiscord{reply: reply}
+	s, _ := discordgo.New("Bot test")
+	s.Client = &http.Client{Transport: f}
+	s.MaxRestRetries = 0
+	s.ShouldRetryOnRateLimit = false
+	return s, f
+}
+
+// discordError is the body Discord sends with a failed request.
+func discordError(code int, msg string) map[string]any {
+	return map[string]any{"code": code, "message": msg}
+}
+
+func TestParseUserList(t *testing.T) {
+	const owner = "100000000000000001"
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"<@200000000000000002> <@!300000000000000003>", []string{"200000000000000002", "300000000000000003"}, false},
+		{"<@200000000000000002>, 300000000000000003,<@200000000000000002>", []string{"200000000000000002", "300000000000000003"}, false},
+		{"<@100000000000000001> <@200000000000000002>", []string{"200000000000000002"}, false},
+		{"@bob", nil, true},
+		{"<@&400000000000000004>", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseUserList(tt.raw, owner)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("parseUserList(%q) = %v, %v; want %v, error %t", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	var many []string
+	for i := range maxExtraUsers + 1 {
+		many = append(many, fmt.Sprintf("<@2000000000000000%02d>", i))
+	}
+	if _, err := parseUserList(strings.Join(many, " "), owner); err == nil {
+		t.Errorf("%d extra users were accepted", maxExtraUsers+1)
+	}
+}
+
+func TestRenderMultipleMentions(t *testing.T) {
+	r := Reminder{UserID: "1", Extra: []string{"2", "3"}, Message: "stand-up"}
+	if got, want := renderReminder(r, ""), "<@1> <@2> <@3> stand-up"; got != want {
+		t.Errorf("renderReminder = %q, want %q", got, want)
+	}
+	if got := r.pinged(); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("pinged = %v, want all three", got)
+	}
+}
+
+func TestSendPingsEveryMentionedUser(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Extra: []string{"2", "3"}, Message: "stand-up", TZ: "UTC"}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	posts := f.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(posts))
+	}
+	if got := posts[0].AllowedMentions.Users; !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("allowed mentions = %v, want all three users", got)
+	}
+}
+
+func TestSendReopensArchivedThread(t *testing.T) {
+	var posts int
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Method == http.MethodPost && c.Path == "/channels/10/messages" {
+			if posts++; posts == 1 {
+				return http.StatusBadRequest, discordError(discordgo.ErrCodePerformedOperationOnArchivedThread, "Thread is archived")
+			}
+		}
+		return 0, nil
+	})
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "hi", TZ: "UTC"}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var unarchived bool
+	for _, c := range f.calls {
+		if c.Method == http.MethodPatch && c.Path == "/channels/10" && strings.Contains(string(c.Body), `"archived":false`) {
+			unarchived = true
+		}
+	}
+	if !unarchived {
+		t.Error("the thread wasn't unarchived")
+	}
+	if got := f.posts(t); len(got) != 2 || got[1].ChannelID != "10" {
+		t.Errorf("posts = %+v, want the retry in the thread", got)
+	}
+}
+
+// The retry into a reopened thread is a send of its own and waits for
+// sendLimit like the first try did.
+func TestSendRetryTakesSendBudget(t *testing.T) {
+	old := sendLimit
+	t.Cleanup(func() { sendLimit = old })
+	sendLimit = newTokenBucket(10)
+	sendLimit.take(10) // drain the burst
+
+	var posts int
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Method == http.MethodPost && c.Path == "/channels/10/messages" {
+			if posts++; posts == 1 {
+				return http.StatusBadRequest, discordError(discordgo.ErrCodePerformedOperationOnArchivedThread, "Thread is archived")
+			}
+		}
+		return 0, nil
+	})
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "hi", TZ: "UTC"}
+	start := time.Now()
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(f.posts(t)); got != 2 {
+		t.Fatalf("%d posts, want the first try and the retry", got)
+	}
+	// two sends at 10/s is 200ms; counting only the first would be 100ms
+	if d := time.Since(start); d < 170*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("send with a retry took %v, want about 200ms", d)
+	}
+}
+
+func TestSendFallsBackToThreadParent(t *testing.T) {
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		switch {
+		case c.Method == http.MethodPost && c.Path == "/channels/10/messages":
+			return http.StatusBadRequest, discordError(discordgo.ErrCodePerformedOperationOnArchivedThread, "Thread is archived")
+		case c.Method == http.MethodPatch && c.Path == "/channels/10":
+			return http.StatusForbidden, discordError(discordgo.ErrCodeMissingPermissions, "Missing Permissions")
+		case c.Method == http.MethodGet && c.Path == "/channels/10":
+			return http.StatusOK, map[string]any{"id": "10", "parent_id": "5", "type": 11}
+		}
+		return 0, nil
+	})
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "hi", TZ: "UTC", LastMessageID: "99"}
+	if _, err := sendReminder(s, r, delivery{replyTo: "99"}); err != nil {
+		t.Fatal(err)
+	}
+	got := f.posts(t)
+	if len(got) != 2 || got[1].ChannelID != "5" {
+		t.Fatalf("posts = %+v, want the second in parent channel 5", got)
+	}
+	if got[1].Reference != nil {
+		t.Error("the post in the parent still replies to a message in the thread")
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		switch c.Path {
+		case "/guilds/g1/members/u2":
+			return http.StatusOK, map[string]any{"user": map[string]any{"id": "u2", "username": "piggy", "global_name": "Miss Piggy"}}
+		case "/guilds/g1/members/u3":
+			return http.StatusNotFound, discordError(discordgo.ErrCodeUnknownMember, "Unknown Member")
+		}
+		return 0, nil
+	})
+	if err := s.State.GuildAdd(&discordgo.Guild{ID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.State.MemberAdd(&discordgo.Member{GuildID: "g1", Nick: "Kermit", User: &discordgo.User{ID: "u1", Username: "kermit"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		guild, user, want string
+	}{
+		{"g1", "u1", "Kermit"},     // nickname, from the state cache
+		{"g1", "u2", "Miss Piggy"}, // no nickname, looked up
+		{"g1", "u3", ""},           // left the server
+		{"", "u1", ""},             // not in a server at all
+	}
+	for _, tt := range tests {
+		if got := displayName(s, tt.guild, tt.user); got != tt.want {
+			t.Errorf("displayName(%q, %q) = %q, want %q", tt.guild, tt.user, got, tt.want)
+		}
+	}
+	for _, c := range f.calls {
+		if c.Path == "/guilds/g1/members/u1" {
+			t.Error("a cached member was looked up over REST")
+		}
+	}
+}
+
+func TestSendGreetsByName(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	s.State.GuildAdd(&discordgo.Guild{ID: "g1"})
+	s.State.MemberAdd(&discordgo.Member{GuildID: "g1", Nick: "Kermit", User: &discordgo.User{ID: "u1"}})
+	r := Reminder{ID: 1, ChannelID: "10", GuildID: "g1", UserID: "u1", Message: "water the plants", TZ: "UTC"}
+
+	if _, err := sendReminder(s, r, delivery{greet: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	posts := f.posts(t)
+	if len(posts) != 2 {
+		t.Fatalf("posted %d messages, want 2", len(posts))
+	}
+	if want := "<@u1> Hey Kermit, water the plants"; posts[0].Content != want {
+		t.Errorf("greeted post = %q, want %q", posts[0].Content, want)
+	}
+	if want := "<@u1> water the plants"; posts[1].Content != want {
+		t.Errorf("plain post = %q, want %q", posts[1].Content, want)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		want    []string
+	}{
+		{"fits", "hello", 5, []string{"hello"}},
+		{"at a newline", "one two\nthree", 10, []string{"one two\n", "three"}},
+		{"at a space", "one two three", 10, []string{"one two ", "three"}},
+		{"mid word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"never inside a rune", "aéé", 4, []string{"aé", "é"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitMessage(tt.content, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", tt.content, tt.limit, got, tt.want)
+			}
+			for _, c := range got {
+				if len(c) > tt.limit || !utf8.ValidString(c) {
+					t.Errorf("chunk %q: %d bytes, valid UTF-8 %t", c, len(c), utf8.ValidString(c))
+				}
+			}
+		})
+	}
+}
+
+func TestSendSplitsJustOverTheLimit(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	// "<@1> " plus the message comes to the limit plus one byte
+	msg := strings.Repeat("word ", (maxMessageLen-len("<@1> "))/5) + "x"
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: msg, TZ: "UTC", RawMarkdown: true}
+	if n := len("<@1> " + msg); n != maxMessageLen+1 {
+		t.Fatalf("test message renders to %d bytes, want %d", n, maxMessageLen+1)
+	}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := f.posts(t)
+	if len(posts) != 2 {
+		t.Fatalf("posted %d messages, want 2", len(posts))
+	}
+	if got := posts[0].Content + posts[1].Content; got != "<@1> "+msg {
+		t.Error("the two posts don't add up to the whole reminder")
+	}
+	if !slices.Equal(posts[0].AllowedMentions.Users, []string{"1"}) {
+		t.Errorf("first post pings %v, want the owner", posts[0].AllowedMentions.Users)
+	}
+	if len(posts[1].AllowedMentions.Users) != 0 {
+		t.Errorf("second post pings %v, want nobody", posts[1].AllowedMentions.Users)
+	}
+}
+
+func TestSendSilent(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: strings.Repeat("quiet ", maxMessageLen/5), TZ: "UTC", Silent: true}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	r.Silent = false
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := f.posts(t)
+	if len(posts) != 4 {
+		t.Fatalf("posted %d messages, want each reminder in two parts", len(posts))
+	}
+	for i, p := range posts {
+		silent := p.Flags&discordgo.MessageFlagsSuppressNotifications != 0
+		if want := i < 2; silent != want {
+			t.Errorf("post %d silent = %t, want %t", i, silent, want)
+		}
+	}
+	// silent still names the owner, it just doesn't push a notification
+	if !strings.HasPrefix(posts[0].Content, "<@1> ") || !slices.Equal(posts[0].AllowedMentions.Users, []string{"1"}) {
+		t.Errorf("silent post = %q pinging %v, want the owner mentioned", posts[0].Content, posts[0].AllowedMentions.Users)
+	}
+}
+
+func TestRenderPriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     string
+		pinged   []string
+	}{
+		{priorityHigh, "<@1> <@2> 🔴 **stand-up**", []string{"1", "2"}},
+		{priorityNormal, "<@1> <@2> stand-up", []string{"1", "2"}},
+		{"", "<@1> <@2> stand-up", []string{"1", "2"}},
+		{priorityLow, "<@1> <@2> stand-up", nil}, // named, but nobody is notified
+	}
+	for _, tt := range tests {
+		r := Reminder{UserID: "1", Extra: []string{"2"}, Message: "stand-up", Priority: tt.priority}
+		if got := renderReminder(r, ""); got != tt.want {
+			t.Errorf("%q: renderReminder = %q, want %q", tt.priority, got, tt.want)
+		}
+		if got := r.pinged(); !slices.Equal(got, tt.pinged) {
+			t.Errorf("%q: pinged = %v, want %v", tt.priority, got, tt.pinged)
+		}
+	}
+
+	r := Reminder{UserID: "1", Message: "deploy", Priority: priorityHigh}
+	if got, want := renderReminder(r, "Kermit"), "<@1> 🔴 Hey Kermit, **deploy**"; got != want {
+		t.Errorf("high priority with a greeting = %q, want %q", got, want)
+	}
+	if got := priorityOrNormal(""); got != priorityNormal {
+		t.Errorf("priorityOrNormal(\"\") = %q", got)
+	}
+}
+
+func TestSendLowPriorityPingsNobody(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "fyi", TZ: "UTC", Priority: priorityLow}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	p := f.posts(t)[0]
+	if p.Content != "<@1> fyi" || p.AllowedMentions == nil || len(p.AllowedMentions.Users) != 0 {
+		t.Errorf("posted %q allowing mentions %+v", p.Content, p.AllowedMentions)
+	}
+}
+
+// forbidden answers every post to channel 10 with a 403, as Discord does
+// once the bot has lost access to it.
+func forbidden(c discordCall) (int, any) {
+	if c.Method == http.MethodPost && c.Path == "/channels/10/messages" {
+		return http.StatusForbidden, discordError(discordgo.ErrCodeMissingAccess, "Missing Access")
+	}
+	return 0, nil
+}
+
+func TestSendFallsBackOnForbidden(t *testing.T) {
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "standup", TZ: "UTC", LastMessageID: "99"}
+	tests := []struct {
+		fallback, channel string
+	}{
+		{"20", "20"},
+		{fallbackDM, "dm-1"},
+	}
+	for _, tt := range tests {
+		s, f := newFakeDiscord(forbidden)
+		if _, err := sendReminder(s, r, delivery{fallback: tt.fallback, replyTo: "99"}); err != nil {
+			t.Fatalf("fallback %s: %v", tt.fallback, err)
+		}
+		posts := f.posts(t)
+		if len(posts) != 2 || posts[1].ChannelID != tt.channel {
+			t.Fatalf("fallback %s: posts %+v, want the retry in %s", tt.fallback, posts, tt.channel)
+		}
+		if want := "(I can't post in <#10> any more) <@1> standup"; posts[1].Content != want {
+			t.Errorf("fallback %s: posted %q, want %q", tt.fallback, posts[1].Content, want)
+		}
+		if posts[1].Reference != nil {
+			t.Errorf("fallback %s: replies to a message in the lost channel", tt.fallback)
+		}
+	}
+}
+
+func TestSendWithoutFallbackFails(t *testing.T) {
+	s, f := newFakeDiscord(forbidden)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "standup", TZ: "UTC"}
+	if _, err := sendReminder(s, r, delivery{}); !isAccessError(err) {
+		t.Errorf("err = %v, want the access error", err)
+	}
+	if n := len(f.posts(t)); n != 1 {
+		t.Errorf("%d posts, want just the failed one", n)
+	}
+}
+
+func TestIsAccessError(t *testing.T) {
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		switch c.Path {
+		case "/channels/perm/messages":
+			return http.StatusForbidden, discordError(discordgo.ErrCodeMissingPermissions, "Missing Permissions")
+		case "/channels/bare/messages":
+			return http.StatusForbidden, map[string]any{}
+		case "/channels/gone/messages":
+			return http.StatusNotFound, discordError(discordgo.ErrCodeUnknownChannel, "Unknown Channel")
+		}
+		return 0, nil
+	})
+	for ch, want := range map[string]bool{"perm": true, "bare": true, "gone": false, "ok": false} {
+		_, err := s.ChannelMessageSend(ch, "hi")
+		if got := isAccessError(err); got != want {
+			t.Errorf("%s: isAccessError(%v) = %t, want %t", ch, err, got, want)
+		}
+	}
+}
+
+func TestSendDryRunPostsNothing(t *testing.T) {
+	dryRun = true
+	t.Cleanup(func() { dryRun = false })
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 7, ChannelID: "c1", UserID: "u1", Message: "stand up"}
+	for _, d := range []delivery{
+		{},
+		{greet: true},
+		{hook: &webhook{ID: "w1", Token: "wt"}},
+		{replyTo: "99", fallback: fallbackDM},
+	} {
+		m, err := sendReminder(s, r, d)
+		if err != nil || m != nil {
+			t.Errorf("%+v: sendReminder = %v, %v; want nil, nil", d, m, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c.Method != http.MethodGet {
+			t.Errorf("dry run called Discord: %s %s", c.Method, c.Path)
+		}
+	}
+	if n := strings.Count(logs.String(), `DRY RUN: would send reminder 7 to c1: "<@u1> stand up"`); n != 4 {
+		t.Errorf("logged %d would-sends, want one per delivery:\n%s", n, logs.String())
+	}
+}
+
+func TestMirrorFanOut(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// mirror 300 is gone; the others still get their copy
+	s, f := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Path == "/channels/300/messages" {
+			return http.StatusNotFound, discordError(discordgo.ErrCodeUnknownChannel, "Unknown Channel")
+		}
+		if strings.HasPrefix(c.Path, "/webhooks/") {
+			t.Errorf("mirror posted through the webhook: %s", c.Path)
+		}
+		return 0, nil
+	})
+	r := Reminder{ID: 31, ChannelID: "100", UserID: "7", Message: "deploy freeze", Delivery: deliverDM,
+		Mirrors: []string{"200", "300", "400"}}
+	mirrorReminder(s, r, delivery{hook: &webhook{ID: "w", Token: "t"}, replyTo: "m9", fallback: fallbackDM})
+
+	posts := f.posts(t)
+	var channels []string
+	for _, p := range posts {
+		channels = append(channels, p.ChannelID)
+		if p.Reference != nil {
+			t.Errorf("mirror in %s replied to %s", p.ChannelID, p.Reference.MessageID)
+		}
+		if !strings.Contains(p.Content, "deploy freeze") {
+			t.Errorf("mirror in %s posted %q", p.ChannelID, p.Content)
+		}
+	}
+	// 300 was tried, failed, and didn't fall back to the owner's DMs
+	if !slices.Equal(channels, []string{"200", "300", "400"}) {
+		t.Errorf("posted in %v, want each mirror once", channels)
+	}
+	if !strings.Contains(logs.String(), "mirror reminder 31 to 300") {
+		t.Errorf("the failed mirror wasn't logged:\n%s", logs.String())
+	}
+}
+
+func TestEscapeMessage(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"plain text", "plain text"},
+		{"**bold** _it_ ~~gone~~ `code`", "**bold** _it_ ~~gone~~ `code`"},
+		{"```\nfake system message\n```", "\\`\\`\\`\nfake system message\n\\`\\`\\`"},
+		{"[click here](https://evil.example)", "[click here]\\(https://evil.example)"},
+		{"ping <@123> and <@&456>", "ping \\<@123> and \\<@&456>"},
+		{"@everyone look", "@\u200beveryone look"},
+		{"hey @here", "hey @\u200bhere"},
+		{"# Server rules\n  ## changed\n-# from the mods", "\\# Server rules\n  \\## changed\n\\-# from the mods"},
+		{"issue #42 is fixed", "issue #42 is fixed"},
+	} {
+		if got := escapeMessage(c.in); got != c.want {
+			t.Errorf("escapeMessage(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRenderReminderRawMarkdown(t *testing.T) {
+	r := Reminder{UserID: "1", Message: "# Deploy\n@here [notes](https://example.com)"}
+	if got := renderReminder(r, ""); got != "<@1> \\# Deploy\n@\u200bhere [notes]\\(https://example.com)" {
+		t.Errorf("escaped render = %q", got)
+	}
+	r.RawMarkdown = true
+	if got := renderReminder(r, ""); got != "<@1> # Deploy\n@here [notes](https://example.com)" {
+		t.Errorf("raw render = %q", got)
+	}
+	// the owner's own mention is added by the bot, never escaped
+	r.RawMarkdown, r.Message = false, "hi"
+	if got := renderReminder(r, ""); got != "<@1> hi" {
+		t.Errorf("mention render = %q", got)
+	}
+	// raw covers the message, not the nickname
+	r.RawMarkdown = true
+	if got, want := renderReminder(r, "[boss](https://x.example) @everyone"), "<@1> Hey [boss]\\(https://x.example) @\u200beveryone, hi"; got != want {
+		t.Errorf("greeting with a hostile nickname = %q, want %q", got, want)
+	}
+}
+
+func TestDeleteAfterDelay(t *testing.T) {
+	deleted := make(chan time.Time, 1)
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Method == http.MethodDelete && c.Path == "/channels/c1/messages/m5" {
+			deleted <- time.Now()
+			return http.StatusNoContent, nil
+		}
+		return 0, nil
+	})
+
+	const ttl = 150 * time.Millisecond
+	start := time.Now()
+	deleteAfter(s, 1, &discordgo.Message{ID: "m5", ChannelID: "c1"}, ttl)
+	select {
+	case at := <-deleted:
+		if d := at.Sub(start); d < ttl || d > ttl+200*time.Millisecond {
+			t.Errorf("deleted after %v, want %v", d, ttl)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the post was never deleted")
+	}
+}
+
+func TestDeleteAfterAlreadyGone(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	done := make(chan struct{}, 2)
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Method != http.MethodDelete {
+			return 0, nil
+		}
+		defer func() { done <- struct{}{} }()
+		if strings.HasSuffix(c.Path, "/gone") {
+			return http.StatusNotFound, discordError(discordgo.ErrCodeUnknownMessage, "Unknown Message")
+		}
+		return http.StatusForbidden, discordError(discordgo.ErrCodeMissingPermissions, "Missing Permissions")
+	})
+	deleteAfter(s, 8, &discordgo.Message{ID: "gone", ChannelID: "c1"}, 0)
+	deleteAfter(s, 9, &discordgo.Message{ID: "kept", ChannelID: "c1"}, 0)
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("a delete never ran")
+		}
+	}
+	time.Sleep(20 * time.Millisecond) // the log line follows the response
+
+	if strings.Contains(logs.String(), "reminder 8") {
+		t.Errorf("an already deleted post was logged as a failure:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "delete post of reminder 9") {
+		t.Errorf("a refused delete wasn't logged:\n%s", logs.String())
+	}
+}
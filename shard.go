@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// shards holds one gateway session per shard, in shard order. Unless
+// SHARD_COUNT says otherwise there is exactly one.
+var shards []*discordgo.Session
+
+// openShards connects count sessions, shard i of count each, running
+// setup on every session before it opens. On error the sessions already
+// opened are closed again.
+func openShards(token string, count int, timeout time.Duration, setup func(*discordgo.Session)) ([]*discordgo.Session, error) {
+	ss := make([]*discordgo.Session, 0, count)
+	for i := range count {
+		s, err := discordgo.New("Bot " + token)
+		if err != nil {
+			closeShards(ss)
+			return nil, err
+		}
+		s.Client.Timeout = timeout
+		s.ShardID, s.ShardCount = i, count
+		setup(s)
+		if err := s.Open(); err != nil {
+			closeShards(ss)
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+func closeShards(ss []*discordgo.Session) {
+	for _, s := range ss {
+		s.Close()
+	}
+}
+
+// shardIndex is Discord's shard for a guild, (guild_id >> 22) % count.
+// DMs and unparseable IDs go to shard 0, which is the one Discord sends
+// DM events to.
+func shardIndex(guildID string, count int) int {
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil || count < 2 {
+		return 0
+	}
+	return int((id >> 22) % uint64(count))
+}
+
+// sessionFor returns the session that owns guildID's state, or s when the
+// bot isn't sharded.
+func sessionFor(guildID string, s *discordgo.Session) *discordgo.Session {
+	if len(shards) < 2 {
+		return s
+	}
+	return shards[shardIndex(guildID, len(shards))]
+}
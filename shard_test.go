@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestShardIndex(t *testing.T) {
+	for _, c := range []struct {
+		guild string
+		count int
+		want  int
+	}{
+		{"20971520", 3, 2}, // 5<<22
+		{"20971520", 5, 0},
+		{"20971519", 3, 1}, // one under 5<<22 still shifts to 4
+		{"16777216", 3, 1}, // 4<<22
+		{"41771983423143937", 1, 0},
+		{"", 4, 0},
+		{"not-a-guild", 4, 0},
+	} {
+		if got := shardIndex(c.guild, c.count); got != c.want {
+			t.Errorf("shardIndex(%q, %d) = %d, want %d", c.guild, c.count, got, c.want)
+		}
+	}
+}
+
+func TestSessionFor(t *testing.T) {
+	old := shards
+	t.Cleanup(func() { shards = old })
+
+	fallback := &discordgo.Session{}
+	shards = nil
+	if got := sessionFor("20971520", fallback); got != fallback {
+		t.Fatal("unsharded bot should use the calling session")
+	}
+
+	shards = []*discordgo.Session{{ShardID: 0}, {ShardID: 1}, {ShardID: 2}}
+	if got := sessionFor("20971520", fallback); got.ShardID != 2 {
+		t.Fatalf("guild 5<<22 went to shard %d, want 2", got.ShardID)
+	}
+	if got := sessionFor("", fallback); got.ShardID != 0 {
+		t.Fatalf("DM went to shard %d, want 0", got.ShardID)
+	}
+}
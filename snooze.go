@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxSnooze = 7 * 24 * time.Hour
+
+// scheduleOneOff runs fn once at `at` by clk, or right away if that has
+// passed.
+func scheduleOneOff(clk Clock, at time.Time, fn func()) *time.Timer {
+	return time.AfterFunc(max(at.Sub(clk.Now()), 0), fn)
+}
+
+// snoozeUntil is the next occurrence of hour:min in now's location: today
+// if that's still ahead, otherwise tomorrow.
+func snoozeUntil(now time.Time, hour, min int) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, now.Location())
+	if !t.After(now) {
+		t = time.Date(now.Year(), now.Month(), now.Day()+1, hour, min, 0, 0, now.Location())
+	}
+	return t
+}
+
+// armSnooze sends r once at `at`, unless it has been stopped or its
+// server paused by then, and then drops the persisted snooze.
+func armSnooze(db *pgxpool.Pool, clk Clock, s *discordgo.Session, snoozeID int, r Reminder, at time.Time) {
+	scheduleOneOff(clk, at, func() {
+		ctx, cancel := dbCtx()
+		defer cancel()
+		// stopped or paused since it was snoozed: drop the resend
+		var active, paused bool
+		_ = db.QueryRow(ctx,
+			`SELECT r.active, COALESCE(g.paused, false)
+			   FROM reminders r
+			   LEFT JOIN guild_prefs g ON g.guild_id = r.guild_id
+			  WHERE r.id=$1`, r.ID).Scan(&active, &paused)
+		if active && !paused {
+			if _, err := sendReminder(s, r, loadDelivery(ctx, db, s, r, at)); err != nil {
+				log.Printf("send snoozed reminder %d: %v", r.ID, err)
+			}
+		}
+		ctx, cancel = dbCtx()
+		defer cancel()
+		if _, err := db.Exec(ctx,
+			`DELETE FROM snoozes WHERE id=$1`, snoozeID); err != nil {
+			log.Printf("clear snooze %d: %v", snoozeID, err)
+		}
+	})
+}
+
+// snooze persists a one-off resend of r at `at` and arms it. Stopped
+// reminders can't be snoozed.
+func snooze(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, r Reminder, at time.Time) error {
+	if !r.Active {
+		return errInactive
+	}
+	var snoozeID int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO snoozes (reminder_id, fire_at) VALUES ($1,$2) RETURNING id`,
+		r.ID, at).Scan(&snoozeID); err != nil {
+		return err
+	}
+	armSnooze(db, clk, s, snoozeID, r, at)
+	return nil
+}
+
+// restoreSnoozes re-arms snoozes that were pending when the bot stopped.
+// Ones that came due while it was down are sent straight away.
+func restoreSnoozes(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session) {
+	type pending struct {
+		id, reminderID int
+		at             time.Time
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := db.Query(ctx,
+		`SELECT id, reminder_id, fire_at FROM snoozes`)
+	if err != nil {
+		log.Printf("restore snoozes: %v", err)
+		return
+	}
+	var ps []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.reminderID, &p.at); err != nil {
+			continue
+		}
+		ps = append(ps, p)
+	}
+	rows.Close()
+
+	for _, p := range ps {
+		r, err := loadReminder(ctx, db, p.reminderID)
+		if err != nil {
+			continue
+		}
+		armSnooze(db, clk, s, p.id, r, p.at)
+	}
+}
+
+// handleSnooze sends a reminder once more, either after a duration ("for")
+// or at the next HH:MM in the reminder's timezone ("until").
+func handleSnooze(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var forStr, untilStr string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "for":
+			forStr = opt.StringValue() // "30m"
+		case "until":
+			untilStr = opt.StringValue() // "17:00"
+		}
+	}
+	if (forStr == "") == (untilStr == "") {
+		respond(s, ic, "Give either for (e.g. 30m) or until (HH:MM).")
+		return
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		respond(s, ic, "That reminder has an invalid timezone.")
+		return
+	}
+
+	now := clk.Now().In(loc)
+	var at time.Time
+	if forStr != "" {
+		d, err := time.ParseDuration(forStr)
+		if err != nil || d < time.Minute || d > maxSnooze {
+			respond(s, ic, "Snooze for a duration like 30m or 2h, up to 168h.")
+			return
+		}
+		at = now.Add(d)
+	} else {
+		hour, min, err := parseClock(untilStr)
+		if err != nil {
+			respond(s, ic, err.Error())
+			return
+		}
+		at = snoozeUntil(now, hour, min)
+	}
+
+	if err := snooze(ctx, db, clk, s, r, at); errors.Is(err, errInactive) {
+		respond(s, ic, fmt.Sprintf("Reminder %d is stopped, so there's nothing to snooze.", r.ID))
+		return
+	} else if err != nil {
+		respondErr(s, ic, "saving your snooze", err)
+		return
+	}
+
+	respond(s, ic, fmt.Sprintf("💤 I’ll remind you again at %s (%s).",
+		at.Format("Mon 15:04"), r.TZ))
+}
+
+// testFireDelay is how long after /testfire the test send happens.
+const testFireDelay = time.Minute
+
+// handleTestFire sends a reminder once, a minute from now, exactly as it
+// would normally go out, so the owner can check the channel and the
+// formatting. The regular schedule and fire count are untouched.
+func handleTestFire(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	id, ok := resolveRef(ctx, db, s, ic, ic.ApplicationCommandData().Options[0].StringValue())
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	at := clk.Now().Add(testFireDelay)
+	scheduleOneOff(clk, at, func() {
+		ctx, cancel := dbCtx()
+		defer cancel()
+		if _, err := sendReminder(s, r, loadDelivery(ctx, db, s, r, at)); err != nil {
+			log.Printf("test fire reminder %d: %v", r.ID, err)
+			if derr := sendDM(s, r.UserID, fmt.Sprintf("⚠️ The test of reminder %d couldn't be sent: %v", r.ID, err)); derr != nil {
+				log.Printf("test fire DM for reminder %d: %v", r.ID, derr)
+			}
+		}
+	})
+
+	respond(s, ic, fmt.Sprintf("🧪 Reminder %d will be sent to <#%s> <t:%d:R>, just this once.", id, r.ChannelID, at.Unix()))
+}
+
+// buttonSnooze is how long the Snooze button on a fired reminder waits.
+const buttonSnooze = 15 * time.Minute
+
+// firedButtons are attached to every fired reminder so its users can
+// snooze or dismiss it without typing a command, next to its link if it
+// has one.
+func firedButtons(r Reminder) []discordgo.MessageComponent {
+	id := strconv.Itoa(r.ID)
+	row := []discordgo.MessageComponent{
+		discordgo.Button{Label: "Snooze 15m", Emoji: &discordgo.ComponentEmoji{Name: "💤"},
+			Style: discordgo.SecondaryButton, CustomID: "fired:snooze:" + id},
+		discordgo.Button{Label: "Dismiss", Emoji: &discordgo.ComponentEmoji{Name: ackEmoji},
+			Style: discordgo.SecondaryButton, CustomID: "fired:dismiss:" + id},
+	}
+	if link, ok := linkButton(r); ok {
+		row = append(row, link)
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: row}}
+}
+
+// firedButton handles Snooze and Dismiss on a fired reminder. Only the
+// users the reminder names may press them. Dismiss counts as the ✅
+// acknowledgement and takes the buttons away.
+func firedButton(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	action, rawID, _ := strings.Cut(strings.TrimPrefix(ic.MessageComponentData().CustomID, "fired:"), ":")
+	id, _ := strconv.Atoi(rawID)
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	reply := func(msg string) {
+		s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	// pressed in DMs when the reminder was moved there with /dmize
+	presser := ic.User
+	if ic.Member != nil {
+		presser = ic.Member.User
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || presser == nil || !slices.Contains(r.mentions(), presser.ID) {
+		reply("That reminder isn't for you.")
+		return
+	}
+
+	switch action {
+	case "snooze":
+		at := clk.Now().Add(buttonSnooze)
+		if err := snooze(ctx, db, clk, s, r, at); err != nil {
+			log.Printf("button snooze reminder %d: %v", r.ID, err)
+			reply("Sorry, I couldn't snooze that. Try /snooze instead.")
+			return
+		}
+		reply(fmt.Sprintf("💤 I’ll remind you again <t:%d:R>.", at.Unix()))
+	case "dismiss":
+		if err := acknowledge(ctx, db, ic.Message.ID); err != nil {
+			log.Printf("dismiss reminder %d: %v", r.ID, err)
+		}
+		if err := recordAck(ctx, db, clk, r); err != nil {
+			log.Printf("streak for reminder %d: %v", r.ID, err)
+		}
+		// the link stays, it's still useful after the reminder is done
+		kept := []discordgo.MessageComponent{}
+		if link, ok := linkButton(r); ok {
+			kept = append(kept, discordgo.ActionsRow{Components: []discordgo.MessageComponent{link}})
+		}
+		s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: ic.Message.Content, Components: kept},
+		})
+	}
+}
+
+// restOfToday picks, out of rs, the reminders with a fire still to come
+// today as of now, each in its own timezone.
+func restOfToday(rs []Reminder, now time.Time) []Reminder {
+	var today []Reminder
+	for _, r := range rs {
+		loc, err := time.LoadLocation(r.TZ)
+		if err != nil {
+			continue
+		}
+		next, err := nextFire(r, now)
+		if err != nil {
+			continue
+		}
+		if localDate(next).Equal(localDate(now.In(loc))) {
+			today = append(today, r)
+		}
+	}
+	return today
+}
+
+// handleSnoozeAll asks before holding every one of the caller's
+// reminders still due today; snoozeAllButton does the holding.
+func handleSnoozeAll(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	rs, err := userReminders(ctx, db, ic.Member.User.ID)
+	if err != nil {
+		respondErr(s, ic, "listing your reminders", err)
+		return
+	}
+	n := len(restOfToday(rs, clk.Now()))
+	if n == 0 {
+		respond(s, ic, "None of your reminders fires again today.")
+		return
+	}
+	respondWith(s, ic, &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Skip the next fire of your %d reminders still due today? They pick up again after that.", n),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Skip them", Style: discordgo.DangerButton, CustomID: "snoozeall:yes"},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "snoozeall:no"},
+			}},
+		},
+	})
+}
+
+// snoozeAllButton handles the /snoozeall confirmation. The prompt is
+// ephemeral, so whoever presses it is the user who asked. Which
+// reminders are due today is worked out again, as time has passed.
+func snoozeAllButton(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	msg := "Nothing was changed."
+	if ic.MessageComponentData().CustomID == "snoozeall:yes" {
+		ctx, cancel := dbCtx()
+		defer cancel()
+		if n, err := skipRestOfToday(ctx, db, clk, ic.Member.User.ID); err != nil {
+			log.Printf("snooze all for %s: %v", ic.Member.User.ID, err)
+			msg = "Sorry, I couldn't hold your reminders. Try again later."
+		} else {
+			msg = fmt.Sprintf("💤 Holding %d reminders until tomorrow.", n)
+		}
+	}
+	empty := []discordgo.MessageComponent{}
+	s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: msg, Components: empty},
+	})
+}
+
+// skipRestOfToday marks userID's reminders still due today to skip their
+// next fire, and returns how many it marked.
+func skipRestOfToday(ctx context.Context, db *pgxpool.Pool, clk Clock, userID string) (int, error) {
+	rs, err := userReminders(ctx, db, userID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for _, r := range restOfToday(rs, clk.Now()) {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tag, err := db.Exec(ctx,
+		`UPDATE reminders SET skip_next = true WHERE id = ANY($1) AND user_id = $2`, ids, userID)
+	return int(tag.RowsAffected()), err
+}
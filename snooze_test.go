@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSnoozeUntil(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, loc)
+	tests := []struct {
+		hour, min int
+		want      time.Time
+	}{
+		{15, 0, time.Date(2026, 3, 2, 15, 0, 0, 0, loc)},
+		{14, 30, time.Date(2026, 3, 3, 14, 30, 0, 0, loc)},
+		{9, 0, time.Date(2026, 3, 3, 9, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := snoozeUntil(now, tt.hour, tt.min); !got.Equal(tt.want) {
+			t.Errorf("snoozeUntil(%02d:%02d) = %s, want %s", tt.hour, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestSnoozeUntilAcrossMonthAndDST(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Paris")
+	// Paris moves its clocks forward overnight on 29 March 2026, so the
+	// next 08:00 is 22 hours away rather than 23
+	now := time.Date(2026, 3, 28, 9, 0, 0, 0, loc)
+	got := snoozeUntil(now, 8, 0)
+	if want := time.Date(2026, 3, 29, 8, 0, 0, 0, loc); !got.Equal(want) || got.Sub(now) != 22*time.Hour {
+		t.Errorf("snoozeUntil over the DST change = %s (%s away), want %s", got, got.Sub(now), want)
+	}
+
+	now = time.Date(2026, 4, 30, 23, 0, 0, 0, loc)
+	if got, want := snoozeUntil(now, 7, 15), time.Date(2026, 5, 1, 7, 15, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("snoozeUntil at the end of April = %s, want %s", got, want)
+	}
+}
+
+func TestSnoozeRefusesStopped(t *testing.T) {
+	r := Reminder{ID: 1, TZ: "UTC"}
+	if err := snooze(context.Background(), nil, realClock{}, nil, r, time.Now().Add(time.Hour)); !errors.Is(err, errInactive) {
+		t.Fatalf("snooze of a stopped reminder = %v, want errInactive", err)
+	}
+}
+
+func TestRestOfToday(t *testing.T) {
+	// 10:00 in New York is 16:00 in Paris
+	ny, _ := time.LoadLocation("America/New_York")
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, ny)
+	rs := []Reminder{
+		{ID: 1, TZ: "America/New_York", Hour: 9, Active: true},    // done for today
+		{ID: 2, TZ: "America/New_York", Hour: 17, Active: true},   // later today
+		{ID: 3, TZ: "Europe/Paris", Hour: 18, Active: true},       // later today in Paris
+		{ID: 4, TZ: "Europe/Paris", Hour: 15, Active: true},       // done in Paris
+		{ID: 5, TZ: "Not/AZone", Hour: 23, Active: true},          // skipped
+		{ID: 6, TZ: "UTC", Mode: modeWeekly, Days: "2", Hour: 20}, // Tuesday only, today is Monday
+	}
+	var got []int
+	for _, r := range restOfToday(rs, now) {
+		got = append(got, r.ID)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("restOfToday = %v, want [2 3]", got)
+	}
+}
+
+func TestFiredButtons(t *testing.T) {
+	rows := firedButtons(Reminder{ID: 7})
+	if len(rows) != 1 {
+		t.Fatalf("%d rows, want 1", len(rows))
+	}
+	var ids []string
+	for _, c := range rows[0].(discordgo.ActionsRow).Components {
+		ids = append(ids, c.(discordgo.Button).CustomID)
+	}
+	if want := []string{"fired:snooze:7", "fired:dismiss:7"}; !slices.Equal(ids, want) {
+		t.Errorf("buttons = %v, want %v", ids, want)
+	}
+}
+
+func TestSendAttachesFiredButtons(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 7, ChannelID: "10", UserID: "1", Message: strings.Repeat("long ", maxMessageLen/4), TZ: "UTC"}
+	if _, err := sendReminder(s, r, delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	posts := f.posts(t)
+	if len(posts) != 2 {
+		t.Fatalf("posted %d messages, want 2", len(posts))
+	}
+	if len(posts[0].Components) != 1 || !strings.Contains(string(posts[0].Components[0]), `"custom_id":"fired:snooze:7"`) {
+		t.Errorf("first post components = %s", posts[0].Components)
+	}
+	if len(posts[1].Components) != 0 {
+		t.Error("the continuation has buttons too")
+	}
+}
+
+func TestFiredButtonPresses(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-fired'`)
+	})
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, extra_users)
+		 VALUES ('test-fired', 'c1', 'g1', 'standup', 9, 0, 'UTC', true, '{test-fired-extra}') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	snoozed := func() int {
+		var n int
+		if err := db.QueryRow(ctx, `SELECT count(*) FROM snoozes WHERE reminder_id=$1`, id).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	snoozeID, dismissID := fmt.Sprintf("fired:snooze:%d", id), fmt.Sprintf("fired:dismiss:%d", id)
+
+	s, f := newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(snoozeID, "stranger"))
+	if cbs := f.callbacks(t); len(cbs) != 1 || cbs[0].Data.Content != "That reminder isn't for you." || snoozed() != 0 {
+		t.Errorf("a stranger's press: %+v", cbs)
+	}
+
+	// anyone the reminder names may snooze it
+	s, f = newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(snoozeID, "test-fired-extra"))
+	if cbs := f.callbacks(t); len(cbs) != 1 || !strings.HasPrefix(cbs[0].Data.Content, "💤") || cbs[0].Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("snooze press: %+v", cbs)
+	}
+	if n := snoozed(); n != 1 {
+		t.Errorf("%d snoozes saved, want 1", n)
+	}
+
+	s, f = newFakeDiscord(nil)
+	onComponent(db, realClock{})(s, press(dismissID, "test-fired"))
+	cbs := f.callbacks(t)
+	if len(cbs) != 1 || cbs[0].Type != discordgo.InteractionResponseUpdateMessage || len(cbs[0].Data.Components) != 0 {
+		t.Errorf("dismiss press: %+v, want the buttons taken away", cbs)
+	}
+}
+
+func TestSnoozeAllSkipsOneFire(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id IN ('test-snoozeall', 'test-snoozeall-other')`)
+	})
+	fc := newFakeClock(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+
+	add := func(user string, hour int) int {
+		var id int
+		if err := db.QueryRow(ctx,
+			`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+			 VALUES ($1, 'c1', 'g1', 'stretch', $2, 0, 'UTC', true) RETURNING id`, user, hour).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	later, evening := add("test-snoozeall", 15), add("test-snoozeall", 18)
+	done := add("test-snoozeall", 9)
+	other := add("test-snoozeall-other", 15)
+
+	s, f := newFakeDiscord(nil)
+	onComponent(db, fc)(s, press("snoozeall:yes", "test-snoozeall"))
+	if cbs := f.callbacks(t); len(cbs) != 1 || cbs[0].Data.Content != "💤 Holding 2 reminders until tomorrow." {
+		t.Fatalf("confirmation: %+v", cbs)
+	}
+	skipping := func(id int) bool {
+		var skip bool
+		if err := db.QueryRow(ctx, `SELECT skip_next FROM reminders WHERE id=$1`, id).Scan(&skip); err != nil {
+			t.Fatal(err)
+		}
+		return skip
+	}
+	if skipping(done) || skipping(other) {
+		t.Error("held a reminder that's done for today or isn't the caller's")
+	}
+
+	for _, id := range []int{later, evening} {
+		fire := func() int {
+			r, err := loadReminder(ctx, db, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, f := newFakeDiscord(nil)
+			fireReminder(db, fc, s, r, time.UTC)
+			return len(f.posts(t))
+		}
+		if n := fire(); n != 0 {
+			t.Errorf("reminder %d posted %d times on its held fire", id, n)
+		}
+		if skipping(id) {
+			t.Errorf("reminder %d still skips after its held fire", id)
+		}
+		// a restart just after the held fire mustn't catch it up
+		if r, err := loadReminder(ctx, db, id); err != nil || r.LastFired == nil {
+			t.Errorf("reminder %d has no last fire after its held one: %v", id, err)
+		} else if missed, ok := missedFire(r, fc.Now().Add(time.Minute), time.Hour); ok {
+			t.Errorf("reminder %d would catch up the held fire at %s", id, missed)
+		}
+		fc.Advance(24 * time.Hour)
+		if n := fire(); n != 1 {
+			t.Errorf("reminder %d posted %d times the day after, want 1", id, n)
+		}
+	}
+}
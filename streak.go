@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// nextStreak is the streak after an ack on day, given the day of the
+// previous ack and the streak then. Another ack the same day changes
+// nothing, the next day extends it, and any gap starts over at 1.
+func nextStreak(last *time.Time, streak int, day time.Time) int {
+	switch {
+	case last == nil:
+		return 1
+	case last.Equal(day):
+		return max(streak, 1)
+	case last.AddDate(0, 0, 1).Equal(day):
+		return streak + 1
+	default:
+		return 1
+	}
+}
+
+// liveStreak is the streak as of today: a day that went by without an
+// ack has already broken it, even before the next ack resets it.
+func liveStreak(last *time.Time, streak int, today time.Time) int {
+	if last == nil || last.AddDate(0, 0, 1).Before(today) {
+		return 0
+	}
+	return streak
+}
+
+// recordAck counts an acknowledgement of r today, in r's timezone,
+// towards its streak.
+func recordAck(ctx context.Context, db *pgxpool.Pool, clk Clock, r Reminder) error {
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		return err
+	}
+	day := localDate(clk.Now().In(loc))
+
+	var last *time.Time
+	var streak, best int
+	if err := db.QueryRow(ctx,
+		`SELECT last_ack_date, current_streak, best_streak FROM reminders WHERE id=$1`,
+		r.ID).Scan(&last, &streak, &best); err != nil {
+		return err
+	}
+	streak = nextStreak(last, streak, day)
+	_, err = db.Exec(ctx,
+		`UPDATE reminders SET last_ack_date = $2, current_streak = $3, best_streak = $4 WHERE id=$1`,
+		r.ID, day, streak, max(best, streak))
+	return err
+}
+
+// handleStreak shows how many days in a row the caller has acknowledged
+// each of their reminders.
+func handleStreak(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	rows, err := db.Query(ctx,
+		`SELECT id, message, tz, last_ack_date, current_streak, best_streak
+		   FROM reminders
+		  WHERE user_id=$1 AND active AND best_streak > 0
+		  ORDER BY id`, ic.Member.User.ID)
+	if err != nil {
+		respondErr(s, ic, "loading your streaks", err)
+		return
+	}
+	defer rows.Close()
+
+	var b strings.Builder
+	for rows.Next() {
+		var id, streak, best int
+		var message, tz string
+		var last *time.Time
+		if err := rows.Scan(&id, &message, &tz, &last, &streak, &best); err != nil {
+			respondErr(s, ic, "loading your streaks", err)
+			return
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			continue
+		}
+		line := fmt.Sprintf("• **%d** %s: 🔥 %d (best %d)\n",
+			id, message, liveStreak(last, streak, localDate(clk.Now().In(loc))), best)
+		if b.Len()+len(line) > 1900 {
+			b.WriteString("…")
+			break
+		}
+		b.WriteString(line)
+	}
+	if err := rows.Err(); err != nil {
+		respondErr(s, ic, "loading your streaks", err)
+		return
+	}
+	if b.Len() == 0 {
+		respond(s, ic, "No streaks yet. React ✅ to a reminder, or press Dismiss, to start one.")
+		return
+	}
+	respond(s, ic, "Your streaks, in days in a row acknowledged:\n"+b.String())
+}
+
+// fireCounter is the "(day N)" appended to r's message when it shows its
+// count, N being the fire about to go out. The unit follows the schedule;
+// anything that isn't daily, weekly or monthly just gets a number.
+func fireCounter(r Reminder) string {
+	n := r.FireCount + 1
+	switch modeOrDaily(r.Mode) {
+	case modeDaily:
+		return fmt.Sprintf("(day %d)", n)
+	case modeWeekly:
+		return fmt.Sprintf("(week %d)", n)
+	case modeMonthly, modeLastDay:
+		return fmt.Sprintf("(month %d)", n)
+	default:
+		return fmt.Sprintf("(#%d)", n)
+	}
+}
+
+// handleDayCount turns a reminder's fire counter on or off.
+func handleDayCount(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var on bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "enabled":
+			on = opt.BoolValue()
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET show_count = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, on).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the counter setting", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if on {
+		respond(s, ic, fmt.Sprintf("Reminder %d will end with its count, next %s.", id, fireCounter(r)))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d won't show its count any more.", id))
+}
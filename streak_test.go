@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func ackDay(d int) *time.Time {
+	t := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestNextStreak(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		last   *time.Time
+		streak int
+		on     int
+		want   int
+	}{
+		{"first ack", nil, 0, 14, 1},
+		{"next day extends", ackDay(13), 4, 14, 5},
+		{"same day again", ackDay(14), 5, 14, 5},
+		{"same day after a reset row", ackDay(14), 0, 14, 1},
+		{"missed a day", ackDay(12), 4, 14, 1},
+		{"across a month end", ackDay(31), 2, 32, 3}, // Nov 1st
+	} {
+		if got := nextStreak(c.last, c.streak, *ackDay(c.on)); got != c.want {
+			t.Errorf("%s: nextStreak = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLiveStreak(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		last   *time.Time
+		streak int
+		want   int
+	}{
+		{"never acked", nil, 0, 0},
+		{"acked today", ackDay(14), 3, 3},
+		{"acked yesterday, today still open", ackDay(13), 3, 3},
+		{"missed yesterday", ackDay(12), 3, 0},
+	} {
+		if got := liveStreak(c.last, c.streak, *ackDay(14)); got != c.want {
+			t.Errorf("%s: liveStreak = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestStreakOverAWeek(t *testing.T) {
+	// acks on the 1st, 2nd, 3rd (twice), then the 5th and 6th
+	var last *time.Time
+	streak, best := 0, 0
+	for _, d := range []int{1, 2, 3, 3, 5, 6} {
+		streak = nextStreak(last, streak, *ackDay(d))
+		best = max(best, streak)
+		last = ackDay(d)
+	}
+	if streak != 2 || best != 3 {
+		t.Errorf("streak %d best %d, want 2 and 3", streak, best)
+	}
+}
+
+func TestRecordAck(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	var id int
+	t.Cleanup(func() { db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'test-streak'`) })
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('test-streak', 'c1', 'g1', 'stretch', 9, 0, 'Europe/Paris', true) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	r := Reminder{ID: id, TZ: "Europe/Paris"}
+	clk := newFakeClock(time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	check := func(step string, wantStreak, wantBest int) {
+		t.Helper()
+		if err := recordAck(ctx, db, clk, r); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		var streak, best int
+		db.QueryRow(ctx, `SELECT current_streak, best_streak FROM reminders WHERE id=$1`, id).Scan(&streak, &best)
+		if streak != wantStreak || best != wantBest {
+			t.Errorf("%s: streak %d best %d, want %d and %d", step, streak, best, wantStreak, wantBest)
+		}
+	}
+	check("first", 1, 1)
+	clk.Advance(24 * time.Hour)
+	check("next day", 2, 2)
+	clk.Set(time.Date(2026, 10, 13, 22, 30, 0, 0, time.UTC)) // already the 14th in Paris
+	check("after midnight in Paris", 3, 3)
+	clk.Set(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	check("after a missed day", 1, 3)
+}
+
+func TestFireCounter(t *testing.T) {
+	for _, c := range []struct {
+		r    Reminder
+		want string
+	}{
+		{Reminder{FireCount: 0}, "(day 1)"}, // rows from before modes are daily
+		{Reminder{Mode: modeDaily, FireCount: 41}, "(day 42)"},
+		{Reminder{Mode: modeWeekly, FireCount: 2}, "(week 3)"},
+		{Reminder{Mode: modeMonthly, FireCount: 11}, "(month 12)"},
+		{Reminder{Mode: modeLastDay, FireCount: 0}, "(month 1)"},
+		{Reminder{Mode: modeInterval, FireCount: 99}, "(#100)"},
+		{Reminder{Mode: modeRRule, FireCount: 4}, "(#5)"},
+	} {
+		if got := fireCounter(c.r); got != c.want {
+			t.Errorf("fireCounter(%q, %d fired) = %q, want %q", c.r.Mode, c.r.FireCount, got, c.want)
+		}
+	}
+}
+
+func TestRenderShowsCount(t *testing.T) {
+	r := Reminder{UserID: "1", Message: "Meditate", FireCount: 41}
+	if got := renderReminder(r, ""); got != "<@1> Meditate" {
+		t.Errorf("without the flag = %q", got)
+	}
+	r.ShowCount = true
+	if got := renderReminder(r, ""); got != "<@1> Meditate (day 42)" {
+		t.Errorf("with the flag = %q", got)
+	}
+	r.Priority = priorityHigh
+	if got := renderReminder(r, ""); got != "<@1> 🔴 **Meditate** (day 42)" {
+		t.Errorf("high priority = %q", got)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// templateColumns is the part of a reminder a template carries: what it
+// says and when, not who it's for or where it posts.
+const templateColumns = `message,hour,minute,tz,mode,days,month_day,interval_min,cron_spec,rrule,
+	window_start,window_end`
+
+// templateDest lists r's fields in templateColumns order.
+func templateDest(r *Reminder) []any {
+	return []any{&r.Message, &r.Hour, &r.Min, &r.TZ, &r.Mode, &r.Days, &r.MonthDay,
+		&r.IntervalMin, &r.CronSpec, &r.RRule, &r.WindowStart, &r.WindowEnd}
+}
+
+// handlePublish shares one of the caller's reminders with the server as a
+// template members can /subscribe to. Publishing under a name that's
+// taken replaces that template. Admin only.
+func handlePublish(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+	var ref, name string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "name":
+			name = strings.TrimSpace(opt.StringValue()) // "study"
+		}
+	}
+	if err := checkName(name); err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	if r.Mode == modeOnce || r.Poll != nil {
+		respond(s, ic, "Only repeating text reminders can be published.")
+		return
+	}
+
+	if _, err := db.Exec(ctx,
+		`INSERT INTO reminder_templates (guild_id, name, created_by, published, `+templateColumns+`)
+		 VALUES ($1,$2,$3,true,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+		 ON CONFLICT (guild_id, lower(name)) DO UPDATE SET
+		        name = EXCLUDED.name, created_by = EXCLUDED.created_by, published = true,
+		        message = EXCLUDED.message, hour = EXCLUDED.hour, minute = EXCLUDED.minute, tz = EXCLUDED.tz,
+		        mode = EXCLUDED.mode, days = EXCLUDED.days, month_day = EXCLUDED.month_day,
+		        interval_min = EXCLUDED.interval_min, cron_spec = EXCLUDED.cron_spec, rrule = EXCLUDED.rrule,
+		        window_start = EXCLUDED.window_start, window_end = EXCLUDED.window_end`,
+		ic.GuildID, name, r.UserID,
+		r.Message, r.Hour, r.Min, r.TZ, modeOrDaily(r.Mode), r.Days, r.MonthDay,
+		r.IntervalMin, r.CronSpec, r.RRule, r.WindowStart, r.WindowEnd); err != nil {
+		respondErr(s, ic, "publishing the template", err)
+		return
+	}
+	respond(s, ic, fmt.Sprintf("📋 Published reminder %d as %q. Members can get their own copy with /subscribe %s.", id, name, name))
+}
+
+// handleUnpublish takes a template off /templates. Copies already made
+// are left alone. Admin only.
+func handleUnpublish(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		respond(s, ic, "You need the Manage Server permission to do that.")
+		return
+	}
+	name := strings.TrimSpace(ic.ApplicationCommandData().Options[0].StringValue())
+	tag, err := db.Exec(ctx,
+		`UPDATE reminder_templates SET published = false
+		  WHERE guild_id = $1 AND lower(name) = lower($2) AND published`, ic.GuildID, name)
+	if err != nil {
+		respondErr(s, ic, "unpublishing the template", err)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		respond(s, ic, fmt.Sprintf("There's no published template called %q.", name))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Template %q is no longer offered. Existing copies keep running.", name))
+}
+
+// handleTemplates lists the server's published templates.
+func handleTemplates(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	rows, err := db.Query(ctx,
+		`SELECT name, `+templateColumns+` FROM reminder_templates
+		  WHERE guild_id = $1 AND published
+		  ORDER BY lower(name)`, ic.GuildID)
+	if err != nil {
+		respondErr(s, ic, "listing templates", err)
+		return
+	}
+	defer rows.Close()
+
+	h12 := uses12h(ctx, db, ic.Member.User.ID)
+	var b strings.Builder
+	n := 0
+	for rows.Next() {
+		var name string
+		var r Reminder
+		if err := rows.Scan(append([]any{&name}, templateDest(&r)...)...); err != nil {
+			respondErr(s, ic, "listing templates", err)
+			return
+		}
+		line := fmt.Sprintf("• **%s** %s: %s\n", name, describeScheduleClock(r, h12), r.Message)
+		if b.Len()+len(line) > 1800 {
+			b.WriteString("…and more")
+			break
+		}
+		b.WriteString(line)
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		respondErr(s, ic, "listing templates", err)
+		return
+	}
+	if n == 0 {
+		respond(s, ic, "This server hasn't published any templates.")
+		return
+	}
+	respond(s, ic, "Templates you can /subscribe to:\n"+b.String())
+}
+
+// handleSubscribe gives the caller their own copy of a published
+// template, posting in this channel. With a timezone it keeps the time of
+// day but in their zone.
+func handleSubscribe(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var name, tz string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "name":
+			name = strings.TrimSpace(opt.StringValue()) // "study"
+		case "timezone":
+			tz = opt.StringValue() // "Europe/Paris"
+		}
+	}
+
+	row := Reminder{UserID: ic.Member.User.ID, ChannelID: ic.ChannelID, GuildID: ic.GuildID, Active: true}
+	err := db.QueryRow(ctx,
+		`SELECT `+templateColumns+` FROM reminder_templates
+		  WHERE guild_id = $1 AND lower(name) = lower($2) AND published`,
+		ic.GuildID, name).Scan(templateDest(&row)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respond(s, ic, fmt.Sprintf("There's no template called %q here. /templates lists them.", name))
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "loading the template", err)
+		return
+	}
+	if tz != "" {
+		row.TZ = tz
+	}
+	loc, err := time.LoadLocation(row.TZ)
+	if err != nil {
+		respond(s, ic, invalidTZ(row.TZ))
+		return
+	}
+	if msg := checkPostChannel(s, ic, row.ChannelID); msg != "" {
+		respond(s, ic, msg)
+		return
+	}
+
+	if !saveNewReminder(ctx, db, clk, s, ic, &row, loc) {
+		return
+	}
+	log.Printf("%s subscribed to template %q in %s as reminder %d", row.UserID, name, row.GuildID, row.ID)
+	respond(s, ic, fmt.Sprintf("Subscribed ✅ I’ll remind you %s (ID %d). It’s yours now; /stop %d turns it off.",
+		describeScheduleClock(row, uses12h(ctx, db, row.UserID)), row.ID, row.ID))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPublishNeedsAdmin(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	handlePublish(context.Background(), nil, s, slash("publish", "u1", "id", "42", "name", "study"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "You need the Manage Server permission to do that." {
+		t.Errorf("replies = %q", got)
+	}
+}
+
+func TestSubscribeMakesOwnCopy(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		rows, _ := db.Query(context.Background(), `SELECT id FROM reminders WHERE user_id = 'test-subscriber'`)
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			unschedule(id)
+		}
+		rows.Close()
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id IN ('test-publisher', 'test-subscriber')`)
+		db.Exec(context.Background(), `DELETE FROM reminder_templates WHERE created_by = 'test-publisher'`)
+	})
+
+	var orig int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active, mode, days)
+		 VALUES ('test-publisher', 'c-announce', 'g1', 'study block', 7, 30, 'Europe/Paris', true, 'weekly', '1,3') RETURNING id`).Scan(&orig); err != nil {
+		t.Fatal(err)
+	}
+	s, f := postingState(t)
+	if err := s.State.MemberAdd(&discordgo.Member{GuildID: "g1", User: &discordgo.User{ID: "test-subscriber"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	publish := slash("publish", "test-publisher", "id", strconv.Itoa(orig), "name", "Study")
+	publish.Member.Permissions = discordgo.PermissionManageServer
+	handlePublish(ctx, db, s, publish)
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], "📋 Published reminder") {
+		t.Fatalf("publish replies = %q", got)
+	}
+
+	s, f = postingState(t)
+	s.State.MemberAdd(&discordgo.Member{GuildID: "g1", User: &discordgo.User{ID: "test-subscriber"}})
+	handleSubscribe(ctx, db, realClock{}, s, slash("subscribe", "test-subscriber", "name", "study", "timezone", "Asia/Tokyo"))
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], "Subscribed ✅") {
+		t.Fatalf("subscribe replies = %q", got)
+	}
+
+	var copyID, hour, min int
+	var channel, guild, message, tz, mode, days string
+	var active bool
+	if err := db.QueryRow(ctx,
+		`SELECT id, channel_id, guild_id, message, hour, minute, tz, mode, days, active
+		   FROM reminders WHERE user_id = 'test-subscriber'`).Scan(
+		&copyID, &channel, &guild, &message, &hour, &min, &tz, &mode, &days, &active); err != nil {
+		t.Fatalf("no copy saved: %v", err)
+	}
+	if copyID == orig || channel != "c1" || guild != "g1" || !active {
+		t.Errorf("copy %d posts in %s/%s, active %t; want a new reminder in g1/c1", copyID, guild, channel, active)
+	}
+	if message != "study block" || hour != 7 || min != 30 || tz != "Asia/Tokyo" || mode != modeWeekly || days != "1,3" {
+		t.Errorf("copy says %q at %02d:%02d %s, %s %s", message, hour, min, tz, mode, days)
+	}
+	if _, ok := scheduledNext(copyID); !ok {
+		t.Error("the copy wasn't scheduled")
+	}
+
+	var owner, origTZ string
+	if err := db.QueryRow(ctx, `SELECT user_id, tz FROM reminders WHERE id=$1`, orig).Scan(&owner, &origTZ); err != nil {
+		t.Fatal(err)
+	}
+	if owner != "test-publisher" || origTZ != "Europe/Paris" {
+		t.Errorf("the published reminder changed: %s in %s", owner, origTZ)
+	}
+
+	// an unpublished template can't be subscribed to
+	unpublish := slash("unpublish", "test-publisher", "name", "Study")
+	unpublish.Member.Permissions = discordgo.PermissionManageServer
+	s, _ = newFakeDiscord(nil)
+	handleUnpublish(ctx, db, s, unpublish)
+	s, f = postingState(t)
+	handleSubscribe(ctx, db, realClock{}, s, slash("subscribe", "test-subscriber", "name", "study"))
+	if got := f.replies(t); len(got) != 1 || !strings.HasPrefix(got[0], `There's no template called "study" here.`) {
+		t.Errorf("subscribe after unpublish replies = %q", got)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const tzPageSize = 40
+
+// matchZones returns the bundled zone names starting with prefix, ignoring
+// case. An empty prefix matches everything.
+func matchZones(prefix string) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	var out []string
+	for _, z := range zoneNames {
+		if strings.HasPrefix(strings.ToLower(z), prefix) {
+			out = append(out, z)
+		}
+	}
+	return out
+}
+
+// timezonesPage renders one page of the zones matching prefix along with
+// the prev/next buttons. Out-of-range pages are clamped.
+func timezonesPage(prefix string, page int) *discordgo.InteractionResponseData {
+	zones := matchZones(prefix)
+	if len(zones) == 0 {
+		return &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("No timezones start with %q.", prefix),
+		}
+	}
+
+	pages := (len(zones) + tzPageSize - 1) / tzPageSize
+	page = max(0, min(page, pages-1))
+	end := min((page+1)*tzPageSize, len(zones))
+
+	content := fmt.Sprintf("Timezones (page %d/%d):\n```\n%s\n```",
+		page+1, pages, strings.Join(zones[page*tzPageSize:end], "\n"))
+
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label: "◀ Prev", Style: discordgo.SecondaryButton,
+					CustomID: tzCustomID(prefix, page-1), Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label: "Next ▶", Style: discordgo.SecondaryButton,
+					CustomID: tzCustomID(prefix, page+1), Disabled: page == pages-1,
+				},
+			}},
+		},
+	}
+}
+
+// tzCustomID encodes a page request as "tz:<page>:<prefix>".
+func tzCustomID(prefix string, page int) string {
+	return "tz:" + strconv.Itoa(page) + ":" + prefix
+}
+
+// parseTZCustomID is the inverse of tzCustomID.
+func parseTZCustomID(id string) (prefix string, page int, ok bool) {
+	rest, ok := strings.CutPrefix(id, "tz:")
+	if !ok {
+		return "", 0, false
+	}
+	n, prefix, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", 0, false
+	}
+	page, err := strconv.Atoi(n)
+	return prefix, page, err == nil
+}
+
+// suggestZones returns up to n zone names close to what the user typed,
+// best first, matching either the whole name or just its city part.
+func suggestZones(input string, n int) []string {
+	in := strings.ToLower(strings.Join(strings.Fields(input), "_"))
+	if in == "" {
+		return nil
+	}
+	maxDist := max(2, len([]rune(in))/3)
+
+	type match struct {
+		zone string
+		dist int
+	}
+	var ms []match
+	for _, z := range zoneNames {
+		lz := strings.ToLower(z)
+		d := levenshtein(in, lz)
+		if i := strings.LastIndexByte(lz, '/'); i >= 0 {
+			d = min(d, levenshtein(in, lz[i+1:]))
+		}
+		if d <= maxDist {
+			ms = append(ms, match{z, d})
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].dist < ms[j].dist })
+
+	// a near-exact hit makes the distant ones noise
+	var out []string
+	for _, m := range ms[:min(n, len(ms))] {
+		if m.dist > ms[0].dist+1 {
+			break
+		}
+		out = append(out, m.zone)
+	}
+	return out
+}
+
+// levenshtein is the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// describeZoneTime is t's wall time with its UTC offset and, when the
+// zone has one, its abbreviation: "14:32 (UTC-04:00, EDT)".
+func describeZoneTime(t time.Time, h12 bool) string {
+	desc := formatClock(t.Hour(), t.Minute(), h12) + " (UTC" + t.Format("-07:00")
+	if abbr := t.Format("MST"); abbr[0] != '+' && abbr[0] != '-' {
+		desc += ", " + abbr
+	}
+	return desc + ")"
+}
+
+// handleCheckTZ tells the caller whether a timezone name is valid and what
+// time it is there right now.
+func handleCheckTZ(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	tz := strings.TrimSpace(ic.ApplicationCommandData().Options[0].StringValue())
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "" || tz == "Local" {
+		respond(s, ic, invalidTZ(tz))
+		return
+	}
+	now := clk.Now().In(loc)
+	respond(s, ic, fmt.Sprintf("✅ %s is valid. Currently %s.", tz, describeZoneTime(now, uses12h(ctx, db, ic.Member.User.ID))))
+}
+
+// invalidTZ is the reply for a timezone name that doesn't load, with a
+// suggestion when there's a close one.
+func invalidTZ(tz string) string {
+	if s := suggestZones(tz, 1); len(s) > 0 {
+		return fmt.Sprintf("Invalid timezone name. Did you mean %s?", s[0])
+	}
+	return "Invalid timezone name."
+}
+
+// tzFixes holds reminders waiting on the user to pick a suggested
+// timezone, keyed by the ID of the interaction that created them.
+var (
+	tzFixes   = map[string]pendingReminder{}
+	tzFixesMu sync.Mutex
+)
+
+// pendingReminder is a reminder held back until its creator answers a
+// question about it.
+type pendingReminder struct {
+	userID string
+	in     remindInput
+}
+
+// tzFixTTL is how long a "Did you mean" button keeps working.
+const tzFixTTL = 15 * time.Minute
+
+// tzFixPrompt is the reply to a reminder with a bad timezone: the error,
+// plus a button per suggestion that creates the reminder with it.
+func tzFixPrompt(ic *discordgo.InteractionCreate, in remindInput) *discordgo.InteractionResponseData {
+	sugg := suggestZones(in.TZ, 3)
+	if len(sugg) == 0 {
+		return &discordgo.InteractionResponseData{Content: "Invalid timezone name."}
+	}
+
+	tzFixesMu.Lock()
+	tzFixes[ic.ID] = pendingReminder{userID: ic.Member.User.ID, in: in}
+	tzFixesMu.Unlock()
+	time.AfterFunc(tzFixTTL, func() {
+		tzFixesMu.Lock()
+		delete(tzFixes, ic.ID)
+		tzFixesMu.Unlock()
+	})
+
+	var buttons []discordgo.MessageComponent
+	for _, z := range sugg {
+		buttons = append(buttons, discordgo.Button{
+			Label: z, Style: discordgo.PrimaryButton, CustomID: "tzfix:" + ic.ID + ":" + z,
+		})
+	}
+	return &discordgo.InteractionResponseData{
+		Content:    fmt.Sprintf("Invalid timezone name. Did you mean %s?", strings.Join(sugg, " or ")),
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+	}
+}
+
+// acceptTZFix creates the reminder a "Did you mean" button was offered
+// for, with the zone on the button.
+func acceptTZFix(db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate, customID string) {
+	key, zone, _ := strings.Cut(strings.TrimPrefix(customID, "tzfix:"), ":")
+
+	tzFixesMu.Lock()
+	fix, ok := tzFixes[key]
+	if ok && fix.userID == ic.Member.User.ID {
+		delete(tzFixes, key)
+	}
+	tzFixesMu.Unlock()
+
+	if !ok || fix.userID != ic.Member.User.ID {
+		msg := "That suggestion has expired. Run the command again."
+		if ok {
+			msg = "That isn't your reminder."
+		}
+		s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	// swap the buttons for a placeholder; createReminder's reply edits it
+	empty := []discordgo.MessageComponent{}
+	if err := s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: "Using " + zone + "…", Components: empty},
+	}); err != nil {
+		log.Printf("ack %s: %v", customID, err)
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	fix.in.TZ = zone
+	createReminder(ctx, db, clk, s, ic, fix.in)
+}
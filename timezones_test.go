@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"toronto", "toronto", 0},
+		{"torontoo", "toronto", 1},
+		{"tornoto", "toronto", 2},
+		{"zürich", "zurich", 1}, // one rune, not two bytes
+		{"", "paris", 5},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestZones(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the best suggestion, "" for none
+	}{
+		{"America/Toronto ", "America/Toronto"},
+		{"torontoo", "America/Toronto"},
+		{"america/new york", "America/New_York"},
+		{"Europe/Pari", "Europe/Paris"},
+		{"   ", ""},
+		{"definitely not a zone", ""},
+	}
+	for _, tt := range tests {
+		got := suggestZones(tt.input, 3)
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("suggestZones(%q) = %v, want none", tt.input, got)
+			}
+			continue
+		}
+		if len(got) == 0 || got[0] != tt.want {
+			t.Errorf("suggestZones(%q) = %v, want %s first", tt.input, got, tt.want)
+		}
+		if len(got) > 3 {
+			t.Errorf("suggestZones(%q) gave %d suggestions, asked for 3", tt.input, len(got))
+		}
+	}
+}
+
+func TestInvalidTZ(t *testing.T) {
+	if got, want := invalidTZ("torontoo"), "Invalid timezone name. Did you mean America/Toronto?"; got != want {
+		t.Errorf("invalidTZ = %q, want %q", got, want)
+	}
+	if got := invalidTZ("definitely not a zone"); got != "Invalid timezone name." {
+		t.Errorf("invalidTZ with nothing close = %q", got)
+	}
+}
+
+func TestTZFixPromptButtons(t *testing.T) {
+	ic := slash("remind", "u1")
+	ic.ID = "i1"
+	t.Cleanup(func() {
+		tzFixesMu.Lock()
+		delete(tzFixes, "i1")
+		tzFixesMu.Unlock()
+	})
+	data := tzFixPrompt(ic, remindInput{Time: "09:00", TZ: "torontoo", Message: "hi"})
+	if len(data.Components) != 1 {
+		t.Fatalf("components = %v, want one row of buttons", data.Components)
+	}
+	var ids []string
+	for _, c := range data.Components[0].(discordgo.ActionsRow).Components {
+		ids = append(ids, c.(discordgo.Button).CustomID)
+	}
+	if !slices.Contains(ids, "tzfix:i1:America/Toronto") {
+		t.Errorf("buttons %v don't offer America/Toronto", ids)
+	}
+
+	// someone else pressing the button doesn't get the reminder
+	s, f := newFakeDiscord(nil)
+	press := slash("remind", "u2")
+	acceptTZFix(nil, realClock{}, s, press, "tzfix:i1:America/Toronto")
+	var refused bool
+	for _, c := range f.calls {
+		refused = refused || (c.Method == http.MethodPost && strings.Contains(string(c.Body), "That isn't your reminder."))
+	}
+	if !refused {
+		t.Error("another user's press wasn't refused")
+	}
+	tzFixesMu.Lock()
+	_, kept := tzFixes["i1"]
+	tzFixesMu.Unlock()
+	if !kept {
+		t.Error("another user's press used up the suggestion")
+	}
+}
+
+func TestDescribeZoneTime(t *testing.T) {
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+	for _, c := range []struct {
+		zone string
+		at   time.Time
+		h12  bool
+		want string
+	}{
+		{"America/New_York", utc(7, 1, 18, 32), false, "14:32 (UTC-04:00, EDT)"},
+		{"America/New_York", utc(1, 15, 19, 32), false, "14:32 (UTC-05:00, EST)"},
+		{"America/New_York", utc(7, 1, 18, 32), true, "2:32 PM (UTC-04:00, EDT)"},
+		{"Asia/Kolkata", utc(7, 1, 9, 0), false, "14:30 (UTC+05:30, IST)"},
+		{"Asia/Kathmandu", utc(7, 1, 0, 0), false, "05:45 (UTC+05:45)"}, // the zone's abbreviation is just +0545
+		{"Asia/Dubai", utc(7, 1, 20, 0), true, "12:00 AM (UTC+04:00)"},
+		{"UTC", utc(3, 29, 0, 59), false, "00:59 (UTC+00:00, UTC)"},
+		{"Europe/London", utc(3, 29, 0, 59), false, "00:59 (UTC+00:00, GMT)"}, // a minute before BST
+		{"Europe/London", utc(3, 29, 1, 0), false, "02:00 (UTC+01:00, BST)"},
+	} {
+		loc, err := time.LoadLocation(c.zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describeZoneTime(c.at.In(loc), c.h12); got != c.want {
+			t.Errorf("%s at %s: %q, want %q", c.zone, c.at.Format(time.RFC3339), got, c.want)
+		}
+	}
+}
+
+func TestCheckTZRejects(t *testing.T) {
+	for _, tz := range []string{"torontoo", "", "Local"} {
+		s, f := newFakeDiscord(nil)
+		handleCheckTZ(context.Background(), nil, realClock{}, s, slash("checktz", "u1", "timezone", tz))
+		if got := f.replies(t); len(got) != 1 || got[0] != invalidTZ(tz) {
+			t.Errorf("%q: replies = %q, want %q", tz, got, invalidTZ(tz))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// inVoice reports whether userID is in one of guildID's voice channels,
+// the AFK channel not counting. known is false when the guild isn't in
+// st, so there's no telling.
+func inVoice(st *discordgo.State, guildID, userID string) (in, known bool) {
+	if st == nil || !st.TrackVoice {
+		return false, false
+	}
+	g, err := st.Guild(guildID)
+	if err != nil {
+		return false, false
+	}
+	vs, err := st.VoiceState(guildID, userID)
+	if err != nil {
+		return false, true
+	}
+	return vs.ChannelID != "" && vs.ChannelID != g.AfkChannelID, true
+}
+
+// voiceGated reports whether a fire of r is to be skipped because its
+// owner isn't in voice. If their voice state can't be seen the fire goes
+// ahead, since a missed reminder is worse than an unneeded one.
+func voiceGated(s *discordgo.Session, r Reminder) bool {
+	if !r.VoiceOnly {
+		return false
+	}
+	in, known := inVoice(s.State, r.GuildID, r.UserID)
+	if !known {
+		log.Printf("reminder %d: voice state of %s in %s not cached, firing anyway", r.ID, r.UserID, r.GuildID)
+		return false
+	}
+	return !in
+}
+
+// handleVoiceOnly makes a reminder fire only while its owner is in a
+// voice channel, or always again.
+func handleVoiceOnly(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var on bool
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "enabled":
+			on = opt.BoolValue()
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	if on && r.GuildID == "" {
+		respond(s, ic, "Only reminders in a server can depend on voice.")
+		return
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET voice_only = $2, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, on).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the voice setting", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if on {
+		respond(s, ic, fmt.Sprintf("🎧 Reminder %d will only fire while you're in a voice channel here.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d will fire whether you're in voice or not.", id))
+}
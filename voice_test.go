@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// voiceState is a state tracking voice for server g1, whose AFK channel
+// is v-afk: u-talking is in v1, u-afk in v-afk, and u-away in no channel.
+func voiceState(t *testing.T) *discordgo.State {
+	t.Helper()
+	st := discordgo.NewState()
+	if err := st.GuildAdd(&discordgo.Guild{ID: "g1", AfkChannelID: "v-afk", VoiceStates: []*discordgo.VoiceState{
+		{GuildID: "g1", UserID: "u-talking", ChannelID: "v1"},
+		{GuildID: "g1", UserID: "u-afk", ChannelID: "v-afk"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestInVoice(t *testing.T) {
+	st := voiceState(t)
+	tests := []struct {
+		guild, user string
+		in, known   bool
+	}{
+		{"g1", "u-talking", true, true},
+		{"g1", "u-afk", false, true},
+		{"g1", "u-away", false, true},
+		{"g-uncached", "u-talking", false, false},
+	}
+	for _, tt := range tests {
+		in, known := inVoice(st, tt.guild, tt.user)
+		if in != tt.in || known != tt.known {
+			t.Errorf("inVoice(%s, %s) = %t, %t; want %t, %t", tt.guild, tt.user, in, known, tt.in, tt.known)
+		}
+	}
+
+	st.TrackVoice = false
+	if _, known := inVoice(st, "g1", "u-talking"); known {
+		t.Error("claimed to know voice states it doesn't track")
+	}
+	if _, known := inVoice(nil, "g1", "u-talking"); known {
+		t.Error("claimed to know voice states without a state")
+	}
+}
+
+func TestVoiceGated(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := &discordgo.Session{State: voiceState(t)}
+	tests := []struct {
+		name string
+		r    Reminder
+		want bool
+	}{
+		{"not voice only", Reminder{ID: 1, GuildID: "g1", UserID: "u-away"}, false},
+		{"in voice", Reminder{ID: 2, GuildID: "g1", UserID: "u-talking", VoiceOnly: true}, false},
+		{"afk", Reminder{ID: 3, GuildID: "g1", UserID: "u-afk", VoiceOnly: true}, true},
+		{"not in voice", Reminder{ID: 4, GuildID: "g1", UserID: "u-away", VoiceOnly: true}, true},
+		{"not cached", Reminder{ID: 5, GuildID: "g-uncached", UserID: "u-away", VoiceOnly: true}, false},
+	}
+	for _, tt := range tests {
+		if got := voiceGated(s, tt.r); got != tt.want {
+			t.Errorf("%s: voiceGated = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+	if got := buf.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "reminder 5: voice state of u-away in g-uncached not cached, firing anyway") {
+		t.Errorf("log = %q, want one line about the uncached server", got)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// webhookKey encrypts webhook tokens at rest, since a token alone is
+// enough to post in the channel. It's derived from WEBHOOK_KEY; without one
+// webhook delivery is off.
+var webhookKey []byte
+
+func setWebhookKey(secret string) {
+	if secret == "" {
+		return
+	}
+	sum := sha256.Sum256([]byte(secret))
+	webhookKey = sum[:]
+}
+
+func tokenCipher() (cipher.AEAD, error) {
+	if webhookKey == nil {
+		return nil, errors.New("WEBHOOK_KEY not set")
+	}
+	block, err := aes.NewCipher(webhookKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealToken encrypts a webhook token for storage, nonce first.
+func sealToken(token string) ([]byte, error) {
+	aead, err := tokenCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, []byte(token), nil), nil
+}
+
+// openToken reverses sealToken.
+func openToken(sealed []byte) (string, error) {
+	aead, err := tokenCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed token too short")
+	}
+	nonce, box := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	token, err := aead.Open(nil, nonce, box, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// webhook is where a reminder posts when it's delivered as a webhook.
+// Threads share their parent channel's webhook.
+type webhook struct {
+	ID, Token string
+	ThreadID  string // set when posting into a thread
+}
+
+// webhookParent is the channel whose webhook posts in channelID: the
+// channel itself, or a thread's parent along with the thread.
+func webhookParent(s *discordgo.Session, channelID string) (parent, threadID string, err error) {
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		ch, err = s.Channel(channelID)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if ch.IsThread() {
+		return ch.ParentID, channelID, nil
+	}
+	return channelID, "", nil
+}
+
+// channelWebhook finds the bot's webhook for channelID, creating it first
+// if create is set.
+func channelWebhook(ctx context.Context, db *pgxpool.Pool, s *discordgo.Session, channelID string, create bool) (*webhook, error) {
+	hook := &webhook{}
+	parent, threadID, err := webhookParent(s, channelID)
+	if err != nil {
+		return nil, err
+	}
+	hook.ThreadID = threadID
+
+	var sealed []byte
+	err = db.QueryRow(ctx,
+		`SELECT webhook_id, token FROM webhooks WHERE channel_id=$1`, parent).Scan(&hook.ID, &sealed)
+	if err == nil {
+		hook.Token, err = openToken(sealed)
+		return hook, err
+	}
+	if !errors.Is(err, pgx.ErrNoRows) || !create {
+		return nil, err
+	}
+
+	w, err := s.WebhookCreate(parent, "KermitTheBot", "")
+	if err != nil {
+		return nil, err
+	}
+	if sealed, err = sealToken(w.Token); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(ctx,
+		`INSERT INTO webhooks (channel_id, webhook_id, token) VALUES ($1,$2,$3)
+		 ON CONFLICT (channel_id) DO UPDATE SET webhook_id = EXCLUDED.webhook_id, token = EXCLUDED.token`,
+		parent, w.ID, sealed); err != nil {
+		return nil, err
+	}
+	hook.ID, hook.Token = w.ID, w.Token
+	return hook, nil
+}
+
+// webhookParams is the payload for one chunk of r posted as a webhook,
+// under r's name and avatar. Only the first chunk can ping, and it carries
+// the same buttons as a post by the bot; the bot owns the webhook, so
+// Discord routes their presses back to it.
+func webhookParams(r Reminder, content string, first bool) *discordgo.WebhookParams {
+	p := &discordgo.WebhookParams{
+		Content:         content,
+		Username:        r.WebhookName,
+		AvatarURL:       r.WebhookAvatar,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Flags:           r.messageFlags(),
+	}
+	if first {
+		p.AllowedMentions.Users = r.pinged()
+		p.Components = firedButtons(r)
+	}
+	return p
+}
+
+// sendWebhook posts chunks of r through hook and returns the first message.
+func sendWebhook(s *discordgo.Session, r Reminder, hook *webhook, chunks []string) (*discordgo.Message, error) {
+	var first *discordgo.Message
+	for i, c := range chunks {
+		p := webhookParams(r, c, i == 0)
+		var m *discordgo.Message
+		var err error
+		sendLimit.take(1)
+		if hook.ThreadID != "" {
+			m, err = s.WebhookThreadExecute(hook.ID, hook.Token, true, hook.ThreadID, p)
+		} else {
+			m, err = s.WebhookExecute(hook.ID, hook.Token, true, p)
+		}
+		if err != nil {
+			return first, err
+		}
+		if first == nil {
+			first = m
+		}
+	}
+	return first, nil
+}
+
+// impersonates says what in guildID a webhook named name would pass for,
+// a member or a role going by that name, or "" if nothing does.
+func impersonates(s *discordgo.Session, guildID, name string) (string, error) {
+	var roles []*discordgo.Role
+	if g, err := s.State.Guild(guildID); err == nil {
+		roles = g.Roles
+	} else if roles, err = s.GuildRoles(guildID); err != nil {
+		return "", err
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, name) {
+			return "the " + role.Name + " role", nil
+		}
+	}
+
+	// the search matches by prefix on user and nicknames; check each hit
+	members, err := s.GuildMembersSearch(guildID, name, 100)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range members {
+		if m.User == nil {
+			continue
+		}
+		for _, n := range []string{m.Nick, m.User.GlobalName, m.User.Username} {
+			if n != "" && strings.EqualFold(n, name) {
+				return "<@" + m.User.ID + ">", nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// handleWebhook sets the name and avatar a reminder is posted under, or
+// with no name goes back to posting as the bot. Owner only, and only by
+// someone who could make such a webhook in the channel themselves.
+func handleWebhook(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref string
+	var name, avatar string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "name":
+			name = strings.TrimSpace(opt.StringValue())
+		case "avatar":
+			avatar = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+
+	if name != "" {
+		if webhookKey == nil {
+			respond(s, ic, "Webhook delivery isn't set up on this bot.")
+			return
+		}
+		if r.Poll != nil {
+			respond(s, ic, "Polls can't be posted through a webhook.")
+			return
+		}
+		if strings.Contains(strings.ToLower(name), "discord") {
+			respond(s, ic, "Discord doesn't allow \"discord\" in webhook names.")
+			return
+		}
+		if avatar != "" && !strings.HasPrefix(avatar, "https://") {
+			respond(s, ic, "The avatar must be an https:// image link.")
+			return
+		}
+		parent, _, err := webhookParent(s, r.ChannelID)
+		if err != nil {
+			respondErr(s, ic, "setting up the webhook", err)
+			return
+		}
+		if p, err := s.UserChannelPermissions(ic.Member.User.ID, parent); err != nil || p&discordgo.PermissionManageWebhooks == 0 {
+			respond(s, ic, fmt.Sprintf("You need the Manage Webhooks permission in <#%s> for that.", parent))
+			return
+		}
+		who, err := impersonates(s, r.GuildID, name)
+		if err != nil {
+			respondErr(s, ic, "checking the name", err)
+			return
+		}
+		if who != "" {
+			respond(s, ic, fmt.Sprintf("**%s** would look like %s, pick another name.", escapeMessage(name), who))
+			return
+		}
+		if _, err := channelWebhook(ctx, db, s, r.ChannelID, true); err != nil {
+			if discordErrCode(err) == discordgo.ErrCodeMissingPermissions {
+				respond(s, ic, fmt.Sprintf("I need the Manage Webhooks permission in <#%s> for that.", r.ChannelID))
+				return
+			}
+			respondErr(s, ic, "setting up the webhook", err)
+			return
+		}
+	} else {
+		avatar = ""
+	}
+
+	if err := db.QueryRow(ctx,
+		`UPDATE reminders SET webhook_name = $2, webhook_avatar = $3, updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, name, avatar).Scan(reminderDest(&r)...); err != nil {
+		respondErr(s, ic, "saving the webhook", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	if name == "" {
+		respond(s, ic, fmt.Sprintf("Reminder %d will be posted by me again.", id))
+		return
+	}
+	respond(s, ic, fmt.Sprintf("Reminder %d will be posted as **%s** ✅", id, name))
+}
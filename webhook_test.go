@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestWebhookParams(t *testing.T) {
+	r := Reminder{UserID: "1", Extra: []string{"2"}, WebhookName: "Standup Bot", WebhookAvatar: "https://example.com/a.png", Silent: true}
+	p := webhookParams(r, "<@1> <@2> standup", true)
+	if p.Username != "Standup Bot" || p.AvatarURL != "https://example.com/a.png" || p.Content != "<@1> <@2> standup" {
+		t.Errorf("params = %+v", p)
+	}
+	if !slices.Equal(p.AllowedMentions.Users, []string{"1", "2"}) {
+		t.Errorf("first chunk pings %v, want both users", p.AllowedMentions.Users)
+	}
+	if p.Flags&discordgo.MessageFlagsSuppressNotifications == 0 {
+		t.Error("a silent reminder's webhook post isn't silent")
+	}
+	if len(p.Components) != 1 {
+		t.Errorf("first chunk has %d component rows, want the fired buttons", len(p.Components))
+	}
+	rest := webhookParams(r, "more", false)
+	if len(rest.AllowedMentions.Users) != 0 {
+		t.Errorf("later chunk pings %v, want nobody", rest.AllowedMentions.Users)
+	}
+	if len(rest.Components) != 0 {
+		t.Error("a later chunk has buttons too")
+	}
+}
+
+func TestImpersonates(t *testing.T) {
+	s, _ := newFakeDiscord(func(c discordCall) (int, any) {
+		if c.Path == "/guilds/g1/members/search" {
+			return http.StatusOK, []map[string]any{
+				{"nick": "Standup Bot Jr", "user": map[string]any{"id": "u2", "username": "jr"}},
+				{"nick": "", "user": map[string]any{"id": "u3", "username": "kermit", "global_name": "Kermit"}},
+			}
+		}
+		return 0, nil
+	})
+	if err := s.State.GuildAdd(&discordgo.Guild{ID: "g1", Roles: []*discordgo.Role{{ID: "r1", Name: "Moderators"}}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ name, want string }{
+		{"moderators", "the Moderators role"},
+		{"KERMIT", "<@u3>"},
+		{"Standup Bot", ""}, // only a prefix of a nickname
+	}
+	for _, tt := range tests {
+		got, err := impersonates(s, "g1", tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("impersonates(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestWebhookNeedsManageWebhooks(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	old := webhookKey
+	t.Cleanup(func() {
+		webhookKey = old
+		db.Exec(context.Background(), `DELETE FROM reminders WHERE user_id = 'u1' AND message = 'test-webhook-perm'`)
+	})
+	setWebhookKey("hunter2")
+
+	var id int
+	if err := db.QueryRow(ctx,
+		`INSERT INTO reminders (user_id, channel_id, guild_id, message, hour, minute, tz, active)
+		 VALUES ('u1', 'c1', 'g1', 'test-webhook-perm', 9, 0, 'UTC', false) RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	s, f := postingState(t)
+	handleWebhook(ctx, db, realClock{}, s, slash("webhook", "u1", "id", strconv.Itoa(id), "name", "Standup Bot"))
+	if got := f.replies(t); len(got) != 1 || got[0] != "You need the Manage Webhooks permission in <#c1> for that." {
+		t.Errorf("replies = %q", got)
+	}
+	for _, c := range f.calls {
+		if c.Method == http.MethodPost && strings.HasSuffix(c.Path, "/webhooks") {
+			t.Error("created a webhook for someone without Manage Webhooks")
+		}
+	}
+}
+
+func TestSealTokenRoundTrip(t *testing.T) {
+	old := webhookKey
+	t.Cleanup(func() { webhookKey = old })
+
+	webhookKey = nil
+	if _, err := sealToken("secret"); err == nil {
+		t.Fatal("sealed a token with no WEBHOOK_KEY")
+	}
+
+	setWebhookKey("hunter2")
+	sealed, err := sealToken("webhook-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sealed), "webhook-token") {
+		t.Error("the token is stored in the clear")
+	}
+	if got, err := openToken(sealed); err != nil || got != "webhook-token" {
+		t.Errorf("openToken = %q, %v", got, err)
+	}
+
+	setWebhookKey("another key")
+	if _, err := openToken(sealed); err == nil {
+		t.Error("a token sealed under one key opened under another")
+	}
+}
+
+func TestSendThroughWebhook(t *testing.T) {
+	s, f := newFakeDiscord(nil)
+	r := Reminder{ID: 1, ChannelID: "10", UserID: "1", Message: "standup", TZ: "UTC", WebhookName: "Standup Bot"}
+	d := delivery{hook: &webhook{ID: "h1", Token: "tok", ThreadID: "t1"}}
+	if _, err := sendReminder(s, r, d); err != nil {
+		t.Fatal(err)
+	}
+
+	var execs []discordCall
+	for _, c := range f.calls {
+		if c.Method == http.MethodPost && c.Path == "/webhooks/h1/tok" {
+			execs = append(execs, c)
+		}
+	}
+	if len(execs) != 1 || len(f.posts(t)) != 0 {
+		t.Fatalf("%d webhook posts and %d bot posts, want just one webhook post", len(execs), len(f.posts(t)))
+	}
+	if q, _ := url.ParseQuery(execs[0].Query); q.Get("thread_id") != "t1" {
+		t.Errorf("query %q, want the post in thread t1", execs[0].Query)
+	}
+	var body struct {
+		Content  string `json:"content"`
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(execs[0].Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Content != "<@1> standup" || body.Username != "Standup Bot" {
+		t.Errorf("webhook payload = %+v", body)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// windowSchedule is a cron.Schedule firing once a day at a minute picked
+// from [from, to], both minutes of the day. The pick is random-looking
+// but fixed per reminder and date, so /next, heads-ups and restarts all
+// agree on today's time while tomorrow's is still a surprise.
+type windowSchedule struct {
+	seed     int
+	from, to int
+	loc      *time.Location
+}
+
+// Next returns the first fire strictly after t. Each day has exactly one
+// fire, so it's today's or tomorrow's.
+func (s windowSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	for d := range 2 {
+		if at := s.on(t.Year(), t.Month(), t.Day()+d); at.After(t) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// on is the fire time on the given date.
+func (s windowSchedule) on(year int, month time.Month, day int) time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, s.loc)
+	m := s.from + windowPick(s.seed, date, s.to-s.from+1)
+	return time.Date(date.Year(), date.Month(), date.Day(), m/60, m%60, 0, 0, s.loc)
+}
+
+// windowPick maps a reminder and a date to a number in [0, n).
+func windowPick(seed int, date time.Time, n int) int {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s", seed, date.Format("2006-01-02"))
+	return int(h.Sum64() % uint64(n))
+}
+
+// handleWindow makes a reminder fire once a day at a random time between
+// two times of day, for nudges that shouldn't be predictable. /convert
+// turns it back into a fixed-time reminder. Owner only.
+func handleWindow(ctx context.Context, db *pgxpool.Pool, clk Clock, s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	var ref, rawFrom, rawTo string
+	for _, opt := range ic.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			ref = opt.StringValue() // "42", "standup"
+		case "from":
+			rawFrom = opt.StringValue() // "14:00"
+		case "to":
+			rawTo = opt.StringValue() // "16:00"
+		}
+	}
+
+	fh, fm, err := parseClock(rawFrom)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	th, tm, err := parseClock(rawTo)
+	if err != nil {
+		respond(s, ic, err.Error())
+		return
+	}
+	from, to := fh*60+fm, th*60+tm
+	if to <= from {
+		respond(s, ic, "The window has to end after it starts, on the same day.")
+		return
+	}
+
+	id, ok := resolveRef(ctx, db, s, ic, ref)
+	if !ok {
+		return
+	}
+	r, err := loadReminder(ctx, db, id)
+	if err != nil || r.UserID != ic.Member.User.ID {
+		respond(s, ic, fmt.Sprintf("You don't have a reminder %d.", id))
+		return
+	}
+	switch modeOrDaily(r.Mode) {
+	case modeDaily, modeWindow:
+	default:
+		respond(s, ic, "Only daily reminders can fire at a random time. /convert it to daily first.")
+		return
+	}
+
+	// hour and minute keep the window's start for sorting and listings
+	err = db.QueryRow(ctx,
+		`UPDATE reminders SET mode = $2, window_start = $3, window_end = $4, hour = $5, minute = $6,
+		       updated_at = now()
+		  WHERE id = $1
+		RETURNING `+reminderColumns, id, modeWindow, from, to, fh, fm).Scan(reminderDest(&r)...)
+	if isUniqueViolation(err) {
+		respond(s, ic, fmt.Sprintf("You already have the same reminder at %02d:%02d.", fh, fm))
+		return
+	}
+	if err != nil {
+		respondErr(s, ic, "updating your reminder", err)
+		return
+	}
+	if r.Active {
+		if err := reschedule(db, clk, s, r); err != nil {
+			log.Printf("reschedule reminder %d: %v", r.ID, err)
+		}
+	}
+
+	respond(s, ic, fmt.Sprintf("🎲 Reminder %d now fires %s.", id, describeSchedule(r)))
+}
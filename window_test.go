@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowPickInBounds(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, n := range []int{1, 2, 7, 121, 24 * 60} {
+		seen := map[int]bool{}
+		for seed := 1; seed <= 20; seed++ {
+			for d := range 50 {
+				p := windowPick(seed, start.AddDate(0, 0, d), n)
+				if p < 0 || p >= n {
+					t.Fatalf("windowPick(%d, day %d, %d) = %d, out of [0, %d)", seed, d, n, p, n)
+				}
+				seen[p] = true
+			}
+		}
+		if want := min(n, 7); len(seen) < want {
+			t.Errorf("n = %d: only %d distinct picks in 1000 tries", n, len(seen))
+		}
+	}
+}
+
+func TestWindowPickIsStable(t *testing.T) {
+	date := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	if windowPick(42, date, 121) != windowPick(42, date, 121) {
+		t.Error("the same reminder and date picked different minutes")
+	}
+	// midnight elsewhere is still the same date
+	paris, _ := time.LoadLocation("Europe/Paris")
+	if windowPick(42, date, 121) != windowPick(42, time.Date(2026, 10, 14, 0, 0, 0, 0, paris), 121) {
+		t.Error("the pick depends on more than the date")
+	}
+}
+
+func TestWindowScheduleStaysInBounds(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	from, to := 14*60, 16*60+30
+	sched := windowSchedule{seed: 7, from: from, to: to, loc: paris}
+
+	// a year from the day after the spring DST change, through the autumn one
+	t0 := time.Date(2026, 3, 30, 0, 0, 0, 0, paris)
+	at := t0
+	for d := range 365 {
+		next := sched.Next(at)
+		if next.IsZero() {
+			t.Fatalf("no fire after %s", at)
+		}
+		local := next.In(paris)
+		if m := local.Hour()*60 + local.Minute(); m < from || m > to || local.Second() != 0 {
+			t.Fatalf("fire %d at %s, outside 14:00-16:30", d, local.Format("2006-01-02 15:04:05"))
+		}
+		if want := t0.AddDate(0, 0, d); local.YearDay() != want.YearDay() || local.Year() != want.Year() {
+			t.Fatalf("fire %d on %s, want %s: one a day", d, local.Format("2006-01-02"), want.Format("2006-01-02"))
+		}
+		at = next
+	}
+}
+
+func TestWindowScheduleNext(t *testing.T) {
+	sched := windowSchedule{seed: 3, from: 9 * 60, to: 17 * 60, loc: time.UTC}
+	today := sched.on(2026, 10, 14)
+
+	if got := sched.Next(time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)); !got.Equal(today) {
+		t.Errorf("before the window: next = %s, want today's %s", got, today)
+	}
+	tomorrow := sched.on(2026, 10, 15)
+	if got := sched.Next(today); !got.Equal(tomorrow) {
+		t.Errorf("at today's fire: next = %s, want tomorrow's %s", got, tomorrow)
+	}
+	if got := sched.Next(time.Date(2026, 10, 14, 23, 59, 0, 0, time.UTC)); !got.Equal(tomorrow) {
+		t.Errorf("late evening: next = %s, want tomorrow's %s", got, tomorrow)
+	}
+
+	// a one-minute-wide window is exact
+	exact := windowSchedule{seed: 3, from: 14 * 60, to: 14 * 60, loc: time.UTC}
+	if got := exact.on(2026, 10, 14); got.Hour() != 14 || got.Minute() != 0 {
+		t.Errorf("14:00-14:00 picked %s", got.Format("15:04"))
+	}
+}
+
+func TestWindowReminderSchedule(t *testing.T) {
+	r := Reminder{ID: 12, Mode: modeWindow, WindowStart: 14 * 60, WindowEnd: 16 * 60, TZ: "Asia/Tokyo"}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	times, err := nextFires(r, time.Date(2026, 10, 14, 0, 0, 0, 0, tokyo), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, at := range times {
+		if at.Location().String() != "Asia/Tokyo" || at.Hour() < 14 || at.Hour()*60+at.Minute() > 16*60 || at.Day() != 14+i {
+			t.Errorf("fire %d at %s, want 14:00-16:00 Tokyo on day %d", i, at, 14+i)
+		}
+	}
+}